@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"certview/pkg/cert"
+	"certview/pkg/html"
+	"certview/pkg/report"
+)
+
+// RunBatch analyzes every target in the list file (or stdin for "-") with
+// at most workers concurrent analyses and prints a combined report. Each
+// target is also checked against the policy for the summary.
+func RunBatch(list string, opts CLIOptions, policy cert.Policy, workers int) {
+	if opts.Format != "html" && opts.Format != "json" && opts.Format != "csv" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported output format %q (use html, json or csv)\n", opts.Format)
+		os.Exit(1)
+	}
+	// One dial address or private key cannot belong to every target
+	if opts.Connect != "" {
+		fmt.Fprintf(os.Stderr, "Error: -connect cannot be used with -batch, add connect= to the target lines instead\n")
+		os.Exit(1)
+	}
+	if opts.KeyFile != "" {
+		fmt.Fprintf(os.Stderr, "Error: -key cannot be used with -batch\n")
+		os.Exit(1)
+	}
+
+	targets, title, err := readTargetList(list)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no targets in %s\n", title)
+		os.Exit(1)
+	}
+
+	store, logs, err := loadVerifiers(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Analyzing %d target(s) with %d worker(s)\n", len(targets), workers)
+	results := analyzeBatch(targets, opts, policy, store, logs, workers)
+
+	var output string
+	switch opts.Format {
+	case "json":
+		output, err = report.GenerateBatchJSON(results, title)
+	case "csv":
+		output, err = report.GenerateBatchCSV(results)
+	default:
+		output, err = html.GenerateBatchHTML(results, title)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating %s: %v\n", strings.ToUpper(opts.Format), err)
+		os.Exit(1)
+	}
+
+	fmt.Print(output)
+}
+
+func readTargetList(list string) ([]cert.BatchTarget, string, error) {
+	var r io.Reader = os.Stdin
+	title := "Batch: stdin"
+	if list != "-" {
+		f, err := os.Open(list)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open target list: %v", err)
+		}
+		defer f.Close()
+		r = f
+		title = "Batch: " + list
+	}
+
+	targets, err := cert.ParseTargetList(r)
+	return targets, title, err
+}
+
+// analyzeBatch runs the analyses on a bounded worker pool. Results are
+// returned in the order of the target list.
+func analyzeBatch(targets []cert.BatchTarget, opts CLIOptions, policy cert.Policy, store *cert.TrustStore, logs *cert.CTLogList, workers int) []cert.BatchResult {
+	results := make([]cert.BatchResult, len(targets))
+	runWorkers(len(targets), workers, func(i int) {
+		results[i] = analyzeTarget(targets[i], opts, policy, store, logs)
+	})
+	return results
+}
+
+// runWorkers calls job for 0..n-1 on at most workers goroutines and waits
+// for all of them to finish.
+func runWorkers(n, workers int, job func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				job(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func analyzeTarget(target cert.BatchTarget, opts CLIOptions, policy cert.Policy, store *cert.TrustStore, logs *cert.CTLogList) cert.BatchResult {
+	if target.StartTLS != "" {
+		opts.StartTLS = target.StartTLS
+	}
+	if target.Connect != "" {
+		opts.Connect = target.Connect
+	}
+	if target.SNI != "" {
+		opts.SNI = target.SNI
+	}
+	if target.NoSNI {
+		opts.NoSNI = true
+	}
+	if target.ExpectHost != "" {
+		opts.ExpectHost = target.ExpectHost
+	}
+
+	result := cert.BatchResult{Target: target, Title: target.Input}
+	chainInfo, title, err := analyzeInputWith(target.Input, opts, store, logs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", target.Input, err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Title, result.Chain = title, chainInfo
+	result.Check = policy.Evaluate(chainInfo, time.Now())
+	return result
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"certview/pkg/cert"
+)
+
+func TestRunWorkers(t *testing.T) {
+	tests := []struct {
+		name        string
+		jobs        int
+		workers     int
+		wantRunning int // jobs that must run at the same time
+	}{
+		{name: "one worker", jobs: 10, workers: 1, wantRunning: 1},
+		{name: "no workers runs one", jobs: 5, workers: 0, wantRunning: 1},
+		{name: "bounded pool", jobs: 20, workers: 4, wantRunning: 4},
+		{name: "more workers than jobs", jobs: 3, workers: 8, wantRunning: 3},
+		{name: "no jobs", jobs: 0, workers: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := make(map[int]int)
+			var running, peak atomic.Int32
+			full := make(chan struct{})
+			var fullOnce sync.Once
+
+			runWorkers(tt.jobs, tt.workers, func(i int) {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				if int(n) == tt.wantRunning {
+					fullOnce.Do(func() { close(full) })
+				}
+				// Hold the first jobs until the pool is full, so that the
+				// peak is reached regardless of scheduling
+				select {
+				case <-full:
+				case <-time.After(5 * time.Second):
+				}
+				mu.Lock()
+				calls[i]++
+				mu.Unlock()
+			})
+
+			if len(calls) != tt.jobs {
+				t.Errorf("%d jobs ran, want %d", len(calls), tt.jobs)
+			}
+			for i, n := range calls {
+				if n != 1 {
+					t.Errorf("job %d ran %d times", i, n)
+				}
+			}
+			if got := int(peak.Load()); got != tt.wantRunning {
+				t.Errorf("%d jobs ran at once, want %d", got, tt.wantRunning)
+			}
+		})
+	}
+}
+
+// newSlowTLSServer delays every handshake, so that a target listed first
+// finishes after the ones listed later.
+func newSlowTLSServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			time.Sleep(delay)
+			return nil, nil
+		},
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAnalyzeBatch(t *testing.T) {
+	slow := newSlowTLSServer(t, 200*time.Millisecond)
+	fast := httptest.NewTLSServer(http.NotFoundHandler())
+	defer fast.Close()
+	closed := httptest.NewTLSServer(http.NotFoundHandler())
+	closedAddr := closed.Listener.Addr().String()
+	closed.Close()
+
+	// The httptest certificate is self-signed and covers 127.0.0.1 and
+	// example.com
+	serverCert := fast.Certificate()
+	store := &cert.TrustStore{Name: "test", Pool: x509.NewCertPool(), Certificates: []*x509.Certificate{serverCert}}
+	store.Pool.AddCert(serverCert)
+
+	dir := t.TempDir()
+	pemFile := filepath.Join(dir, "server.pem")
+	if err := os.WriteFile(pemFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list := strings.Join([]string{
+		"# slow first, so that it completes last",
+		slow.Listener.Addr().String(),
+		fast.Listener.Addr().String() + " expect-host=www.example.org",
+		closedAddr,
+		"www.example.com:443 connect=" + fast.Listener.Addr().String() + " expect-host=example.com",
+		filepath.Join(dir, "missing.pem"),
+		pemFile,
+	}, "\n")
+	targets, err := cert.ParseTargetList(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		status    int
+		wantError string
+	}{
+		{status: cert.StatusOK},
+		{status: cert.StatusCritical},
+		{status: cert.StatusUnknown, wantError: "failed to connect"},
+		{status: cert.StatusOK},
+		{status: cert.StatusUnknown, wantError: "missing.pem"},
+		{status: cert.StatusOK},
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("%d targets, want %d", len(targets), len(want))
+	}
+
+	for _, workers := range []int{1, 3, len(targets)} {
+		policy := cert.Policy{WarnDays: 30, CritDays: 7, RequireValidChain: true}
+		results := analyzeBatch(targets, CLIOptions{}, policy, store, nil, workers)
+		if len(results) != len(targets) {
+			t.Fatalf("%d workers: %d results, want %d", workers, len(results), len(targets))
+		}
+		for i, result := range results {
+			if result.Target != targets[i] {
+				t.Errorf("%d workers: result %d is for line %d (%s), want line %d", workers, i, result.Target.Line, result.Target.Input, targets[i].Line)
+			}
+			if result.Status() != want[i].status {
+				t.Errorf("%d workers: %s: status %d, want %d (%s)", workers, result.Target.Input, result.Status(), want[i].status, result.Error)
+			}
+			if want[i].wantError == "" {
+				if result.Error != "" || result.Chain == nil || result.Check == nil {
+					t.Errorf("%d workers: %s: error %q", workers, result.Target.Input, result.Error)
+				}
+				continue
+			}
+			if !strings.Contains(result.Error, want[i].wantError) || result.Chain != nil || result.Check != nil {
+				t.Errorf("%d workers: %s: error %q, want %q and no chain", workers, result.Target.Input, result.Error, want[i].wantError)
+			}
+		}
+
+		summary := cert.SummarizeBatch(results)
+		if summary != (cert.BatchSummary{Total: 6, OK: 3, Critical: 1, Unknown: 2}) {
+			t.Errorf("%d workers: summary %+v", workers, summary)
+		}
+	}
+}
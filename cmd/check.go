@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"certview/pkg/cert"
+)
+
+// RunCheck analyzes input against the policy, prints a one-line
+// Nagios-style summary and exits with the matching status code.
+func RunCheck(input string, opts CLIOptions, policy cert.Policy) {
+	chainInfo, _, err := analyzeInput(input, opts)
+	if err != nil {
+		fmt.Printf("CERTVIEW UNKNOWN - %s: %v\n", input, err)
+		os.Exit(cert.StatusUnknown)
+	}
+
+	result := policy.Evaluate(chainInfo, time.Now())
+	fmt.Println(checkSummary(input, result, policy))
+	os.Exit(result.Status)
+}
+
+func checkSummary(input string, result *cert.CheckResult, policy cert.Policy) string {
+	var details string
+	if len(result.Problems) > 0 {
+		messages := make([]string, len(result.Problems))
+		for i, problem := range result.Problems {
+			messages[i] = problem.Message
+		}
+		details = strings.Join(messages, "; ")
+	} else {
+		details = fmt.Sprintf("expires in %d day(s) (%s)", result.DaysLeft, result.Expiry.Format("2006-01-02"))
+	}
+
+	// Performance data: 'label'=value;warn;crit
+	return fmt.Sprintf("CERTVIEW %s - %s: %s | days_left=%d;%d;%d",
+		cert.StatusName(result.Status), input, details, result.DaysLeft, policy.WarnDays, policy.CritDays)
+}
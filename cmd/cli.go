@@ -1,44 +1,304 @@
 package cmd
 
 import (
+	"crypto"
 	"crypto/x509"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"strings"
 
 	"certview/pkg/cert"
 	"certview/pkg/html"
+	"certview/pkg/report"
 )
 
-func RunCLI(input string) {
+type CLIOptions struct {
+	Format       string
+	Password     string
+	PasswordFile string
+	Trust        string
+	Roots        string
+	CTLogs       string
+	StartTLS     string
+	Connect      string
+	SNI          string
+	NoSNI        bool
+	Scan         bool
+	ExpectHost   string
+	OCSP         bool
+	FetchCRLs    bool
+	CRLFiles     []string
+	// KeyFile is a PEM private key to match against the chain. The
+	// key password is only needed for encrypted keys.
+	KeyFile         string
+	KeyPassword     string
+	KeyPasswordFile string
+
+	// probeResumption is only set for a single interactive target; batch,
+	// discover and check mode skip the extra handshake.
+	probeResumption bool
+}
+
+func RunCLI(input string, opts CLIOptions) {
+	if opts.Format != "html" && opts.Format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported output format %q (use html or json)\n", opts.Format)
+		os.Exit(1)
+	}
+
+	opts.probeResumption = true
+	chainInfo, title, err := analyzeInput(input, opts)
+	var noChain *noChainError
+	if errors.As(err, &noChain) {
+		store, storeErr := loadTrustStore(opts)
+		if storeErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", storeErr)
+			os.Exit(1)
+		}
+		if len(noChain.requests) > 0 {
+			fmt.Fprintf(os.Stderr, "Found %d certificate request(s)\n", len(noChain.requests))
+		} else {
+			fmt.Fprintf(os.Stderr, "Found %d CRL(s)\n", len(noChain.crls))
+		}
+		output, err := renderNoChainReport(noChain, store, title, opts.Format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating %s: %v\n", strings.ToUpper(opts.Format), err)
+			os.Exit(1)
+		}
+		fmt.Println(output)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	output, err := renderReport(chainInfo, title, opts.Format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating %s: %v\n", strings.ToUpper(opts.Format), err)
+		os.Exit(1)
+	}
+
+	fmt.Println(output)
+}
+
+// noChainError is returned for inputs that hold certificate requests or
+// CRLs but no certificates. RunCLI and the server show those in their own
+// reports instead of a chain.
+type noChainError struct {
+	crls     []*x509.RevocationList
+	requests []*x509.CertificateRequest
+}
+
+func (e *noChainError) Error() string {
+	if len(e.requests) > 0 {
+		return "input contains certificate requests but no certificates"
+	}
+	return "input contains CRLs but no certificates"
+}
+
+// analyzeInput fetches or parses the certificates named by input and
+// analyzes them, returning the chain and the report title.
+func analyzeInput(input string, opts CLIOptions) (*cert.ChainInfo, string, error) {
+	store, logs, err := loadVerifiers(opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return analyzeInputWith(input, opts, store, logs)
+}
+
+// loadVerifiers loads the trust store and CT log list selected by opts.
+// Both are safe to share between concurrent analyses.
+func loadVerifiers(opts CLIOptions) (*cert.TrustStore, *cert.CTLogList, error) {
+	store, err := loadTrustStore(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var logs *cert.CTLogList
+	if opts.CTLogs != "" {
+		logs, err = cert.LoadCTLogList(opts.CTLogs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return store, logs, nil
+}
+
+func analyzeInputWith(input string, opts CLIOptions, store *cert.TrustStore, logs *cert.CTLogList) (*cert.ChainInfo, string, error) {
 	var certs []*x509.Certificate
-	var err error
+	var crls []*x509.RevocationList
+	var privateKey crypto.PrivateKey
+	var connection *cert.ConnectionInfo
+	var handshake *cert.HandshakeInfo
+	var scan *cert.ScanResult
 	var title string
+	var err error
 
 	if strings.Contains(input, ":") || (!strings.Contains(input, ".") && !strings.HasSuffix(input, ".pem") && !strings.HasSuffix(input, ".crt") && !strings.HasSuffix(input, ".cer")) {
 		fmt.Fprintf(os.Stderr, "Fetching certificates from domain: %s\n", input)
-		certs, err = cert.FetchCertificatesFromDomain(input)
-		title = fmt.Sprintf("Domain: %s", input)
+		var result *cert.FetchResult
+		fetchOpts := cert.FetchOptions{
+			StartTLS: opts.StartTLS,
+			Connect:  opts.Connect,
+			SNI:      opts.SNI,
+			NoSNI:    opts.NoSNI,
+
+			ProbeResumption: opts.probeResumption,
+		}
+		result, err = cert.Fetch(input, fetchOpts)
+		if err != nil {
+			return nil, "", err
+		}
+		certs, connection, handshake = result.Certificates, result.Connection, result.Handshake
+		title = fetchTitle(connection)
+		if opts.Scan {
+			fmt.Fprintf(os.Stderr, "Scanning TLS versions, cipher suites and groups...\n")
+			scan, err = cert.Scan(input, fetchOpts)
+			if err != nil {
+				return nil, "", err
+			}
+		}
 	} else {
 		fmt.Fprintf(os.Stderr, "Parsing certificate file: %s\n", input)
-		certs, err = cert.ParseCertificateFile(input)
+		password, err := readPassword(opts.Password, opts.PasswordFile)
+		if err != nil {
+			return nil, "", err
+		}
+		bundle, err := cert.ParseBundleFile(input, password)
+		if err != nil {
+			return nil, "", err
+		}
+		certs, crls, privateKey = bundle.Certificates, bundle.CRLs, bundle.PrivateKey
 		title = fmt.Sprintf("File: %s", input)
+		if len(certs) == 0 {
+			if len(crls) > 0 || len(bundle.Requests) > 0 {
+				return nil, title, &noChainError{crls: crls, requests: bundle.Requests}
+			}
+			return nil, "", fmt.Errorf("no certificates found in %s", input)
+		}
 	}
 
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	for _, file := range opts.CRLFiles {
+		loaded, err := cert.ParseCRLFile(file)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %v", file, err)
+		}
+		crls = append(crls, loaded...)
+	}
+
+	if opts.KeyFile != "" {
+		password, err := readPassword(opts.KeyPassword, opts.KeyPasswordFile)
+		if err != nil {
+			return nil, "", err
+		}
+		privateKey, err = cert.ParsePrivateKeyFile(opts.KeyFile, password)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %v", opts.KeyFile, err)
+		}
 	}
 
 	fmt.Fprintf(os.Stderr, "Found %d certificate(s)\n", len(certs))
+	if len(crls) > 0 {
+		fmt.Fprintf(os.Stderr, "Found %d CRL(s)\n", len(crls))
+	}
+
+	chainInfo := cert.AnalyzeCertificateChainWithOptions(certs, cert.AnalyzeOptions{
+		CRLs:       crls,
+		PrivateKey: privateKey,
+		TrustStore: store,
+		CTLogs:     logs,
+		Connection: connection,
+		Handshake:  handshake,
+		Scan:       scan,
+		ExpectHost: opts.ExpectHost,
+		QueryOCSP:  opts.OCSP,
+		FetchCRLs:  opts.FetchCRLs,
+	})
+	return chainInfo, title, nil
+}
 
-	chainInfo := cert.AnalyzeCertificateChain(certs)
+func loadTrustStore(opts CLIOptions) (*cert.TrustStore, error) {
+	if opts.Roots != "" {
+		return cert.LoadTrustStore(opts.Roots)
+	}
+	if opts.Trust == "system" {
+		store, err := cert.SystemTrustStore()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, using embedded Mozilla roots\n", err)
+			return cert.MozillaTrustStore(), nil
+		}
+		return store, nil
+	}
+	return cert.LoadNamedTrustStore(opts.Trust)
+}
 
-	htmlOutput, err := html.GenerateHTML(chainInfo, title)
+func readPassword(password, file string) (string, error) {
+	if file == "" {
+		return password, nil
+	}
+
+	data, err := os.ReadFile(file)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating HTML: %v\n", err)
-		os.Exit(1)
+		return "", fmt.Errorf("failed to read password file: %v", err)
 	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
 
-	fmt.Println(htmlOutput)
-}
\ No newline at end of file
+func renderReport(chainInfo *cert.ChainInfo, title, format string) (string, error) {
+	if format == "json" {
+		return report.GenerateJSON(chainInfo, title)
+	}
+	return html.GenerateHTML(chainInfo, title)
+}
+
+func analyzeCRLs(crls []*x509.RevocationList, store *cert.TrustStore) []*cert.CRLReport {
+	reports := make([]*cert.CRLReport, len(crls))
+	for i, crl := range crls {
+		reports[i] = cert.AnalyzeCRL(crl, store)
+	}
+	return reports
+}
+
+// renderNoChainReport reports the certificate requests of an input or,
+// if it has none, its CRLs, whose signatures are checked against store.
+func renderNoChainReport(e *noChainError, store *cert.TrustStore, title, format string) (string, error) {
+	if len(e.requests) > 0 {
+		requests := cert.AnalyzeRequests(e.requests)
+		if format == "json" {
+			return report.GenerateRequestJSON(requests, title)
+		}
+		return html.GenerateRequestHTML(requests, title)
+	}
+
+	crls := analyzeCRLs(e.crls, store)
+	if format == "json" {
+		return report.GenerateCRLJSON(crls, title)
+	}
+	return html.GenerateCRLHTML(crls, title)
+}
+
+// fetchTitle names the target and, where they differ from the defaults, the
+// address dialed, the SNI sent and the STARTTLS protocol.
+func fetchTitle(conn *cert.ConnectionInfo) string {
+	var details []string
+	if conn.Address != conn.Target {
+		details = append(details, "via "+conn.Address)
+	}
+	host, _, _ := net.SplitHostPort(conn.Target)
+	if conn.ServerName == "" && net.ParseIP(host) == nil {
+		details = append(details, "no SNI")
+	} else if conn.ServerName != "" && conn.ServerName != host {
+		details = append(details, "SNI "+conn.ServerName)
+	}
+	if conn.StartTLS != "" {
+		details = append(details, "STARTTLS "+strings.ToUpper(conn.StartTLS))
+	}
+
+	title := fmt.Sprintf("Domain: %s", conn.Target)
+	if len(details) > 0 {
+		title += " (" + strings.Join(details, ", ") + ")"
+	}
+	return title
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"certview/pkg/cert"
+	"certview/pkg/html"
+	"certview/pkg/report"
+)
+
+// RunDiscover attempts TLS handshakes with every port of every address in
+// ranges and prints an inventory of the certificates found.
+func RunDiscover(ranges []string, ports []int, opts CLIOptions, workers int, timeout time.Duration) {
+	if opts.Format != "html" && opts.Format != "json" && opts.Format != "csv" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported output format %q (use html, json or csv)\n", opts.Format)
+		os.Exit(1)
+	}
+
+	addresses, err := cert.ExpandTargets(ranges, ports)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, logs, err := loadVerifiers(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Probing %d endpoint(s) with %d worker(s)\n", len(addresses), workers)
+	probes := make([]cert.ProbeResult, len(addresses))
+	runWorkers(len(addresses), workers, func(i int) {
+		probes[i] = probeEndpoint(addresses[i], opts, timeout, store, logs)
+	})
+
+	inventory := cert.BuildInventory(probes)
+	fmt.Fprintf(os.Stderr, "Found %d certificate(s) on %d of %d endpoint(s)\n", len(inventory.Entries), inventory.Responding, inventory.Probed)
+
+	portNames := make([]string, len(ports))
+	for i, port := range ports {
+		portNames[i] = strconv.Itoa(port)
+	}
+	title := fmt.Sprintf("Discovery: %s (ports %s)", strings.Join(ranges, ", "), strings.Join(portNames, ", "))
+
+	var output string
+	switch opts.Format {
+	case "json":
+		output, err = report.GenerateInventoryJSON(inventory, title)
+	case "csv":
+		output, err = report.GenerateInventoryCSV(inventory)
+	default:
+		output, err = html.GenerateInventoryHTML(inventory, title)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating %s: %v\n", strings.ToUpper(opts.Format), err)
+		os.Exit(1)
+	}
+
+	fmt.Print(output)
+}
+
+// probeEndpoint fetches and analyzes the chain served on address. Most
+// addresses in a range do not answer, so failures are not reported.
+func probeEndpoint(address string, opts CLIOptions, timeout time.Duration, store *cert.TrustStore, logs *cert.CTLogList) cert.ProbeResult {
+	probe := cert.ProbeResult{Address: address}
+	result, err := cert.Fetch(address, cert.FetchOptions{
+		StartTLS: opts.StartTLS,
+		SNI:      opts.SNI,
+		Timeout:  timeout,
+	})
+	if err != nil {
+		return probe
+	}
+
+	probe.Connection = result.Connection
+	probe.Chain = cert.AnalyzeCertificateChainWithOptions(result.Certificates, cert.AnalyzeOptions{
+		TrustStore: store,
+		CTLogs:     logs,
+		Handshake:  result.Handshake,
+		QueryOCSP:  opts.OCSP,
+		FetchCRLs:  opts.FetchCRLs,
+	})
+	return probe
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"certview/pkg/cert"
+)
+
+// newShortLivedTLSServer serves a self-signed certificate that expires
+// long before the httptest one.
+func newShortLivedTLSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "short-lived.example.com"},
+		DNSNames:     []string{"short-lived.example.com"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(30 * 24 * time.Hour),
+	}, &x509.Certificate{Subject: pkix.Name{CommonName: "short-lived.example.com"}}, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDiscoverInventory(t *testing.T) {
+	// Both httptest servers present the same built-in certificate
+	first := httptest.NewTLSServer(http.NotFoundHandler())
+	defer first.Close()
+	second := httptest.NewTLSServer(http.NotFoundHandler())
+	defer second.Close()
+	shortLived := newShortLivedTLSServer(t)
+	closed := httptest.NewTLSServer(http.NotFoundHandler())
+	closed.Close()
+
+	port := func(server *httptest.Server) int {
+		return server.Listener.Addr().(*net.TCPAddr).Port
+	}
+	ports := []int{port(first), port(second), port(shortLived), port(closed)}
+	addresses, err := cert.ExpandTargets([]string{"127.0.0.1"}, ports)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &cert.TrustStore{Name: "test", Pool: x509.NewCertPool()}
+	store.Pool.AddCert(first.Certificate())
+	probes := make([]cert.ProbeResult, len(addresses))
+	runWorkers(len(addresses), 4, func(i int) {
+		probes[i] = probeEndpoint(addresses[i], CLIOptions{}, 2*time.Second, store, nil)
+	})
+
+	for i, probe := range probes {
+		if probe.Address != addresses[i] {
+			t.Errorf("probe %d is for %s, want %s", i, probe.Address, addresses[i])
+		}
+	}
+	if probes[3].Chain != nil {
+		t.Errorf("closed port %s returned a chain", probes[3].Address)
+	}
+
+	inventory := cert.BuildInventory(probes)
+	if inventory.Probed != 4 || inventory.Responding != 3 {
+		t.Errorf("probed %d, responding %d; want 4 and 3", inventory.Probed, inventory.Responding)
+	}
+	if len(inventory.Entries) != 2 {
+		t.Fatalf("%d inventory entries, want 2", len(inventory.Entries))
+	}
+
+	// Entries are ordered by expiry
+	soon, later := inventory.Entries[0], inventory.Entries[1]
+	if soon.Certificate.Certificate.Subject.CommonName != "short-lived.example.com" {
+		t.Errorf("first entry is %s", soon.Certificate.Subject)
+	}
+	if len(soon.Endpoints) != 1 || soon.Endpoints[0].Address != addresses[2] || soon.ChainValid() {
+		t.Errorf("short-lived certificate endpoints %+v, want only %s with an untrusted chain", soon.Endpoints, addresses[2])
+	}
+	var seen []string
+	for _, endpoint := range later.Endpoints {
+		seen = append(seen, endpoint.Address)
+	}
+	if strings.Join(seen, " ") != addresses[0]+" "+addresses[1] || !later.ChainValid() {
+		t.Errorf("shared certificate seen on %q (valid %v), want %s and %s", seen, later.ChainValid(), addresses[0], addresses[1])
+	}
+}
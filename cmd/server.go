@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,11 +12,25 @@ import (
 
 	"certview/pkg/cert"
 	"certview/pkg/html"
+	"certview/pkg/report"
 )
 
-func RunServer(port int) {
+// ServerOptions configures what the web server may do on behalf of the
+// people submitting certificates.
+type ServerOptions struct {
+	// AllowRevocationFetch lets requests query OCSP responders and download
+	// CRLs named in the submitted certificates. Loopback, private and
+	// link-local addresses are refused even then.
+	AllowRevocationFetch bool
+}
+
+var serverOptions ServerOptions
+
+func RunServer(port int, opts ServerOptions) {
+	serverOptions = opts
 	http.HandleFunc("/", handleHome)
 	http.HandleFunc("/analyze", handleAnalyze)
+	http.HandleFunc("/api/analyze", handleAPIAnalyze)
 
 	addr := fmt.Sprintf(":%d", port)
 	fmt.Printf("Starting CertView server on http://localhost%s\n", addr)
@@ -24,7 +40,7 @@ func RunServer(port int) {
 func handleHome(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		formHTML, err := html.GenerateWebForm()
+		formHTML, err := html.GenerateWebForm(html.FormOptions{RevocationFetch: serverOptions.AllowRevocationFetch})
 		if err != nil {
 			http.Error(w, "Error generating form", http.StatusInternalServerError)
 			return
@@ -48,79 +64,203 @@ func handleAnalyze(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := r.ParseMultipartForm(10 << 20) // 10 MB limit
+	chainInfo, store, title, status, err := analyzeRequest(r)
+	var noChain *noChainError
+	if errors.As(err, &noChain) {
+		htmlOutput, err := renderNoChainReport(noChain, store, title, "html")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error generating HTML: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(htmlOutput))
+		return
+	}
 	if err != nil {
-		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		http.Error(w, err.Error(), status)
 		return
 	}
 
+	htmlOutput, err := html.GenerateHTML(chainInfo, title)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error generating HTML: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Write([]byte(htmlOutput))
+}
+
+func handleAPIAnalyze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	chainInfo, store, title, status, err := analyzeRequest(r)
+	var noChain *noChainError
+	if errors.As(err, &noChain) {
+		jsonOutput, err := renderNoChainReport(noChain, store, title, "json")
+		if err != nil {
+			writeJSONError(w, fmt.Sprintf("Error generating JSON: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(jsonOutput))
+		return
+	}
+	if err != nil {
+		writeJSONError(w, err.Error(), status)
+		return
+	}
+
+	jsonOutput, err := report.GenerateJSON(chainInfo, title)
+	if err != nil {
+		writeJSONError(w, fmt.Sprintf("Error generating JSON: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(jsonOutput))
+}
+
+func writeJSONError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// analyzeRequest reads the certificate source from a submitted form and
+// returns the analyzed chain and the trust store it was validated against,
+// or an error with the HTTP status to report. Inputs with only certificate
+// requests or CRLs yield a *noChainError; the store is still returned so
+// that CRL issuers can be checked against it.
+func analyzeRequest(r *http.Request) (*cert.ChainInfo, *cert.TrustStore, string, int, error) {
+	err := r.ParseMultipartForm(10 << 20) // 10 MB limit
+	if err != nil && err != http.ErrNotMultipart {
+		return nil, nil, "", http.StatusBadRequest, fmt.Errorf("Error parsing form")
+	}
+	if err == http.ErrNotMultipart {
+		if err := r.ParseForm(); err != nil {
+			return nil, nil, "", http.StatusBadRequest, fmt.Errorf("Error parsing form")
+		}
+	}
+
 	source := r.FormValue("source")
 	var certs []*x509.Certificate
+	var bundle *cert.Bundle
+	var connection *cert.ConnectionInfo
+	var handshake *cert.HandshakeInfo
+	var scan *cert.ScanResult
 	var title string
 
 	switch source {
 	case "domain":
 		domain := strings.TrimSpace(r.FormValue("domain"))
 		if domain == "" {
-			http.Error(w, "Domain is required", http.StatusBadRequest)
-			return
+			return nil, nil, "", http.StatusBadRequest, fmt.Errorf("Domain is required")
 		}
 
-		certs, err = cert.FetchCertificatesFromDomain(domain)
+		// The domain and connect address are chosen by the caller, so
+		// only public addresses are dialed
+		fetchOpts := cert.FetchOptions{
+			StartTLS: r.FormValue("starttls"),
+			Connect:  strings.TrimSpace(r.FormValue("connect")),
+			SNI:      strings.TrimSpace(r.FormValue("sni")),
+			NoSNI:    r.FormValue("nosni") != "",
+
+			ProbeResumption:   true,
+			PublicNetworkOnly: true,
+		}
+		result, err := cert.Fetch(domain, fetchOpts)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Error fetching certificates: %v", err), http.StatusInternalServerError)
-			return
+			return nil, nil, "", http.StatusInternalServerError, fmt.Errorf("Error fetching certificates: %v", err)
+		}
+		certs, connection, handshake = result.Certificates, result.Connection, result.Handshake
+		title = fetchTitle(connection)
+
+		if r.FormValue("scan") != "" {
+			scan, err = cert.Scan(domain, fetchOpts)
+			if err != nil {
+				return nil, nil, "", http.StatusInternalServerError, fmt.Errorf("Error scanning TLS configuration: %v", err)
+			}
 		}
-		title = fmt.Sprintf("Domain: %s", domain)
 
 	case "file":
 		file, header, err := r.FormFile("certfile")
 		if err != nil {
-			http.Error(w, "Error reading file", http.StatusBadRequest)
-			return
+			return nil, nil, "", http.StatusBadRequest, fmt.Errorf("Error reading file")
 		}
 		defer file.Close()
 
 		data, err := io.ReadAll(file)
 		if err != nil {
-			http.Error(w, "Error reading file content", http.StatusInternalServerError)
-			return
+			return nil, nil, "", http.StatusInternalServerError, fmt.Errorf("Error reading file content")
 		}
 
-		certs, err = cert.ParseCertificateData(data)
+		bundle, err = cert.ParseBundleData(data, r.FormValue("password"))
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Error parsing certificate file: %v", err), http.StatusBadRequest)
-			return
+			return nil, nil, "", http.StatusBadRequest, fmt.Errorf("Error parsing certificate file: %v", err)
 		}
 		title = fmt.Sprintf("File: %s", header.Filename)
 
 	case "paste":
 		certData := strings.TrimSpace(r.FormValue("certdata"))
 		if certData == "" {
-			http.Error(w, "Certificate data is required", http.StatusBadRequest)
-			return
+			return nil, nil, "", http.StatusBadRequest, fmt.Errorf("Certificate data is required")
 		}
 
-		certs, err = cert.ParseCertificateData([]byte(certData))
+		bundle, err = cert.ParseBundleData([]byte(certData), "")
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Error parsing certificate data: %v", err), http.StatusBadRequest)
-			return
+			return nil, nil, "", http.StatusBadRequest, fmt.Errorf("Error parsing certificate data: %v", err)
 		}
 		title = "Pasted Certificate"
 
 	default:
-		http.Error(w, "Invalid source", http.StatusBadRequest)
-		return
+		return nil, nil, "", http.StatusBadRequest, fmt.Errorf("Invalid source")
 	}
 
-	chainInfo := cert.AnalyzeCertificateChain(certs)
+	opts := cert.AnalyzeOptions{
+		Connection: connection,
+		Handshake:  handshake,
+		Scan:       scan,
+		ExpectHost: strings.TrimSpace(r.FormValue("expecthost")),
+		QueryOCSP:  serverOptions.AllowRevocationFetch && r.FormValue("ocsp") != "",
+		FetchCRLs:  serverOptions.AllowRevocationFetch && r.FormValue("crl") != "",
 
-	htmlOutput, err := html.GenerateHTML(chainInfo, title)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error generating HTML: %v", err), http.StatusInternalServerError)
-		return
+		PublicNetworkOnly: true,
+	}
+	if trust := r.FormValue("trust"); trust != "" && trust != "system" {
+		opts.TrustStore, err = cert.LoadNamedTrustStore(trust)
+		if err != nil {
+			return nil, nil, "", http.StatusBadRequest, err
+		}
+	} else {
+		// Like the CLI, fall back to the embedded Mozilla roots
+		opts.TrustStore = cert.DefaultTrustStore()
+	}
+	if bundle != nil {
+		certs, opts.CRLs, opts.PrivateKey = bundle.Certificates, bundle.CRLs, bundle.PrivateKey
+		if len(certs) == 0 {
+			if len(bundle.CRLs) > 0 || len(bundle.Requests) > 0 {
+				return nil, opts.TrustStore, title, http.StatusOK, &noChainError{crls: bundle.CRLs, requests: bundle.Requests}
+			}
+			return nil, nil, "", http.StatusBadRequest, fmt.Errorf("No certificates found in input")
+		}
 	}
 
-	w.Header().Set("Content-Type", "text/html")
-	w.Write([]byte(htmlOutput))
-}
\ No newline at end of file
+	// The key is only held in memory for the analysis, never written out
+	if file, _, err := r.FormFile("keyfile"); err == nil {
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, nil, "", http.StatusInternalServerError, fmt.Errorf("Error reading private key")
+		}
+		opts.PrivateKey, err = cert.ParsePrivateKeyData(data, r.FormValue("keypassword"))
+		if err != nil {
+			return nil, nil, "", http.StatusBadRequest, fmt.Errorf("Error parsing private key: %v", err)
+		}
+	}
+
+	return cert.AnalyzeCertificateChainWithOptions(certs, opts), opts.TrustStore, title, http.StatusOK, nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"certview/pkg/cert"
+)
+
+// newTestCRLPEM signs an empty CRL for issuer with key, which need not be
+// the issuer's.
+func newTestCRLPEM(t *testing.T, issuer *x509.Certificate, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Hour),
+		NextUpdate: time.Now().Add(time.Hour),
+	}, issuer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}))
+}
+
+func TestAnalyzeRequestCRL(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mozillaRoot *x509.Certificate
+	for _, root := range cert.MozillaTrustStore().Certificates {
+		if len(root.SubjectKeyId) > 0 {
+			mozillaRoot = root
+			break
+		}
+	}
+	// A CRL that names an embedded root as its issuer, but is signed with
+	// another key
+	forged := newTestCRLPEM(t, &x509.Certificate{
+		RawSubject:   mozillaRoot.RawSubject,
+		SubjectKeyId: mozillaRoot.SubjectKeyId,
+		KeyUsage:     x509.KeyUsageCRLSign,
+	}, key)
+	unknown := newTestCRLPEM(t, &x509.Certificate{
+		Subject:      pkix.Name{CommonName: "Unknown CRL Issuer"},
+		SubjectKeyId: []byte{1, 2, 3, 4},
+		KeyUsage:     x509.KeyUsageCRLSign,
+	}, key)
+
+	tests := []struct {
+		name      string
+		trust     string
+		crl       string
+		wantStore string
+		wantError string
+	}{
+		{
+			name:      "issuer in the trust store",
+			trust:     "mozilla",
+			crl:       forged,
+			wantStore: "Mozilla (embedded)",
+			wantError: "verification",
+		},
+		{
+			name:      "issuer not in the trust store",
+			trust:     "mozilla",
+			crl:       unknown,
+			wantStore: "Mozilla (embedded)",
+			wantError: "issuer not found in the Mozilla (embedded) trust store",
+		},
+		{
+			name:      "default trust store",
+			crl:       unknown,
+			wantStore: cert.DefaultTrustStore().Name,
+			wantError: "issuer not found in the " + cert.DefaultTrustStore().Name + " trust store",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"source": {"paste"}, "certdata": {tt.crl}, "trust": {tt.trust}}
+			r := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			_, store, _, status, err := analyzeRequest(r)
+			var noChain *noChainError
+			if !errors.As(err, &noChain) || status != http.StatusOK {
+				t.Fatalf("got status %d and error %v, want a CRL report", status, err)
+			}
+			if store == nil || store.Name != tt.wantStore {
+				t.Fatalf("trust store %v, want %s", store, tt.wantStore)
+			}
+
+			reports := analyzeCRLs(noChain.crls, store)
+			if len(reports) != 1 {
+				t.Fatalf("got %d CRL reports, want 1", len(reports))
+			}
+			if reports[0].SignatureValid || !strings.Contains(reports[0].SignatureError, tt.wantError) {
+				t.Errorf("signature error %q, want %q", reports[0].SignatureError, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestAnalyzeRequestDomainPublicOnly(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	address := server.Listener.Addr().String()
+
+	tests := []struct {
+		name string
+		form url.Values
+	}{
+		{name: "domain", form: url.Values{"domain": {address}}},
+		{name: "connect address", form: url.Values{"domain": {"www.example.com:443"}, "connect": {address}}},
+		{name: "scan", form: url.Values{"domain": {address}, "scan": {"1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.form.Set("source", "domain")
+			tt.form.Set("starttls", "none")
+			r := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			_, _, _, status, err := analyzeRequest(r)
+			if err == nil || !strings.Contains(err.Error(), "not a public address") {
+				t.Fatalf("got status %d and error %v, want the address refused", status, err)
+			}
+		})
+	}
+}
@@ -1,3 +1,12 @@
 module certview
 
-go 1.24.3
+go 1.25.0
+
+require (
+	golang.org/x/crypto v0.44.0
+	golang.org/x/crypto/x509roots/fallback v0.0.0-20260213171211-a408498e5541
+	golang.org/x/net v0.47.0
+	software.sslmate.com/src/go-pkcs12 v0.7.3
+)
+
+require golang.org/x/text v0.31.0 // indirect
@@ -4,14 +4,47 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"certview/cmd"
+	"certview/pkg/cert"
 )
 
 func main() {
 	var (
 		serverMode = flag.Bool("server", false, "Run in server mode")
 		port       = flag.Int("port", 8080, "Server port (only in server mode)")
+		allowFetch = flag.Bool("allow-revocation-fetch", false, "Server mode: let requests query the OCSP responders and download the CRLs named in submitted certificates (public addresses only)")
+		format     = flag.String("format", "html", "Output format: html or json, or csv with -batch (CLI mode)")
+		password   = flag.String("password", "", "Password for PKCS#12/PFX files")
+		passFile   = flag.String("password-file", "", "Read the PKCS#12/PFX password from a file")
+		keyFile    = flag.String("key", "", "PEM private key (PKCS#1, PKCS#8 or SEC 1) to match against the certificates")
+		keyPass    = flag.String("key-password", "", "Password for an encrypted -key")
+		keyPwFile  = flag.String("key-password-file", "", "Read the -key password from a file")
+		trust      = flag.String("trust", "system", "Trust store used for validation: system or mozilla")
+		roots      = flag.String("roots", "", "Validate against the CA certificates in this file instead of -trust")
+		ctLogs     = flag.String("ct-logs", "", "CT log list (log_list.json v3 format) used to identify SCT logs")
+		startTLS   = flag.String("starttls", "", "Upgrade with STARTTLS first: smtp, imap, pop3, ftp, ldap, xmpp, postgres or none (default: detect from port)")
+		connect    = flag.String("connect", "", "Dial this address instead of the target host (e.g. 10.0.0.5:443)")
+		sni        = flag.String("sni", "", "Server name to send in the TLS handshake (default: target host)")
+		noSNI      = flag.Bool("no-sni", false, "Do not send a server name in the TLS handshake")
+		scan       = flag.Bool("scan", false, "Also probe the TLS versions, cipher suites and groups the server accepts")
+		ocsp       = flag.Bool("ocsp", false, "Query the OCSP responders of the certificates (stapled responses are always checked)")
+		fetchCRLs  = flag.Bool("crl", false, "Download the CRLs (and delta CRLs) named in the certificates and check revocation")
+		crlFiles   = flag.String("crl-file", "", "Comma-separated CRL files (DER or PEM) to check revocation against")
+		expectHost = flag.String("expect-host", "", "Check that the leaf certificate is valid for this host name or IP (default for domains: the SNI sent)")
+		check      = flag.Bool("check", false, "Check mode: print a one-line summary and exit 0/1/2/3 (OK/WARNING/CRITICAL/UNKNOWN)")
+		warnDays   = flag.Int("warn-days", 30, "Check and batch mode: warn when a certificate expires within this many days")
+		critDays   = flag.Int("crit-days", 7, "Check and batch mode: critical when a certificate expires within this many days")
+		validChain = flag.Bool("require-valid-chain", false, "Check and batch mode: critical when the chain does not validate")
+		minKeyBits = flag.Int("min-key-bits", 0, "Check and batch mode: critical when an RSA or DSA key is smaller than this")
+		badSigAlgs = flag.String("disallow-sig", "", "Check and batch mode: comma-separated signature algorithms that are critical (e.g. sha1,md5)")
+		batch      = flag.String("batch", "", "Analyze the targets listed in this file, one per line (- reads stdin)")
+		workers    = flag.Int("workers", 8, "Batch and discover mode: number of targets analyzed concurrently")
+		ports      = flag.String("ports", "443", "Discover mode: comma-separated ports and ranges to probe (e.g. 443,8443,9000-9010)")
+		timeout    = flag.Duration("timeout", 5*time.Second, "Discover mode: connection and handshake timeout per endpoint")
 		help       = flag.Bool("help", false, "Show help")
 	)
 
@@ -20,32 +53,168 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Usage:\n")
 		fmt.Fprintf(os.Stderr, "  CLI Mode:\n")
 		fmt.Fprintf(os.Stderr, "    %s [options] <certificate-file|domain:port>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Batch Mode:\n")
+		fmt.Fprintf(os.Stderr, "    %s -batch=<targets.txt|-> [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Discover Mode:\n")
+		fmt.Fprintf(os.Stderr, "    %s discover [options] <cidr|ip|host>...\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  Server Mode:\n")
-		fmt.Fprintf(os.Stderr, "    %s -server [-port=8080]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "    %s -server [-port=8080] [-allow-revocation-fetch]\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s cert.pem\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s google.com:443\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -format=json google.com:443\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s mail.example.com:587\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -starttls=ldap ldap.example.com:3389\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -connect=10.0.0.5:443 api.example.com:443\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -scan example.com:443\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -ocsp example.com:443\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -crl example.com:443\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -crl-file=ca.crl chain.pem\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -expect-host=www.example.com cert.pem\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -check -warn-days=30 -crit-days=7 -require-valid-chain example.com:443\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -batch=targets.txt -workers=16 -format=csv\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s discover -ports=443,8443,636 -workers=64 10.0.0.0/24\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -password-file=pass.txt server.pfx\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -key=server.key -key-password-file=pass.txt chain.pem\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -roots=internal-ca.pem chain.pem\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -ct-logs=log_list.json chain.pem\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -server -port=8080\n", os.Args[0])
 	}
 
-	flag.Parse()
+	// Parse errors must exit UNKNOWN in check mode rather than with the
+	// flag package's 2, which a monitoring system reads as CRITICAL
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		exitUsage(err)
+	}
 
 	if *help {
 		flag.Usage()
 		os.Exit(0)
 	}
 
+	discover := flag.Arg(0) == "discover"
+	if discover {
+		// Options may also follow the command name
+		if err := flag.CommandLine.Parse(flag.Args()[1:]); err != nil {
+			exitUsage(err)
+		}
+	}
+
+	if *check && (*serverMode || discover || *batch != "") {
+		fmt.Fprintf(os.Stderr, "Error: -check cannot be combined with -server, -batch or discover\n")
+		os.Exit(cert.StatusUnknown)
+	}
+
+	var crlList []string
+	if *crlFiles != "" {
+		crlList = strings.Split(*crlFiles, ",")
+	}
+
 	if *serverMode {
-		cmd.RunServer(*port)
-	} else {
+		cmd.RunServer(*port, cmd.ServerOptions{AllowRevocationFetch: *allowFetch})
+	} else if discover {
 		if flag.NArg() < 1 {
+			fmt.Fprintf(os.Stderr, "Error: Missing CIDR, IP or host to discover\n\n")
+			flag.Usage()
+			os.Exit(1)
+		}
+		portList, err := cert.ParsePortList(*ports)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cmd.RunDiscover(flag.Args(), portList, cmd.CLIOptions{
+			Format:    *format,
+			Trust:     *trust,
+			Roots:     *roots,
+			CTLogs:    *ctLogs,
+			StartTLS:  *startTLS,
+			SNI:       *sni,
+			OCSP:      *ocsp,
+			FetchCRLs: *fetchCRLs,
+		}, *workers, *timeout)
+	} else {
+		if *batch == "" && flag.NArg() < 1 {
 			fmt.Fprintf(os.Stderr, "Error: Missing certificate file or domain:port\n\n")
 			flag.Usage()
+			if *check {
+				os.Exit(cert.StatusUnknown)
+			}
 			os.Exit(1)
 		}
 		input := flag.Arg(0)
-		cmd.RunCLI(input)
+		opts := cmd.CLIOptions{
+			Format:          *format,
+			Password:        *password,
+			PasswordFile:    *passFile,
+			Trust:           *trust,
+			Roots:           *roots,
+			CTLogs:          *ctLogs,
+			StartTLS:        *startTLS,
+			Connect:         *connect,
+			SNI:             *sni,
+			NoSNI:           *noSNI,
+			Scan:            *scan,
+			ExpectHost:      *expectHost,
+			OCSP:            *ocsp,
+			FetchCRLs:       *fetchCRLs,
+			CRLFiles:        crlList,
+			KeyFile:         *keyFile,
+			KeyPassword:     *keyPass,
+			KeyPasswordFile: *keyPwFile,
+		}
+		var disallowed []string
+		if *badSigAlgs != "" {
+			disallowed = strings.Split(*badSigAlgs, ",")
+		}
+		policy := cert.Policy{
+			WarnDays:                      *warnDays,
+			CritDays:                      *critDays,
+			RequireValidChain:             *validChain,
+			MinKeyBits:                    *minKeyBits,
+			DisallowedSignatureAlgorithms: disallowed,
+		}
+		if *batch != "" {
+			cmd.RunBatch(*batch, opts, policy, *workers)
+			return
+		}
+		if *check {
+			cmd.RunCheck(input, opts, policy)
+		}
+		cmd.RunCLI(input, opts)
+	}
+}
+
+// exitUsage ends the process after a command line error, which the flag
+// package has already reported.
+func exitUsage(err error) {
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if checkRequested(os.Args[1:]) {
+		os.Exit(cert.StatusUnknown)
+	}
+	os.Exit(2)
+}
+
+// checkRequested looks for -check in the raw arguments, as parsing may
+// have stopped before reaching it.
+func checkRequested(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name == "check" {
+			enabled, err := strconv.ParseBool(value)
+			return !hasValue || (err == nil && enabled)
+		}
 	}
+	return false
 }
\ No newline at end of file
@@ -1,8 +1,12 @@
 package cert
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/x509"
 	"fmt"
+	"net"
+	"net/http"
 	"time"
 )
 
@@ -17,11 +21,14 @@ type CertificateInfo struct {
 	IsCA           bool
 	KeyUsage       []string
 	ExtKeyUsage    []string
-	SANs           []string
+	SANs           []SubjectAltName
 	SignatureAlg   string
 	PublicKeyAlg   string
 	PublicKeySize  int
+	PublicKey      PublicKeyDetails
+	Identifiers    Identifiers
 	Extensions     []ExtensionInfo
+	SCTs           []SCTInfo
 	CrossSigns     []*x509.Certificate
 }
 
@@ -29,36 +36,182 @@ type ExtensionInfo struct {
 	OID      string
 	Name     string
 	Critical bool
-	Value    string
+	Value    string // decoded, one "Name: Value" line per field; hex if unknown
+	Fields   []ExtensionField
+	Raw      string
 }
 
 type ChainInfo struct {
 	Certificates []CertificateInfo
 	IsValid      bool
 	Errors       []string
-	CrossSigning map[string][]*x509.Certificate
+	CrossSigning []CrossSignGroup
 	ChainPaths   []ChainPath
+	Graph        *CertGraph
+	CRLs         []CRLInfo
+	PrivateKey   *KeyInfo
+	Trust        *TrustInfo
+	Leaves       []int // indexes of the end-entity certificates
+	Findings     []ChainFinding
+	Lint         []LintFinding
+	Connection   *ConnectionInfo // set when the chain was fetched from a server
+	Handshake    *HandshakeInfo
+	Scan         *ScanResult
+	Hostname     *HostnameCheck // set when a host was given or fetched from
+	OCSP         []OCSPInfo
+	CRLChecks    []CRLCheck
+	CTLogList    *CTLogListInfo // set when SCTs were checked
+}
+
+type AnalyzeOptions struct {
+	CRLs       []*x509.RevocationList
+	PrivateKey crypto.PrivateKey
+	TrustStore *TrustStore // defaults to DefaultTrustStore()
+	CTLogs     *CTLogList  // defaults to BundledCTLogList()
+	Connection *ConnectionInfo
+	Handshake  *HandshakeInfo
+	Scan       *ScanResult
+	// ExpectHost is the name or IP the leaf must cover. It defaults to the
+	// server name (or host) of Connection.
+	ExpectHost string
+	// QueryOCSP asks the OCSP responders named in the certificates'
+	// Authority Information Access. Stapled responses are always checked.
+	QueryOCSP bool
+	// FetchCRLs downloads the CRLs (and delta CRLs) the certificates point
+	// to, in addition to CRLs.
+	FetchCRLs bool
+	// PublicNetworkOnly refuses OCSP queries and CRL downloads from
+	// loopback, private and link-local addresses. Servers analyzing
+	// certificates submitted by others set it.
+	PublicNetworkOnly bool
 }
 
 type ChainPath struct {
 	Path        []CertificateInfo
 	IsComplete  bool
+	IsTrusted   bool
+	Anchor      string
+	CrossSigned bool
+	CrossSigns  []bool // CrossSigns[i] is set when Path[i] is cross-signed by Path[i+1]
 	Description string
 }
 
 func AnalyzeCertificateChain(certs []*x509.Certificate) *ChainInfo {
+	return AnalyzeCertificateChainWithOptions(certs, AnalyzeOptions{})
+}
+
+func AnalyzeCertificateChainWithOptions(certs []*x509.Certificate, opts AnalyzeOptions) *ChainInfo {
 	chain := &ChainInfo{
 		Certificates: make([]CertificateInfo, len(certs)),
-		CrossSigning: make(map[string][]*x509.Certificate),
+		Connection:   opts.Connection,
+		Handshake:    opts.Handshake,
+		Scan:         opts.Scan,
 	}
 
 	for i, cert := range certs {
 		chain.Certificates[i] = analyzeCertificate(cert)
 	}
 
-	chain.IsValid, chain.Errors = validateChain(certs)
-	chain.CrossSigning = detectCrossSigning(certs)
-	chain.ChainPaths = buildChainPaths(chain)
+	graph := buildIssuerGraph(certs)
+	chain.Leaves = graph.leaves
+	chain.IsValid, chain.Errors = validateChain(certs, graph)
+
+	store := opts.TrustStore
+	if store == nil {
+		store = DefaultTrustStore()
+	}
+	chain.Trust = verifyTrust(certs, graph.leaves, store)
+	if !chain.Trust.Verified {
+		chain.Errors = append(chain.Errors, chain.Trust.Failures...)
+		chain.IsValid = false
+	}
+	var ocspClient *http.Client
+	if opts.QueryOCSP {
+		ocspClient = downloadClient(opts.PublicNetworkOnly)
+	}
+	chain.OCSP = analyzeOCSP(certs, graph, chain.Trust, opts.Handshake, ocspClient)
+	for _, info := range chain.OCSP {
+		if info.Status == "revoked" && info.SignatureValid {
+			chain.Errors = append(chain.Errors, fmt.Sprintf("Certificate %d was revoked on %s (%s) according to the %s OCSP response",
+				info.Certificate+1, info.RevokedAt.Format("2006-01-02"), info.Reason, info.Source))
+			chain.IsValid = false
+		}
+	}
+	chain.CRLs = analyzeCRLs(opts.CRLs, certs, chain.Trust)
+	var crlFailures map[int]string
+	if opts.FetchCRLs {
+		var fetched []*x509.RevocationList
+		var sources []string
+		fetched, sources, crlFailures = fetchCRLs(downloadClient(opts.PublicNetworkOnly), certs, graph)
+		for i, info := range analyzeCRLs(fetched, certs, chain.Trust) {
+			info.Source = sources[i]
+			chain.CRLs = append(chain.CRLs, info)
+		}
+	}
+	chain.CRLChecks = checkCRLs(certs, graph, chain.Trust, chain.CRLs, crlFailures)
+	for _, check := range chain.CRLChecks {
+		if check.Status == "revoked" {
+			chain.Errors = append(chain.Errors, fmt.Sprintf("Certificate %d was revoked on %s (%s) according to its issuer's CRL",
+				check.Certificate+1, check.RevokedAt.Format("2006-01-02"), check.Reason))
+			chain.IsValid = false
+		}
+	}
+	chain.Findings = graph.findings(store)
+	chain.Lint = lintChain(chain.Certificates)
+
+	chain.Graph = buildCertGraph(chain, graph, store)
+	chain.CrossSigning = chain.Graph.crossSigning()
+	var truncated bool
+	chain.ChainPaths, truncated = chain.Graph.chainPaths(graph.leaves)
+	if truncated {
+		chain.Findings = append(chain.Findings, ChainFinding{
+			Kind:        FindingTruncated,
+			Certificate: -1,
+			Message:     fmt.Sprintf("Only the first %d paths from the leaf are listed; the certificates form more", maxChainPaths),
+		})
+	}
+
+	logs := opts.CTLogs
+	if logs == nil {
+		logs, _ = BundledCTLogList()
+	}
+	for i, cert := range certs {
+		var issuer *x509.Certificate
+		if len(graph.issuers[i]) > 0 {
+			issuer = certs[graph.issuers[i][0]]
+		}
+		chain.Certificates[i].SCTs = analyzeSCTs(cert, issuer, logs)
+	}
+	if chain.Handshake != nil && len(certs) > 0 {
+		chain.Handshake.SCTs = analyzeTLSSCTs(chain.Handshake.rawSCTs, certs[0], logs)
+	}
+	checkedSCTs := chain.Handshake != nil && len(chain.Handshake.SCTs) > 0
+	for _, info := range chain.Certificates {
+		checkedSCTs = checkedSCTs || len(info.SCTs) > 0
+	}
+	if checkedSCTs && logs != nil {
+		chain.CTLogList = &CTLogListInfo{Name: logs.Name, Timestamp: logs.Timestamp, Stale: logs.Stale(time.Now())}
+	}
+
+	host := opts.ExpectHost
+	if host == "" && opts.Connection != nil {
+		host = opts.Connection.ServerName
+		if host == "" {
+			host, _, _ = net.SplitHostPort(opts.Connection.Target)
+		}
+	}
+	if host != "" && len(certs) > 0 {
+		leaf := 0
+		if len(graph.leaves) > 0 {
+			leaf = graph.leaves[0]
+		}
+		chain.Hostname = VerifyHostname(certs[leaf], host)
+		chain.Hostname.Certificate = leaf
+	}
+
+	if opts.PrivateKey != nil {
+		chain.PrivateKey = analyzePrivateKey(opts.PrivateKey, certs, graph.leaves)
+	}
 
 	return chain
 }
@@ -73,15 +226,17 @@ func analyzeCertificate(cert *x509.Certificate) CertificateInfo {
 		NotAfter:      cert.NotAfter,
 		IsExpired:     time.Now().After(cert.NotAfter),
 		IsCA:          cert.IsCA,
-		SANs:          cert.DNSNames,
+		SANs:          analyzeSANs(cert),
 		SignatureAlg:  cert.SignatureAlgorithm.String(),
-		PublicKeyAlg:  cert.PublicKeyAlgorithm.String(),
-		Extensions:    analyzeExtensions(cert),
+		PublicKey:     analyzePublicKey(cert),
+		Identifiers:   analyzeIdentifiers(cert),
+		Extensions:    analyzeExtensions(cert.Extensions),
 	}
 
 	info.KeyUsage = parseKeyUsage(cert.KeyUsage)
 	info.ExtKeyUsage = parseExtKeyUsage(cert.ExtKeyUsage)
-	info.PublicKeySize = getPublicKeySize(cert)
+	info.PublicKeyAlg = info.PublicKey.Algorithm
+	info.PublicKeySize = info.PublicKey.Size
 
 	return info
 }
@@ -145,51 +300,7 @@ func parseExtKeyUsage(usage []x509.ExtKeyUsage) []string {
 	return usages
 }
 
-func getPublicKeySize(cert *x509.Certificate) int {
-	switch key := cert.PublicKey.(type) {
-	case interface{ Size() int }:
-		return key.Size() * 8
-	default:
-		return 0
-	}
-}
-
-func analyzeExtensions(cert *x509.Certificate) []ExtensionInfo {
-	var extensions []ExtensionInfo
-	
-	for _, ext := range cert.Extensions {
-		info := ExtensionInfo{
-			OID:      ext.Id.String(),
-			Critical: ext.Critical,
-			Name:     getExtensionName(ext.Id.String()),
-			Value:    fmt.Sprintf("%x", ext.Value),
-		}
-		extensions = append(extensions, info)
-	}
-
-	return extensions
-}
-
-func getExtensionName(oid string) string {
-	knownOIDs := map[string]string{
-		"2.5.29.14":  "Subject Key Identifier",
-		"2.5.29.15":  "Key Usage",
-		"2.5.29.17":  "Subject Alternative Name",
-		"2.5.29.19":  "Basic Constraints",
-		"2.5.29.31":  "CRL Distribution Points",
-		"2.5.29.32":  "Certificate Policies",
-		"2.5.29.35":  "Authority Key Identifier",
-		"2.5.29.37":  "Extended Key Usage",
-		"1.3.6.1.5.5.7.1.1": "Authority Information Access",
-	}
-	
-	if name, ok := knownOIDs[oid]; ok {
-		return name
-	}
-	return "Unknown Extension"
-}
-
-func validateChain(certs []*x509.Certificate) (bool, []string) {
+func validateChain(certs []*x509.Certificate, graph *issuerGraph) (bool, []string) {
 	var errors []string
 	
 	if len(certs) == 0 {
@@ -199,155 +310,27 @@ func validateChain(certs []*x509.Certificate) (bool, []string) {
 	now := time.Now()
 	for i, cert := range certs {
 		if now.Before(cert.NotBefore) {
-			errors = append(errors, fmt.Sprintf("Certificate %d not yet valid", i))
+			errors = append(errors, fmt.Sprintf("Certificate %d not yet valid", i+1))
 		}
 		if now.After(cert.NotAfter) {
-			errors = append(errors, fmt.Sprintf("Certificate %d has expired", i))
-		}
-	}
-
-	for i := 0; i < len(certs)-1; i++ {
-		child := certs[i]
-		parent := certs[i+1]
-		
-		if err := child.CheckSignatureFrom(parent); err != nil {
-			errors = append(errors, fmt.Sprintf("Certificate %d signature validation failed: %v", i, err))
+			errors = append(errors, fmt.Sprintf("Certificate %d has expired", i+1))
 		}
 	}
 
-	return len(errors) == 0, errors
-}
-
-func detectCrossSigning(certs []*x509.Certificate) map[string][]*x509.Certificate {
-	crossSigns := make(map[string][]*x509.Certificate)
-	
-	for i, cert := range certs {
-		subject := cert.Subject.String()
-		issuer := cert.Issuer.String()
-		
-		if subject == issuer {
+	// Certificates whose issuer is named in the input but did not verify
+	for i, child := range certs {
+		if graph.isDuplicate(i) || len(graph.issuers[i]) > 0 || isSelfSigned(child) {
 			continue
 		}
-		
-		for j, otherCert := range certs {
-			if i == j {
+		for j, parent := range certs {
+			if i == j || !bytes.Equal(child.RawIssuer, parent.RawSubject) {
 				continue
 			}
-			
-			if areCrossSignedCertificates(cert, otherCert) {
-				key := subject // Use just the subject as key
-				if crossSigns[key] == nil {
-					crossSigns[key] = []*x509.Certificate{cert}
-				}
-				
-				found := false
-				for _, existing := range crossSigns[key] {
-					if existing == otherCert {
-						found = true
-						break
-					}
-				}
-				if !found {
-					crossSigns[key] = append(crossSigns[key], otherCert)
-				}
+			if err := child.CheckSignatureFrom(parent); err != nil {
+				errors = append(errors, fmt.Sprintf("Certificate %d signature validation failed: %v", i+1, err))
 			}
 		}
 	}
-	
-	filtered := make(map[string][]*x509.Certificate)
-	for key, certList := range crossSigns {
-		if len(certList) > 1 {
-			filtered[key] = certList
-		}
-	}
-	
-	return filtered
-}
-
-func areCrossSignedCertificates(cert1, cert2 *x509.Certificate) bool {
-	if cert1.Subject.String() != cert2.Subject.String() {
-		return false
-	}
-	
-	if cert1.Issuer.String() == cert2.Issuer.String() {
-		return false
-	}
-	
-	return samePublicKey(cert1, cert2)
-}
 
-func samePublicKey(cert1, cert2 *x509.Certificate) bool {
-	hash1 := getPublicKeyHash(cert1)
-	hash2 := getPublicKeyHash(cert2)
-	
-	if len(hash1) != len(hash2) {
-		return false
-	}
-	
-	for i := range hash1 {
-		if hash1[i] != hash2[i] {
-			return false
-		}
-	}
-	
-	return true
-}
-
-func getPublicKeyHash(cert *x509.Certificate) []byte {
-	switch pub := cert.PublicKey.(type) {
-	case interface{ Equal(interface{}) bool }:
-		return cert.RawSubjectPublicKeyInfo
-	default:
-		_ = pub
-		return cert.RawSubjectPublicKeyInfo
-	}
+	return len(errors) == 0, errors
 }
-
-func buildChainPaths(chainInfo *ChainInfo) []ChainPath {
-	var paths []ChainPath
-	
-	if len(chainInfo.CrossSigning) == 0 {
-		// No cross-signing, build simple path
-		paths = append(paths, ChainPath{
-			Path:        chainInfo.Certificates,
-			IsComplete:  chainInfo.IsValid,
-			Description: "Primary Certificate Chain",
-		})
-		return paths
-	}
-	
-	// With cross-signing, build multiple paths
-	endEntity := chainInfo.Certificates[0] // Assume first cert is end entity
-	
-	for subject, crossSignedCerts := range chainInfo.CrossSigning {
-		for i, cert := range crossSignedCerts {
-			path := []CertificateInfo{endEntity}
-			
-			// Find the cross-signed cert in our analyzed certificates
-			for _, analyzedCert := range chainInfo.Certificates {
-				if analyzedCert.Subject == subject && analyzedCert.Issuer == cert.Issuer.String() {
-					path = append(path, analyzedCert)
-					break
-				}
-			}
-			
-			// Add root CA if it exists in our chain
-			for _, analyzedCert := range chainInfo.Certificates {
-				if analyzedCert.Subject == cert.Issuer.String() && analyzedCert.IsCA {
-					path = append(path, analyzedCert)
-					break
-				}
-			}
-			
-			description := fmt.Sprintf("Chain Path %d via %s", i+1, cert.Issuer.String())
-			
-			paths = append(paths, ChainPath{
-				Path:        path,
-				IsComplete:  len(path) > 1,
-				Description: description,
-			})
-		}
-	}
-	
-	return paths
-}
\ No newline at end of file
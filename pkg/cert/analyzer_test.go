@@ -0,0 +1,62 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Messages about the same certificate must number it the same way, from 1
+// like the report and the check output.
+func TestAnalyzeCertificateChainNumbering(t *testing.T) {
+	root := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Numbering Test Root"},
+		NotBefore:             time.Now().Add(-72 * time.Hour),
+		NotAfter:              time.Now().Add(72 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil)
+	leaf := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "www.example.com"},
+		DNSNames:    []string{"www.example.com"},
+		NotBefore:   time.Now().Add(-48 * time.Hour),
+		NotAfter:    time.Now().Add(-24 * time.Hour),
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, root)
+	crl := testCRL{number: 1, revoked: 1}.create(t, root, leaf.cert)
+
+	tests := []struct {
+		name  string
+		certs []*x509.Certificate
+		want  string
+	}{
+		{name: "leaf first", certs: []*x509.Certificate{leaf.cert, root.cert}, want: "Certificate 1 "},
+		{name: "leaf second", certs: []*x509.Certificate{root.cert, leaf.cert}, want: "Certificate 2 "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := AnalyzeCertificateChainWithOptions(tt.certs, AnalyzeOptions{
+				CRLs:       []*x509.RevocationList{crl},
+				TrustStore: newTestStore(root),
+				CTLogs:     &CTLogList{},
+			})
+			var expired, revoked bool
+			for _, message := range chain.Errors {
+				if !strings.HasPrefix(message, "Certificate ") {
+					continue
+				}
+				if !strings.HasPrefix(message, tt.want) {
+					t.Errorf("error %q does not start with %q", message, tt.want)
+				}
+				expired = expired || strings.HasSuffix(message, "has expired")
+				revoked = revoked || strings.Contains(message, "was revoked")
+			}
+			if !expired || !revoked {
+				t.Errorf("errors %q, want the leaf expired and revoked", chain.Errors)
+			}
+		})
+	}
+}
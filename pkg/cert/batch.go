@@ -0,0 +1,110 @@
+package cert
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// BatchTarget is one line of a target list: a certificate file or
+// host:port followed by optional key=value settings that override the
+// command line for that target, e.g.
+//
+//	mail.example.com:25 starttls=smtp
+//	10.0.0.5:443 sni=api.example.com expect-host=api.example.com
+//	chain.pem
+type BatchTarget struct {
+	Input      string
+	Line       int
+	StartTLS   string
+	Connect    string
+	SNI        string
+	NoSNI      bool
+	ExpectHost string
+}
+
+// BatchResult is the outcome of analyzing one target. Chain and Check are
+// nil when Error is set.
+type BatchResult struct {
+	Target BatchTarget
+	Title  string
+	Chain  *ChainInfo
+	Check  *CheckResult
+	Error  string
+}
+
+// BatchSummary counts the targets of a batch by check status. Targets that
+// could not be analyzed count as unknown.
+type BatchSummary struct {
+	Total    int
+	OK       int
+	Warning  int
+	Critical int
+	Unknown  int
+}
+
+// ParseTargetList reads one target per line. Blank lines and lines starting
+// with '#' are skipped.
+func ParseTargetList(r io.Reader) ([]BatchTarget, error) {
+	var targets []BatchTarget
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		target := BatchTarget{Input: fields[0], Line: line}
+		for _, option := range fields[1:] {
+			if strings.HasPrefix(option, "#") {
+				break
+			}
+			key, value, _ := strings.Cut(option, "=")
+			switch key {
+			case "starttls":
+				target.StartTLS = value
+			case "connect":
+				target.Connect = value
+			case "sni":
+				target.SNI = value
+			case "no-sni":
+				target.NoSNI = true
+			case "expect-host":
+				target.ExpectHost = value
+			default:
+				return nil, fmt.Errorf("line %d: unknown option %q (use starttls, connect, sni, no-sni or expect-host)", line, option)
+			}
+		}
+		targets = append(targets, target)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read target list: %v", err)
+	}
+	return targets, nil
+}
+
+// Status is the check status of the target, StatusUnknown if it failed.
+func (r BatchResult) Status() int {
+	if r.Error != "" || r.Check == nil {
+		return StatusUnknown
+	}
+	return r.Check.Status
+}
+
+func SummarizeBatch(results []BatchResult) BatchSummary {
+	summary := BatchSummary{Total: len(results)}
+	for _, result := range results {
+		switch result.Status() {
+		case StatusOK:
+			summary.OK++
+		case StatusWarning:
+			summary.Warning++
+		case StatusCritical:
+			summary.Critical++
+		default:
+			summary.Unknown++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,512 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	oidDeltaCRLIndicator        = "2.5.29.27"
+	oidIssuingDistributionPoint = "2.5.29.28"
+	oidFreshestCRL              = "2.5.29.46"
+)
+
+// maxCRLSize bounds the size of a downloaded CRL.
+const maxCRLSize = 32 << 20
+
+type CRLInfo struct {
+	CRL            *x509.RevocationList
+	Source         string // URL the CRL was downloaded from, empty if it was supplied
+	Issuer         string
+	ThisUpdate     time.Time
+	NextUpdate     time.Time
+	Number         string
+	IsDelta        bool   // carries a Delta CRL Indicator
+	BaseNumber     string // for delta CRLs, the number of the base CRL they update
+	RevokedCount   int
+	IsStale        bool
+	IssuerIndex    int    // index of the issuing certificate in the chain, -1 if absent
+	SignedBy       string // subject of the certificate the signature verified with
+	SignatureValid bool
+	SignatureError string
+
+	signer     *x509.Certificate
+	baseNumber *big.Int
+	scope      crlScope
+}
+
+// crlScope is the Issuing Distribution Point of a CRL (RFC 5280, section
+// 5.2.5). The zero value covers every certificate of the issuer.
+type crlScope struct {
+	points          []string // full distribution point names
+	relativeName    bool
+	onlyUser        bool
+	onlyCA          bool
+	onlyAttribute   bool
+	onlySomeReasons bool
+	indirect        bool
+	err             error
+}
+
+// CRLCheck is the revocation status of one certificate according to the
+// CRLs of its issuer.
+type CRLCheck struct {
+	Certificate int
+	Status      string // "good", "revoked" or "unknown"
+	RevokedAt   time.Time
+	Reason      string
+	CRL         int  // index in ChainInfo.CRLs of the newest CRL consulted, -1 if none
+	Stale       bool // that CRL is past its nextUpdate
+	Error       string
+}
+
+// CRLReport is a CRL analyzed on its own rather than as part of a chain.
+type CRLReport struct {
+	CRLInfo
+	SignatureAlgorithm string
+	AuthorityKeyID     string
+	Extensions         []ExtensionInfo
+	Entries            []RevokedEntry
+}
+
+// RevokedEntry is one revokedCertificates entry. Reason is empty when the
+// entry has no reason code, and InvalidityDate is zero when it has none.
+type RevokedEntry struct {
+	SerialNumber   string
+	SerialHex      string
+	RevokedAt      time.Time
+	Reason         string
+	InvalidityDate time.Time
+}
+
+// AnalyzeCRL describes crl for the standalone CRL report. Its signature is
+// checked against the root of store that issued it, found by subject and
+// key identifier.
+func AnalyzeCRL(crl *x509.RevocationList, store *TrustStore) *CRLReport {
+	var issuers []*x509.Certificate
+	if store != nil {
+		issuers = store.issuers(crl.RawIssuer, crl.AuthorityKeyId)
+	}
+
+	report := &CRLReport{
+		CRLInfo:            analyzeCRL(crl, issuers, nil),
+		SignatureAlgorithm: crl.SignatureAlgorithm.String(),
+		AuthorityKeyID:     hexColon(crl.AuthorityKeyId),
+		Extensions:         analyzeExtensions(crl.Extensions),
+		Entries:            make([]RevokedEntry, len(crl.RevokedCertificateEntries)),
+	}
+	// The issuers are not a chain
+	report.IssuerIndex = -1
+	if !report.SignatureValid && len(issuers) == 0 {
+		if store != nil {
+			report.SignatureError = fmt.Sprintf("issuer not found in the %s trust store", store.Name)
+		} else {
+			report.SignatureError = "no issuer certificates supplied"
+		}
+	}
+
+	for i, entry := range crl.RevokedCertificateEntries {
+		e := RevokedEntry{
+			SerialNumber: entry.SerialNumber.String(),
+			SerialHex:    hexColon(entry.SerialNumber.Bytes()),
+			RevokedAt:    entry.RevocationTime,
+		}
+		for _, ext := range entry.Extensions {
+			switch ext.Id.String() {
+			case "2.5.29.21":
+				e.Reason = revocationReasonName(entry.ReasonCode)
+			case "2.5.29.24":
+				var date time.Time
+				if _, err := asn1.Unmarshal(ext.Value, &date); err == nil {
+					e.InvalidityDate = date
+				}
+			}
+		}
+		report.Entries[i] = e
+	}
+
+	return report
+}
+
+// ParseCRLFile reads DER or PEM ("X509 CRL") CRLs.
+func ParseCRLFile(filename string) ([]*x509.RevocationList, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRL file: %v", err)
+	}
+	return parseCRLData(data)
+}
+
+func parseCRLData(data []byte) ([]*x509.RevocationList, error) {
+	if !isPEM(data) {
+		crl, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CRL: %v", err)
+		}
+		return []*x509.RevocationList{crl}, nil
+	}
+
+	var crls []*x509.RevocationList
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "X509 CRL" {
+			continue
+		}
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PEM CRL: %v", err)
+		}
+		crls = append(crls, crl)
+	}
+	if len(crls) == 0 {
+		return nil, fmt.Errorf("no X509 CRL blocks found in PEM data")
+	}
+	return crls, nil
+}
+
+func analyzeCRLs(crls []*x509.RevocationList, certs []*x509.Certificate, trust *TrustInfo) []CRLInfo {
+	var infos []CRLInfo
+	for _, crl := range crls {
+		infos = append(infos, analyzeCRL(crl, certs, trust))
+	}
+	return infos
+}
+
+// analyzeCRL verifies the CRL with a certificate of the chain or, failing
+// that, a trust anchor of one of the verified paths.
+func analyzeCRL(crl *x509.RevocationList, certs []*x509.Certificate, trust *TrustInfo) CRLInfo {
+	info := CRLInfo{
+		CRL:          crl,
+		Issuer:       crl.Issuer.String(),
+		ThisUpdate:   crl.ThisUpdate,
+		NextUpdate:   crl.NextUpdate,
+		RevokedCount: len(crl.RevokedCertificateEntries),
+		IsStale:      !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate),
+		IssuerIndex:  -1,
+	}
+	if crl.Number != nil {
+		info.Number = crl.Number.String()
+	}
+	for _, ext := range crl.Extensions {
+		if ext.Id.String() == oidDeltaCRLIndicator {
+			info.IsDelta = true
+			var base *big.Int
+			if rest, err := asn1.Unmarshal(ext.Value, &base); err == nil && len(rest) == 0 {
+				info.baseNumber, info.BaseNumber = base, base.String()
+			}
+		}
+	}
+
+	info.scope = parseCRLScope(crl.Extensions)
+
+	candidates := append([]*x509.Certificate(nil), certs...)
+	if trust != nil {
+		for _, path := range trust.Paths {
+			candidates = append(candidates, path.Path[len(path.Path)-1].Certificate)
+		}
+	}
+	for i, cert := range candidates {
+		if !bytes.Equal(cert.RawSubject, crl.RawIssuer) {
+			continue
+		}
+		if err := crl.CheckSignatureFrom(cert); err != nil {
+			info.SignatureError = err.Error()
+			continue
+		}
+		if i < len(certs) {
+			info.IssuerIndex = i
+		}
+		info.signer = cert
+		info.SignedBy = cert.Subject.String()
+		info.SignatureValid = true
+		info.SignatureError = ""
+		break
+	}
+	if !info.SignatureValid && info.SignatureError == "" {
+		info.SignatureError = "issuer certificate not present"
+	}
+
+	return info
+}
+
+// fetchCRLs downloads the CRLs named in the CRL Distribution Points and
+// Freshest CRL (delta CRL) extensions of the chain. Failures are returned
+// by certificate index.
+func fetchCRLs(client *http.Client, certs []*x509.Certificate, graph *issuerGraph) ([]*x509.RevocationList, []string, map[int]string) {
+	var crls []*x509.RevocationList
+	var sources []string
+	failures := make(map[int]string)
+	fetched := make(map[string]bool)
+
+	for i, cert := range certs {
+		if _, dup := graph.duplicates[i]; dup || isSelfSigned(cert) {
+			continue
+		}
+
+		// One base CRL is enough; the other points are usually mirrors
+		var errs []string
+		for _, url := range httpURLs(cert.CRLDistributionPoints) {
+			if fetched[url] {
+				errs = nil
+				break
+			}
+			crl, err := fetchCRL(client, url)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			fetched[url] = true
+			crls, sources = append(crls, crl), append(sources, url)
+			errs = nil
+			break
+		}
+		if len(errs) > 0 {
+			failures[i] = strings.Join(errs, "; ")
+		}
+
+		for _, url := range freshestCRLURLs(cert.Extensions) {
+			if fetched[url] {
+				continue
+			}
+			fetched[url] = true
+			if crl, err := fetchCRL(client, url); err == nil {
+				crls, sources = append(crls, crl), append(sources, url)
+			}
+		}
+	}
+	return crls, sources, failures
+}
+
+func fetchCRL(client *http.Client, url string) (*x509.RevocationList, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("CRL download failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CRL download from %s returned %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCRLSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRL from %s: %v", url, err)
+	}
+	crls, err := parseCRLData(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", url, err)
+	}
+	return crls[0], nil
+}
+
+func httpURLs(urls []string) []string {
+	var result []string
+	for _, url := range urls {
+		lower := strings.ToLower(url)
+		if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+			result = append(result, url)
+		}
+	}
+	return result
+}
+
+func freshestCRLURLs(exts []pkix.Extension) []string {
+	var urls []string
+	for _, ext := range exts {
+		if ext.Id.String() != oidFreshestCRL {
+			continue
+		}
+		fields, err := decodeCRLDistributionPoints(ext.Value)
+		if err != nil {
+			continue
+		}
+		for _, field := range fields {
+			if field.Name == "Distribution Point" {
+				urls = append(urls, field.Value)
+			}
+		}
+	}
+	return httpURLs(urls)
+}
+
+func parseCRLScope(exts []pkix.Extension) crlScope {
+	var scope crlScope
+	for _, ext := range exts {
+		if ext.Id.String() != oidIssuingDistributionPoint {
+			continue
+		}
+		fields, err := decodeIssuingDistributionPoint(ext.Value)
+		if err != nil {
+			scope.err = err
+			break
+		}
+		for _, field := range fields {
+			switch field.Name {
+			case "Distribution Point":
+				scope.points = append(scope.points, field.Value)
+			case "Relative Name":
+				scope.relativeName = true
+			case "Only User Certificates":
+				scope.onlyUser = true
+			case "Only CA Certificates":
+				scope.onlyCA = true
+			case "Only Attribute Certificates":
+				scope.onlyAttribute = true
+			case "Only Some Reasons":
+				scope.onlySomeReasons = true
+			case "Indirect CRL":
+				scope.indirect = true
+			}
+		}
+	}
+	return scope
+}
+
+// excludes explains why cert is outside the scope of the CRL, or returns
+// an empty string if the CRL covers it. A CRL limited to some reasons
+// covers the certificate but cannot show that it is not revoked, so
+// checkCRLs treats it separately.
+func (s crlScope) excludes(cert *x509.Certificate) string {
+	switch {
+	case s.err != nil:
+		return fmt.Sprintf("invalid Issuing Distribution Point in the CRL: %v", s.err)
+	case s.indirect:
+		return "the CRL is an indirect CRL, which is not supported"
+	case s.onlyAttribute:
+		return "the CRL only covers attribute certificates"
+	case s.onlyUser && cert.IsCA:
+		return "the CRL only covers end-entity certificates"
+	case s.onlyCA && !cert.IsCA:
+		return "the CRL only covers CA certificates"
+	case s.relativeName:
+		return "the CRL has a relative distribution point name, which is not supported"
+	}
+	if len(s.points) == 0 {
+		return ""
+	}
+	for _, point := range s.points {
+		for _, url := range cert.CRLDistributionPoints {
+			if point == url {
+				return ""
+			}
+		}
+	}
+	return "the CRL's distribution point does not match the certificate's"
+}
+
+// checkCRLs determines the revocation status of every certificate for
+// which CRLs from its issuer are available, applying delta CRLs on top of
+// the newest base CRL. CRLs whose Issuing Distribution Point excludes the
+// certificate are not consulted.
+func checkCRLs(certs []*x509.Certificate, graph *issuerGraph, trust *TrustInfo, crls []CRLInfo, failures map[int]string) []CRLCheck {
+	var checks []CRLCheck
+	for i, cert := range certs {
+		if _, dup := graph.duplicates[i]; dup || isSelfSigned(cert) {
+			continue
+		}
+
+		issuer := chainIssuer(i, certs, graph, trust)
+		var bases, partial, deltas []int
+		var scopeError string
+		for j, info := range crls {
+			if !info.SignatureValid || !bytes.Equal(info.CRL.RawIssuer, cert.RawIssuer) {
+				continue
+			}
+			if issuer != nil && !info.signer.Equal(issuer) {
+				continue
+			}
+			if reason := info.scope.excludes(cert); reason != "" {
+				if scopeError == "" {
+					scopeError = reason
+				}
+				continue
+			}
+			switch {
+			case info.IsDelta:
+				deltas = append(deltas, j)
+			case info.scope.onlySomeReasons:
+				partial = append(partial, j)
+			default:
+				bases = append(bases, j)
+			}
+		}
+
+		check := CRLCheck{Certificate: i, Status: "unknown", CRL: -1}
+		if len(bases) == 0 {
+			// A CRL for some reasons can still show a revocation
+			for _, j := range partial {
+				if entry := revokedEntry(crls[j].CRL, cert); entry != nil {
+					check.Status, check.RevokedAt, check.Reason = "revoked", entry.RevocationTime, revocationReasonName(entry.ReasonCode)
+					check.CRL, check.Stale = j, crls[j].IsStale
+					break
+				}
+			}
+			switch {
+			case check.Status == "revoked":
+			case failures[i] != "":
+				check.Error = failures[i]
+			case len(partial) > 0:
+				check.Error = "the CRL only covers some revocation reasons"
+			case scopeError != "":
+				check.Error = scopeError
+			case len(deltas) > 0:
+				check.Error = "only delta CRLs from the issuer are available"
+			default:
+				continue
+			}
+			checks = append(checks, check)
+			continue
+		}
+
+		base := bases[0]
+		for _, j := range bases[1:] {
+			if crls[j].ThisUpdate.After(crls[base].ThisUpdate) {
+				base = j
+			}
+		}
+		check.Status, check.CRL, check.Stale = "good", base, crls[base].IsStale
+		if entry := revokedEntry(crls[base].CRL, cert); entry != nil {
+			check.Status, check.RevokedAt, check.Reason = "revoked", entry.RevocationTime, revocationReasonName(entry.ReasonCode)
+		}
+
+		// A delta updates every base whose number is at least its base CRL
+		// number (RFC 5280, section 5.2.4)
+		sort.Slice(deltas, func(a, b int) bool { return crls[deltas[a]].ThisUpdate.Before(crls[deltas[b]].ThisUpdate) })
+		baseNumber := crls[base].CRL.Number
+		for _, j := range deltas {
+			delta := crls[j]
+			if delta.baseNumber == nil || baseNumber == nil || baseNumber.Cmp(delta.baseNumber) < 0 {
+				continue
+			}
+			check.CRL, check.Stale = j, delta.IsStale
+			entry := revokedEntry(delta.CRL, cert)
+			switch {
+			case entry == nil:
+			case entry.ReasonCode == 8: // removeFromCRL releases a certificateHold
+				check.Status, check.RevokedAt, check.Reason = "good", time.Time{}, ""
+			default:
+				check.Status, check.RevokedAt, check.Reason = "revoked", entry.RevocationTime, revocationReasonName(entry.ReasonCode)
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func revokedEntry(crl *x509.RevocationList, cert *x509.Certificate) *x509.RevocationListEntry {
+	for i := range crl.RevokedCertificateEntries {
+		if crl.RevokedCertificateEntries[i].SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return &crl.RevokedCertificateEntries[i]
+		}
+	}
+	return nil
+}
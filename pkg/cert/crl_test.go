@@ -0,0 +1,247 @@
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testIDP encodes an Issuing Distribution Point extension.
+type testIDP struct {
+	uri             string
+	onlyUser        bool
+	onlyCA          bool
+	onlySomeReasons bool
+	indirect        bool
+}
+
+func (idp testIDP) extension(t *testing.T) pkix.Extension {
+	t.Helper()
+	tagged := func(tag int, compound bool, content []byte) []byte {
+		der, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: compound, Bytes: content})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	var fields []byte
+	if idp.uri != "" {
+		fullName := tagged(0, true, tagged(6, false, []byte(idp.uri)))
+		fields = append(fields, tagged(0, true, fullName)...)
+	}
+	if idp.onlyUser {
+		fields = append(fields, tagged(1, false, []byte{0xff})...)
+	}
+	if idp.onlyCA {
+		fields = append(fields, tagged(2, false, []byte{0xff})...)
+	}
+	if idp.onlySomeReasons {
+		fields = append(fields, tagged(3, false, []byte{0x07, 0x80})...) // unused (bit 0) only
+	}
+	if idp.indirect {
+		fields = append(fields, tagged(4, false, []byte{0xff})...)
+	}
+	value, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: fields})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 28}, Critical: true, Value: value}
+}
+
+// testCRL describes a CRL issued by the test root.
+type testCRL struct {
+	number  int64
+	base    int64 // delta CRL indicator, 0 for a base CRL
+	revoked int   // reason code of an entry for the subject, -1 for none
+	exts    []pkix.Extension
+}
+
+func (c testCRL) create(t *testing.T, ca *testCA, subject *x509.Certificate) *x509.RevocationList {
+	t.Helper()
+	template := &x509.RevocationList{
+		Number:          big.NewInt(c.number),
+		ThisUpdate:      time.Now().Add(-time.Hour + time.Duration(c.number)*time.Second),
+		NextUpdate:      time.Now().Add(time.Hour),
+		ExtraExtensions: c.exts,
+	}
+	if c.base != 0 {
+		value, err := asn1.Marshal(big.NewInt(c.base))
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 27}, Critical: true, Value: value})
+	}
+	if c.revoked >= 0 {
+		template.RevokedCertificateEntries = []x509.RevocationListEntry{{
+			SerialNumber:   subject.SerialNumber,
+			RevocationTime: time.Now().Add(-2 * time.Hour).Truncate(time.Second),
+			ReasonCode:     c.revoked,
+		}}
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, template, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crl
+}
+
+func TestCheckCRLs(t *testing.T) {
+	const crlURL = "http://crl.example.com/root.crl"
+	root := newTestRoot(t, "CRL Test Root")
+	leaf := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "crl.example.com"},
+		DNSNames:              []string{"crl.example.com"},
+		CRLDistributionPoints: []string{crlURL},
+	}, root)
+	intermediate := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "CRL Test Intermediate"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		CRLDistributionPoints: []string{crlURL},
+	}, root)
+	invalidIDP := pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 28}, Critical: true, Value: []byte{0x04, 0x00}}
+
+	tests := []struct {
+		name       string
+		subject    *testCA
+		crls       []testCRL
+		want       string
+		wantReason string
+		wantError  string
+	}{
+		{name: "not listed", subject: leaf, crls: []testCRL{{number: 1, revoked: -1}}, want: "good"},
+		{name: "revoked", subject: leaf, crls: []testCRL{{number: 1, revoked: 1}}, want: "revoked", wantReason: "keyCompromise"},
+		{name: "newest base wins", subject: leaf, crls: []testCRL{{number: 1, revoked: 1}, {number: 2, revoked: -1}}, want: "good"},
+		{name: "delta revokes", subject: leaf, crls: []testCRL{{number: 1, revoked: -1}, {number: 2, base: 1, revoked: 4}}, want: "revoked", wantReason: "superseded"},
+		{name: "delta releases hold", subject: leaf, crls: []testCRL{{number: 1, revoked: 6}, {number: 2, base: 1, revoked: 8}}, want: "good"},
+		{name: "delta for a newer base", subject: leaf, crls: []testCRL{{number: 1, revoked: -1}, {number: 3, base: 2, revoked: 1}}, want: "good"},
+		{name: "only a delta", subject: leaf, crls: []testCRL{{number: 2, base: 1, revoked: -1}}, want: "unknown",
+			wantError: "only delta CRLs"},
+		{name: "user certificates only, leaf", subject: leaf,
+			crls: []testCRL{{number: 1, revoked: -1, exts: []pkix.Extension{testIDP{onlyUser: true}.extension(t)}}}, want: "good"},
+		{name: "user certificates only, CA", subject: intermediate,
+			crls: []testCRL{{number: 1, revoked: 1, exts: []pkix.Extension{testIDP{onlyUser: true}.extension(t)}}}, want: "unknown",
+			wantError: "only covers end-entity certificates"},
+		{name: "CA certificates only, leaf", subject: leaf,
+			crls: []testCRL{{number: 1, revoked: -1, exts: []pkix.Extension{testIDP{onlyCA: true}.extension(t)}}}, want: "unknown",
+			wantError: "only covers CA certificates"},
+		{name: "CA certificates only, CA", subject: intermediate,
+			crls: []testCRL{{number: 1, revoked: 1, exts: []pkix.Extension{testIDP{onlyCA: true}.extension(t)}}}, want: "revoked", wantReason: "keyCompromise"},
+		{name: "matching distribution point", subject: leaf,
+			crls: []testCRL{{number: 1, revoked: -1, exts: []pkix.Extension{testIDP{uri: crlURL}.extension(t)}}}, want: "good"},
+		{name: "other distribution point", subject: leaf,
+			crls: []testCRL{{number: 1, revoked: -1, exts: []pkix.Extension{testIDP{uri: "http://crl.example.com/other.crl"}.extension(t)}}}, want: "unknown",
+			wantError: "distribution point does not match"},
+		{name: "out of scope CRL next to a full one", subject: leaf,
+			crls: []testCRL{{number: 1, revoked: -1}, {number: 2, revoked: -1, exts: []pkix.Extension{testIDP{onlyCA: true}.extension(t)}}}, want: "good"},
+		{name: "some reasons, not listed", subject: leaf,
+			crls: []testCRL{{number: 1, revoked: -1, exts: []pkix.Extension{testIDP{onlySomeReasons: true}.extension(t)}}}, want: "unknown",
+			wantError: "only covers some revocation reasons"},
+		{name: "some reasons, listed", subject: leaf,
+			crls: []testCRL{{number: 1, revoked: 1, exts: []pkix.Extension{testIDP{onlySomeReasons: true}.extension(t)}}}, want: "revoked", wantReason: "keyCompromise"},
+		{name: "indirect", subject: leaf,
+			crls: []testCRL{{number: 1, revoked: -1, exts: []pkix.Extension{testIDP{indirect: true}.extension(t)}}}, want: "unknown",
+			wantError: "indirect CRL"},
+		{name: "invalid issuing distribution point", subject: leaf,
+			crls: []testCRL{{number: 1, revoked: -1, exts: []pkix.Extension{invalidIDP}}}, want: "unknown",
+			wantError: "invalid Issuing Distribution Point"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs := []*x509.Certificate{tt.subject.cert, root.cert}
+			var crls []*x509.RevocationList
+			for _, c := range tt.crls {
+				crls = append(crls, c.create(t, root, tt.subject.cert))
+			}
+			infos := analyzeCRLs(crls, certs, nil)
+			for i, info := range infos {
+				if !info.SignatureValid {
+					t.Fatalf("CRL %d: signature not verified: %s", i, info.SignatureError)
+				}
+			}
+
+			checks := checkCRLs(certs, buildIssuerGraph(certs), nil, infos, nil)
+			if len(checks) != 1 {
+				t.Fatalf("got %d CRL checks, want 1", len(checks))
+			}
+			check := checks[0]
+			if check.Status != tt.want || check.Reason != tt.wantReason {
+				t.Errorf("status = %q (%s), want %q (%s)", check.Status, check.Reason, tt.want, tt.wantReason)
+			}
+			if tt.wantError == "" && check.Error != "" {
+				t.Errorf("unexpected error %q", check.Error)
+			}
+			if !strings.Contains(check.Error, tt.wantError) {
+				t.Errorf("error = %q, want it to contain %q", check.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestCheckCRLsFetchFailure(t *testing.T) {
+	root := newTestRoot(t, "CRL Test Root")
+	leaf := newTestLeaf(t, "crl.example.com", root)
+	certs := []*x509.Certificate{leaf.cert, root.cert}
+
+	checks := checkCRLs(certs, buildIssuerGraph(certs), nil, nil, map[int]string{0: "CRL download failed"})
+	if len(checks) != 1 || checks[0].Status != "unknown" || checks[0].Error != "CRL download failed" {
+		t.Errorf("got %+v, want an unknown status with the download error", checks)
+	}
+
+	// Without CRLs or failures there is nothing to report
+	if checks := checkCRLs(certs, buildIssuerGraph(certs), nil, nil, nil); len(checks) != 0 {
+		t.Errorf("got %+v, want no checks", checks)
+	}
+}
+
+func TestAnalyzeCRL(t *testing.T) {
+	root := newTestRoot(t, "CRL Test Root")
+	impostor := newTestRoot(t, "CRL Test Root")
+	leaf := newTestLeaf(t, "www.example.com", root)
+	crl := testCRL{number: 7, revoked: 1}.create(t, root, leaf.cert)
+
+	tests := []struct {
+		name      string
+		store     *TrustStore
+		wantError string
+	}{
+		{name: "issuer in the store", store: newTestStore(root)},
+		{name: "issuer among other roots", store: newTestStore(impostor, root)},
+		{name: "issuer not in the store", store: newTestStore(impostor), wantError: "issuer not found in the test trust store"},
+		{name: "embedded Mozilla roots", store: MozillaTrustStore(), wantError: "issuer not found in the Mozilla (embedded) trust store"},
+		{name: "no store", wantError: "no issuer certificates supplied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := AnalyzeCRL(crl, tt.store)
+			if report.Number != "7" || report.IssuerIndex != -1 {
+				t.Errorf("number %q, issuer index %d", report.Number, report.IssuerIndex)
+			}
+			if len(report.Entries) != 1 || report.Entries[0].SerialNumber != leaf.cert.SerialNumber.String() || report.Entries[0].Reason != "keyCompromise" {
+				t.Errorf("entries %+v", report.Entries)
+			}
+			if tt.wantError == "" {
+				if !report.SignatureValid || report.SignedBy != root.cert.Subject.String() {
+					t.Errorf("signature not verified: %q", report.SignatureError)
+				}
+				return
+			}
+			if report.SignatureValid || !strings.Contains(report.SignatureError, tt.wantError) {
+				t.Errorf("signature error %q, want %q", report.SignatureError, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package cert
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// RequestInfo is an analyzed PKCS#10 certificate signing request.
+type RequestInfo struct {
+	Request        *x509.CertificateRequest
+	Subject        string
+	SignatureAlg   string
+	SignatureValid bool
+	SignatureError string
+	PublicKey      PublicKeyDetails
+	SANs           []SubjectAltName
+	Extensions     []ExtensionInfo // requested in the extensionRequest attribute
+	Lint           []LintFinding   // Certificate is the index of the request
+}
+
+// requestLintRule is a check that can be made on a request before it is
+// submitted. The IDs match the certificate rules they anticipate.
+type requestLintRule struct {
+	ID       string
+	Severity string
+	Citation string
+	Check    func(csr *x509.CertificateRequest) string
+}
+
+var requestLintRules = []requestLintRule{
+	{
+		ID:       "weak_key",
+		Severity: SeverityError,
+		Citation: "CA/B Forum BR §6.1.5",
+		Check: func(csr *x509.CertificateRequest) string {
+			return weakKey(csr.PublicKey)
+		},
+	},
+	{
+		ID:       "leaf_missing_san",
+		Severity: SeverityError,
+		Citation: "CA/B Forum BR §7.1.4.2.1",
+		Check: func(csr *x509.CertificateRequest) string {
+			if len(csr.DNSNames) == 0 && len(csr.IPAddresses) == 0 {
+				return "Request has no DNS or IP Subject Alternative Names"
+			}
+			return ""
+		},
+	},
+	{
+		ID:       "cn_not_in_san",
+		Severity: SeverityError,
+		Citation: "CA/B Forum BR §7.1.4.2.2(a)",
+		Check: func(csr *x509.CertificateRequest) string {
+			if len(csr.DNSNames) == 0 && len(csr.IPAddresses) == 0 {
+				return "" // reported as leaf_missing_san
+			}
+			return commonNameNotInSANs(csr.Subject.CommonName, csr.DNSNames, csr.IPAddresses)
+		},
+	},
+	{
+		ID:       "weak_signature_hash",
+		Severity: SeverityWarning,
+		Citation: "CA/B Forum BR §7.1.3",
+		Check: func(csr *x509.CertificateRequest) string {
+			switch csr.SignatureAlgorithm {
+			case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+				return fmt.Sprintf("Signed with %s, which many CAs reject", csr.SignatureAlgorithm)
+			}
+			return ""
+		},
+	},
+}
+
+// AnalyzeRequests verifies the self-signature of each request and lints
+// the names and key it asks for.
+func AnalyzeRequests(csrs []*x509.CertificateRequest) []RequestInfo {
+	infos := make([]RequestInfo, len(csrs))
+	for i, csr := range csrs {
+		infos[i] = analyzeRequest(i, csr)
+	}
+	return infos
+}
+
+func analyzeRequest(index int, csr *x509.CertificateRequest) RequestInfo {
+	info := RequestInfo{
+		Request:      csr,
+		Subject:      csr.Subject.String(),
+		SignatureAlg: csr.SignatureAlgorithm.String(),
+		PublicKey:    analyzeSPKI(csr.RawSubjectPublicKeyInfo, csr.PublicKeyAlgorithm, csr.PublicKey),
+		SANs:         analyzeSANExtensions(csr.Extensions),
+		Extensions:   analyzeExtensions(csr.Extensions),
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		info.SignatureError = err.Error()
+	} else {
+		info.SignatureValid = true
+	}
+
+	for _, rule := range requestLintRules {
+		if message := rule.Check(csr); message != "" {
+			info.Lint = append(info.Lint, LintFinding{
+				ID:          rule.ID,
+				Severity:    rule.Severity,
+				Citation:    rule.Citation,
+				Certificate: index,
+				Message:     message,
+			})
+		}
+	}
+
+	return info
+}
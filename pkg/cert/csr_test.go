@@ -0,0 +1,226 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newTestRequest(t *testing.T, template *x509.CertificateRequest, key crypto.Signer) []byte {
+	t.Helper()
+	if key == nil {
+		var err error
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestAnalyzeRequests(t *testing.T) {
+	rsa1024, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		template  x509.CertificateRequest
+		key       crypto.Signer
+		tamper    bool
+		mutate    func(*x509.CertificateRequest)
+		want      []string
+		wantSANs  []SubjectAltName // checked when set
+		wantError string
+	}{
+		{
+			name: "compliant request",
+			template: x509.CertificateRequest{
+				Subject:  pkix.Name{CommonName: "www.example.com"},
+				DNSNames: []string{"www.example.com", "example.com"},
+			},
+			wantSANs: []SubjectAltName{{Type: "DNS", Value: "www.example.com"}, {Type: "DNS", Value: "example.com"}},
+		},
+		{
+			name:     "no SANs",
+			template: x509.CertificateRequest{Subject: pkix.Name{CommonName: "www.example.com"}},
+			want:     []string{"leaf_missing_san"},
+		},
+		{
+			name:     "empty request",
+			template: x509.CertificateRequest{},
+			want:     []string{"leaf_missing_san"},
+		},
+		{
+			name: "Common Name not in the SANs",
+			template: x509.CertificateRequest{
+				Subject:  pkix.Name{CommonName: "mail.example.com"},
+				DNSNames: []string{"www.example.com"},
+			},
+			want: []string{"cn_not_in_san"},
+		},
+		{
+			name: "IP address SAN",
+			template: x509.CertificateRequest{
+				Subject:     pkix.Name{CommonName: "192.0.2.1"},
+				IPAddresses: []net.IP{net.ParseIP("192.0.2.1")},
+			},
+			wantSANs: []SubjectAltName{{Type: "IP", Value: "192.0.2.1"}},
+		},
+		{
+			name: "email address only",
+			template: x509.CertificateRequest{
+				Subject:        pkix.Name{CommonName: "Jane Doe"},
+				EmailAddresses: []string{"jane@example.com"},
+			},
+			want:     []string{"leaf_missing_san"},
+			wantSANs: []SubjectAltName{{Type: "Email", Value: "jane@example.com"}},
+		},
+		{
+			name: "1024-bit RSA key",
+			template: x509.CertificateRequest{
+				Subject:  pkix.Name{CommonName: "www.example.com"},
+				DNSNames: []string{"www.example.com"},
+			},
+			key:  rsa1024,
+			want: []string{"weak_key"},
+		},
+		{
+			name: "SHA-1 signature",
+			template: x509.CertificateRequest{
+				Subject:  pkix.Name{CommonName: "www.example.com"},
+				DNSNames: []string{"www.example.com"},
+			},
+			mutate:    func(csr *x509.CertificateRequest) { csr.SignatureAlgorithm = x509.ECDSAWithSHA1 },
+			want:      []string{"weak_signature_hash"},
+			wantError: "verification failure",
+		},
+		{
+			name: "tampered signature",
+			template: x509.CertificateRequest{
+				Subject:  pkix.Name{CommonName: "www.example.com"},
+				DNSNames: []string{"www.example.com"},
+			},
+			tamper:    true,
+			wantError: "verification failure",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			der := newTestRequest(t, &tt.template, tt.key)
+			if tt.tamper {
+				der[len(der)-1] ^= 0xff
+			}
+			csr, err := x509.ParseCertificateRequest(der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.mutate != nil {
+				tt.mutate(csr)
+			}
+
+			infos := AnalyzeRequests([]*x509.CertificateRequest{csr, csr})
+			if len(infos) != 2 {
+				t.Fatalf("got %d results, want 2", len(infos))
+			}
+			info := infos[1]
+			if info.Request != csr || info.Subject != csr.Subject.String() {
+				t.Errorf("request %q not carried through", info.Subject)
+			}
+			if tt.wantError == "" {
+				if !info.SignatureValid || info.SignatureError != "" {
+					t.Errorf("signature error %q", info.SignatureError)
+				}
+			} else if info.SignatureValid || !strings.Contains(info.SignatureError, tt.wantError) {
+				t.Errorf("signature error %q, want %q", info.SignatureError, tt.wantError)
+			}
+
+			var got []string
+			for _, finding := range info.Lint {
+				if finding.Certificate != 1 {
+					t.Errorf("finding %s for request %d, want 1", finding.ID, finding.Certificate)
+				}
+				got = append(got, finding.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+			if tt.wantSANs != nil && !reflect.DeepEqual(info.SANs, tt.wantSANs) {
+				t.Errorf("SANs = %+v, want %+v", info.SANs, tt.wantSANs)
+			}
+		})
+	}
+}
+
+func TestParseBundleDataRequests(t *testing.T) {
+	der := newTestRequest(t, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "www.example.com"},
+		DNSNames: []string{"www.example.com"},
+	}, nil)
+	encode := func(blockType string, data []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data})
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		want      int
+		wantError string
+	}{
+		{name: "DER", data: der, want: 1},
+		{name: "PEM", data: encode("CERTIFICATE REQUEST", der), want: 1},
+		{name: "legacy PEM label", data: encode("NEW CERTIFICATE REQUEST", der), want: 1},
+		{
+			name: "two requests",
+			data: append(encode("CERTIFICATE REQUEST", der), encode("CERTIFICATE REQUEST", der)...),
+			want: 2,
+		},
+		{
+			name:      "malformed PEM request",
+			data:      encode("CERTIFICATE REQUEST", der[:len(der)/2]),
+			wantError: "failed to parse PEM certificate request",
+		},
+		{name: "truncated DER", data: der[:len(der)/2], wantError: "failed to parse DER certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := ParseBundleData(tt.data, "")
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("error %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(bundle.Requests) != tt.want || len(bundle.Certificates) != 0 {
+				t.Fatalf("got %d requests and %d certificates, want %d requests",
+					len(bundle.Requests), len(bundle.Certificates), tt.want)
+			}
+			if bundle.Requests[0].Subject.CommonName != "www.example.com" {
+				t.Errorf("subject %q", bundle.Requests[0].Subject)
+			}
+		})
+	}
+
+	if _, err := ParseCertificateData(der); err == nil || !strings.Contains(err.Error(), "certificate requests but no certificates") {
+		t.Errorf("ParseCertificateData error %v", err)
+	}
+}
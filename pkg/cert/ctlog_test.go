@@ -0,0 +1,268 @@
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLog is a CT log whose key signs SCTs for the tests.
+type testLog struct {
+	key crypto.Signer
+	id  []byte
+	der []byte
+}
+
+func newTestLog(t *testing.T, key crypto.Signer) *testLog {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := sha256.Sum256(der)
+	return &testLog{key: key, id: id[:], der: der}
+}
+
+// testLogList returns a log list in the log_list.json v3 format with the logs.
+func testLogList(t *testing.T, timestamp time.Time, logs ...*testLog) *CTLogList {
+	t.Helper()
+	type entry struct {
+		Description string `json:"description"`
+		LogID       string `json:"log_id"`
+		Key         string `json:"key"`
+		URL         string `json:"url"`
+	}
+	var entries []entry
+	for i, log := range logs {
+		entries = append(entries, entry{
+			Description: "Test Log " + string(rune('A'+i)),
+			LogID:       base64.StdEncoding.EncodeToString(log.id),
+			Key:         base64.StdEncoding.EncodeToString(log.der),
+			URL:         "https://ct.example.com/",
+		})
+	}
+	data, err := json.Marshal(map[string]any{
+		"log_list_timestamp": timestamp,
+		"operators":          []any{map[string]any{"name": "Test Operator", "logs": entries}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := ParseCTLogList(data, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+// sign issues an SCT over entry, a signed entry as defined in RFC 6962,
+// section 3.2, with the entry type first.
+func (l *testLog) sign(t *testing.T, timestamp time.Time, entry []byte) []byte {
+	t.Helper()
+	var signed bytes.Buffer
+	signed.Write([]byte{0, 0}) // v1, certificate_timestamp
+	binary.Write(&signed, binary.BigEndian, uint64(timestamp.UnixMilli()))
+	signed.Write(entry)
+	signed.Write([]byte{0, 0}) // no extensions
+	digest := sha256.Sum256(signed.Bytes())
+
+	sigAlg := byte(3)
+	if _, ok := l.key.(*rsa.PrivateKey); ok {
+		sigAlg = 1
+	}
+	signature, err := l.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encodeSCT(0, l.id, timestamp, nil, 4, sigAlg, signature)
+}
+
+// newTestPrecert issues a leaf with an SCT list embedded by log, signed
+// over the precertificate TBS as a log would.
+func newTestPrecert(t *testing.T, ca *testCA, log *testLog, tamper bool) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSerial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(testSerial),
+		Subject:      pkix.Name{CommonName: "ct.example.com"},
+		DNSNames:     []string{"ct.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour).Truncate(time.Second),
+		NotAfter:     time.Now().Add(24 * time.Hour).Truncate(time.Second),
+	}
+	issue := func() *x509.Certificate {
+		der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+
+	precert := issue()
+	timestamp := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	issuerKeyHash := sha256.Sum256(ca.cert.RawSubjectPublicKeyInfo)
+	entry := append([]byte{0, 1}, issuerKeyHash[:]...) // precert_entry
+	tbs := precert.RawTBSCertificate
+	entry = append(entry, byte(len(tbs)>>16), byte(len(tbs)>>8), byte(len(tbs)))
+	entry = append(entry, tbs...)
+	sct := log.sign(t, timestamp, entry)
+	if tamper {
+		sct[len(sct)-1] ^= 0xff
+	}
+
+	value, err := asn1.Marshal(encodeSCTList(sct))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}, Value: value}}
+	return issue()
+}
+
+func TestAnalyzeSCTs(t *testing.T) {
+	root := newTestRoot(t, "CT Test Root")
+	otherRoot := newTestRoot(t, "Other Root")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecLog, rsaLog := newTestLog(t, ecKey), newTestLog(t, rsaKey)
+	current := testLogList(t, time.Now().Add(-24*time.Hour), ecLog, rsaLog)
+	stale := testLogList(t, time.Now().Add(-100*24*time.Hour), rsaLog)
+
+	tests := []struct {
+		name      string
+		log       *testLog
+		logs      *CTLogList
+		issuer    *x509.Certificate
+		tamper    bool
+		wantValid bool
+		wantError string
+	}{
+		{name: "ECDSA log", log: ecLog, logs: current, issuer: root.cert, wantValid: true},
+		{name: "RSA log", log: rsaLog, logs: current, issuer: root.cert, wantValid: true},
+		{name: "tampered signature", log: ecLog, logs: current, issuer: root.cert, tamper: true, wantError: "invalid signature"},
+		{name: "wrong issuer", log: ecLog, logs: current, issuer: otherRoot.cert, wantError: "invalid signature"},
+		{name: "issuer missing", log: ecLog, logs: current, wantError: "issuer not present in the chain"},
+		{name: "unknown log", log: ecLog, logs: stale, issuer: root.cert, wantError: "log not in the CT log list (the list from"},
+		{name: "no log list", log: ecLog, issuer: root.cert, wantError: "log not in the CT log list"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaf := newTestPrecert(t, root, tt.log, tt.tamper)
+			infos := analyzeSCTs(leaf, tt.issuer, tt.logs)
+			if len(infos) != 1 {
+				t.Fatalf("got %d SCTs, want 1", len(infos))
+			}
+			info := infos[0]
+			if !bytes.Equal(info.LogID, tt.log.id) {
+				t.Errorf("log ID = %x, want %x", info.LogID, tt.log.id)
+			}
+			if info.SignatureValid != tt.wantValid {
+				t.Errorf("signature valid = %v, want %v (error %q)", info.SignatureValid, tt.wantValid, info.SignatureError)
+			}
+			if tt.wantError == "" && info.SignatureError != "" {
+				t.Errorf("unexpected error %q", info.SignatureError)
+			}
+			if !strings.Contains(info.SignatureError, tt.wantError) {
+				t.Errorf("error = %q, want it to contain %q", info.SignatureError, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTLSSCTs(t *testing.T) {
+	root := newTestRoot(t, "CT Test Root")
+	leaf := newTestLeaf(t, "ct.example.com", root)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := newTestLog(t, key)
+	logs := testLogList(t, time.Now(), log)
+
+	// TLS SCTs are signed over the certificate itself
+	entry := append([]byte{0, 0}, byte(len(leaf.cert.Raw)>>16), byte(len(leaf.cert.Raw)>>8), byte(len(leaf.cert.Raw)))
+	entry = append(entry, leaf.cert.Raw...)
+	good := log.sign(t, time.Now(), entry)
+	forOther := log.sign(t, time.Now(), append([]byte{0, 0, 0, 0, 1}, 0x00))
+	v2 := append([]byte{1}, good[1:]...)
+
+	infos := analyzeTLSSCTs([][]byte{good, forOther, v2, {0x00}}, leaf.cert, logs)
+	if len(infos) != 3 {
+		t.Fatalf("got %d SCTs, want 3 (the malformed one skipped)", len(infos))
+	}
+	if !infos[0].SignatureValid {
+		t.Errorf("SCT over the leaf: %s", infos[0].SignatureError)
+	}
+	if infos[1].SignatureValid || infos[1].SignatureError != "invalid signature" {
+		t.Errorf("SCT over another entry: valid %v, error %q", infos[1].SignatureValid, infos[1].SignatureError)
+	}
+	if infos[2].SignatureError != "unsupported SCT version 2" {
+		t.Errorf("v2 SCT: error %q", infos[2].SignatureError)
+	}
+}
+
+func TestCTLogList(t *testing.T) {
+	bundled, err := BundledCTLogList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundled.Len() == 0 {
+		t.Error("bundled log list is empty")
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		want      bool
+	}{
+		{name: "fresh", timestamp: now.Add(-24 * time.Hour)},
+		{name: "just within the limit", timestamp: now.Add(-ctLogListMaxAge + time.Hour)},
+		{name: "too old", timestamp: now.Add(-ctLogListMaxAge - time.Hour), want: true},
+		{name: "no timestamp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := &CTLogList{Timestamp: tt.timestamp}
+			if got := list.Stale(now); got != tt.want {
+				t.Errorf("stale = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var missing *CTLogList
+	if missing.Stale(now) || missing.Lookup(make([]byte, 32)) != nil || missing.Len() != 0 {
+		t.Error("a nil log list should be empty and not stale")
+	}
+
+	if _, err := ParseCTLogList([]byte(`{"operators": [{"name": "x", "logs": [{"key": "AAAA"}]}]}`), "bad"); err == nil {
+		t.Error("parsing a log with an invalid key succeeded")
+	}
+}
@@ -0,0 +1,187 @@
+package cert
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxDiscoverEndpoints caps the number of host:port pairs a discovery run
+// expands to, so a mistyped prefix length does not start millions of
+// handshakes.
+const maxDiscoverEndpoints = 1 << 16
+
+// ProbeResult is the outcome of one discovery handshake. Chain is nil when
+// the endpoint did not complete a TLS handshake.
+type ProbeResult struct {
+	Address    string
+	Connection *ConnectionInfo
+	Chain      *ChainInfo
+}
+
+// DiscoveredEndpoint is an endpoint a certificate was served on, with the
+// validity of the chain sent there.
+type DiscoveredEndpoint struct {
+	Address    string
+	StartTLS   string
+	ChainValid bool
+	Errors     []string
+}
+
+// InventoryEntry is a distinct leaf certificate and every endpoint it was
+// seen on. Fingerprint is its SHA-256 as in Identifiers.
+type InventoryEntry struct {
+	Fingerprint string
+	Certificate CertificateInfo
+	Endpoints   []DiscoveredEndpoint
+}
+
+// Inventory lists the certificates found by a discovery run, ordered by
+// expiry.
+type Inventory struct {
+	Probed     int // endpoints a handshake was attempted with
+	Responding int // endpoints that completed a handshake
+	Entries    []InventoryEntry
+}
+
+// ParsePortList parses comma-separated ports and ranges like
+// "443,8443,9000-9010".
+func ParsePortList(list string) ([]int, error) {
+	var ports []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := parsePort(first)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			if to, err = parsePort(last); err != nil {
+				return nil, err
+			}
+			if to < from {
+				return nil, fmt.Errorf("invalid port range %q", part)
+			}
+		}
+		for port := from; port <= to; port++ {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no ports given")
+	}
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// ExpandTargets combines every address in the given CIDRs, IPs or host
+// names with every port. The network and broadcast addresses of IPv4
+// prefixes shorter than /31 are skipped.
+func ExpandTargets(ranges []string, ports []int) ([]string, error) {
+	var hosts []string
+	for _, r := range ranges {
+		if !strings.Contains(r, "/") {
+			hosts = append(hosts, strings.Trim(r, "[]"))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %v", r, err)
+		}
+		prefix = prefix.Masked()
+		skipEdges := prefix.Addr().Is4() && prefix.Bits() < 31
+		for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+			if len(hosts)*len(ports) >= maxDiscoverEndpoints {
+				return nil, fmt.Errorf("more than %d endpoints to probe; use smaller ranges", maxDiscoverEndpoints)
+			}
+			if skipEdges && (addr == prefix.Addr() || !prefix.Contains(addr.Next())) {
+				continue
+			}
+			hosts = append(hosts, addr.String())
+		}
+	}
+
+	if len(hosts)*len(ports) > maxDiscoverEndpoints {
+		return nil, fmt.Errorf("more than %d endpoints to probe; use smaller ranges", maxDiscoverEndpoints)
+	}
+	targets := make([]string, 0, len(hosts)*len(ports))
+	for _, host := range hosts {
+		for _, port := range ports {
+			targets = append(targets, net.JoinHostPort(host, strconv.Itoa(port)))
+		}
+	}
+	return targets, nil
+}
+
+// BuildInventory groups the probed endpoints by the fingerprint of the
+// leaf certificate they served.
+func BuildInventory(probes []ProbeResult) *Inventory {
+	inventory := &Inventory{Probed: len(probes)}
+	index := make(map[string]int)
+
+	for _, probe := range probes {
+		chain := probe.Chain
+		if chain == nil || len(chain.Certificates) == 0 {
+			continue
+		}
+		inventory.Responding++
+
+		leaf := 0
+		if len(chain.Leaves) > 0 {
+			leaf = chain.Leaves[0]
+		}
+		info := chain.Certificates[leaf]
+		endpoint := DiscoveredEndpoint{
+			Address:    probe.Address,
+			ChainValid: chain.IsValid,
+			Errors:     chain.Errors,
+		}
+		if probe.Connection != nil {
+			endpoint.StartTLS = probe.Connection.StartTLS
+		}
+
+		fingerprint := info.Identifiers.SHA256
+		i, ok := index[fingerprint]
+		if !ok {
+			i = len(inventory.Entries)
+			index[fingerprint] = i
+			inventory.Entries = append(inventory.Entries, InventoryEntry{Fingerprint: fingerprint, Certificate: info})
+		}
+		inventory.Entries[i].Endpoints = append(inventory.Entries[i].Endpoints, endpoint)
+	}
+
+	sort.SliceStable(inventory.Entries, func(i, j int) bool {
+		return inventory.Entries[i].Certificate.NotAfter.Before(inventory.Entries[j].Certificate.NotAfter)
+	})
+	return inventory
+}
+
+// ChainValid reports whether every endpoint served a valid chain for the
+// certificate.
+func (e InventoryEntry) ChainValid() bool {
+	for _, endpoint := range e.Endpoints {
+		if !endpoint.ChainValid {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package cert
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParsePortList(t *testing.T) {
+	tests := []struct {
+		name      string
+		list      string
+		want      []int
+		wantError string
+	}{
+		{name: "single", list: "443", want: []int{443}},
+		{name: "list and range", list: "443, 8443,9000-9002", want: []int{443, 8443, 9000, 9001, 9002}},
+		{name: "duplicates", list: "443,440-444,443", want: []int{443, 440, 441, 442, 444}},
+		{name: "empty entries", list: ",443,", want: []int{443}},
+		{name: "nothing", list: " , ", wantError: "no ports given"},
+		{name: "zero", list: "0", wantError: `invalid port "0"`},
+		{name: "too large", list: "65536", wantError: `invalid port "65536"`},
+		{name: "not a number", list: "https", wantError: `invalid port "https"`},
+		{name: "reversed range", list: "9010-9000", wantError: `invalid port range "9010-9000"`},
+		{name: "open range", list: "9000-", wantError: `invalid port ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePortList(tt.list)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("error %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTargets(t *testing.T) {
+	tests := []struct {
+		name      string
+		ranges    []string
+		ports     []int
+		want      []string
+		wantCount int // checked instead of want for large ranges
+		wantError string
+	}{
+		{
+			name:   "host names and IPs",
+			ranges: []string{"mail.example.com", "192.0.2.1", "[2001:db8::1]", "2001:db8::2"},
+			ports:  []int{443},
+			want:   []string{"mail.example.com:443", "192.0.2.1:443", "[2001:db8::1]:443", "[2001:db8::2]:443"},
+		},
+		{
+			name:   "every port of every host",
+			ranges: []string{"192.0.2.1", "192.0.2.2"},
+			ports:  []int{443, 8443},
+			want:   []string{"192.0.2.1:443", "192.0.2.1:8443", "192.0.2.2:443", "192.0.2.2:8443"},
+		},
+		{
+			name:   "IPv4 prefix skips network and broadcast",
+			ranges: []string{"192.0.2.0/30"},
+			ports:  []int{443},
+			want:   []string{"192.0.2.1:443", "192.0.2.2:443"},
+		},
+		{
+			name:   "host bits are masked",
+			ranges: []string{"192.0.2.7/29"},
+			ports:  []int{25},
+			want:   []string{"192.0.2.1:25", "192.0.2.2:25", "192.0.2.3:25", "192.0.2.4:25", "192.0.2.5:25", "192.0.2.6:25"},
+		},
+		{
+			name:   "point-to-point /31",
+			ranges: []string{"192.0.2.4/31"},
+			ports:  []int{443},
+			want:   []string{"192.0.2.4:443", "192.0.2.5:443"},
+		},
+		{
+			name:   "single address /32",
+			ranges: []string{"192.0.2.9/32"},
+			ports:  []int{443},
+			want:   []string{"192.0.2.9:443"},
+		},
+		{
+			name:   "IPv6 prefix keeps every address",
+			ranges: []string{"2001:db8::/127"},
+			ports:  []int{443},
+			want:   []string{"[2001:db8::]:443", "[2001:db8::1]:443"},
+		},
+		{
+			name:      "at the limit",
+			ranges:    []string{"10.0.0.0/16"},
+			ports:     []int{443},
+			wantCount: 1<<16 - 2,
+		},
+		{
+			name:      "prefix over the limit",
+			ranges:    []string{"10.0.0.0/8"},
+			ports:     []int{443},
+			wantError: "more than 65536 endpoints",
+		},
+		{
+			name:      "ports over the limit",
+			ranges:    []string{"10.0.0.0/24"},
+			ports:     make([]int, 300),
+			wantError: "more than 65536 endpoints",
+		},
+		{name: "invalid CIDR", ranges: []string{"192.0.2.0/33"}, ports: []int{443}, wantError: `invalid CIDR "192.0.2.0/33"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandTargets(tt.ranges, tt.ports)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("error %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantCount > 0 {
+				if len(got) != tt.wantCount {
+					t.Errorf("%d targets, want %d", len(got), tt.wantCount)
+				}
+				return
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,821 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+type ExtensionField struct {
+	Name  string
+	Value string
+}
+
+type extensionDecoder func(value []byte) ([]ExtensionField, error)
+
+var extensionDecoders = map[string]extensionDecoder{
+	"2.5.29.14":               decodeSubjectKeyID,
+	"2.5.29.15":               decodeKeyUsage,
+	"2.5.29.17":               decodeGeneralNamesExtension,
+	"2.5.29.18":               decodeGeneralNamesExtension,
+	"2.5.29.19":               decodeBasicConstraints,
+	"2.5.29.20":               decodeIntegerExtension("CRL Number"),
+	"2.5.29.21":               decodeReasonCode,
+	"2.5.29.24":               decodeInvalidityDate,
+	"2.5.29.27":               decodeIntegerExtension("Base CRL Number"),
+	"2.5.29.28":               decodeIssuingDistributionPoint,
+	"2.5.29.29":               decodeGeneralNamesExtension,
+	"2.5.29.30":               decodeNameConstraints,
+	"2.5.29.31":               decodeCRLDistributionPoints,
+	"2.5.29.32":               decodeCertificatePolicies,
+	"2.5.29.33":               decodePolicyMappings,
+	"2.5.29.35":               decodeAuthorityKeyID,
+	"2.5.29.36":               decodePolicyConstraints,
+	"2.5.29.37":               decodeExtKeyUsage,
+	"2.5.29.46":               decodeCRLDistributionPoints,
+	"2.5.29.54":               decodeIntegerExtension("Skip Certs"),
+	"1.3.6.1.5.5.7.1.1":       decodeAccessDescriptions,
+	"1.3.6.1.5.5.7.1.11":      decodeAccessDescriptions,
+	"1.3.6.1.5.5.7.1.24":      decodeTLSFeature,
+	"1.3.6.1.5.5.7.48.1.5":    decodeNote("OCSP responses signed by this certificate are not checked for revocation"),
+	oidSCTList:                decodeSCTList,
+	"1.3.6.1.4.1.11129.2.4.3": decodeNote("Precertificate, not valid for use in TLS"),
+	"1.3.6.1.4.1.311.20.2":    decodeStringExtension("Template"),
+	"1.3.6.1.4.1.311.21.7":    decodeCertificateTemplate,
+	"2.16.840.1.113730.1.1":   decodeNetscapeCertType,
+	"2.16.840.1.113730.1.13":  decodeStringExtension("Comment"),
+}
+
+var crlReasonFlags = []string{
+	"Unused", "Key Compromise", "CA Compromise", "Affiliation Changed", "Superseded",
+	"Cessation Of Operation", "Certificate Hold", "Privilege Withdrawn", "AA Compromise",
+}
+
+var netscapeCertTypes = []string{
+	"SSL Client", "SSL Server", "S/MIME", "Object Signing", "Reserved", "SSL CA", "S/MIME CA", "Object Signing CA",
+}
+
+func analyzeExtensions(exts []pkix.Extension) []ExtensionInfo {
+	var extensions []ExtensionInfo
+
+	for _, ext := range exts {
+		extensions = append(extensions, analyzeExtension(ext))
+	}
+
+	return extensions
+}
+
+func analyzeExtension(ext pkix.Extension) ExtensionInfo {
+	info := ExtensionInfo{
+		OID:      ext.Id.String(),
+		Critical: ext.Critical,
+		Name:     getExtensionName(ext.Id.String()),
+		Raw:      fmt.Sprintf("%x", ext.Value),
+	}
+
+	if decode, ok := extensionDecoders[info.OID]; ok {
+		fields, err := decode(ext.Value)
+		if err != nil {
+			fields = []ExtensionField{{Name: "Error", Value: fmt.Sprintf("failed to decode: %v", err)}}
+		}
+		info.Fields = fields
+	}
+
+	if len(info.Fields) > 0 {
+		lines := make([]string, len(info.Fields))
+		for i, field := range info.Fields {
+			lines[i] = field.Name + ": " + field.Value
+		}
+		info.Value = strings.Join(lines, "\n")
+	} else {
+		info.Value = info.Raw
+	}
+
+	return info
+}
+
+func getExtensionName(oid string) string {
+	if name, ok := extensionNames[oid]; ok {
+		return name
+	}
+	return "Unknown Extension"
+}
+
+func decodeSubjectKeyID(value []byte) ([]ExtensionField, error) {
+	var id []byte
+	if err := unmarshalAll(value, &id); err != nil {
+		return nil, err
+	}
+	return []ExtensionField{{Name: "Key ID", Value: hexColon(id)}}, nil
+}
+
+func decodeAuthorityKeyID(value []byte) ([]ExtensionField, error) {
+	elems, err := parseSequence(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, elem := range elems {
+		if elem.Class != asn1.ClassContextSpecific {
+			continue
+		}
+		switch elem.Tag {
+		case 0:
+			fields = append(fields, ExtensionField{Name: "Key ID", Value: hexColon(elem.Bytes)})
+		case 1:
+			names, err := parseElements(elem.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			for _, name := range names {
+				kind, value := describeGeneralName(name)
+				fields = append(fields, ExtensionField{Name: "Issuer", Value: kind + ": " + value})
+			}
+		case 2:
+			fields = append(fields, ExtensionField{Name: "Serial", Value: hexColon(elem.Bytes)})
+		}
+	}
+	return fields, nil
+}
+
+func decodeKeyUsage(value []byte) ([]ExtensionField, error) {
+	var bits asn1.BitString
+	if err := unmarshalAll(value, &bits); err != nil {
+		return nil, err
+	}
+
+	var usage x509.KeyUsage
+	for i := 0; i < 9; i++ {
+		if bits.At(i) != 0 {
+			usage |= 1 << uint(i)
+		}
+	}
+
+	var fields []ExtensionField
+	for _, name := range parseKeyUsage(usage) {
+		fields = append(fields, ExtensionField{Name: "Usage", Value: name})
+	}
+	return fields, nil
+}
+
+func decodeExtKeyUsage(value []byte) ([]ExtensionField, error) {
+	var oids []asn1.ObjectIdentifier
+	if err := unmarshalAll(value, &oids); err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, oid := range oids {
+		fields = append(fields, ExtensionField{Name: "Purpose", Value: oidName(extKeyUsageNames, oid.String())})
+	}
+	return fields, nil
+}
+
+func decodeBasicConstraints(value []byte) ([]ExtensionField, error) {
+	var constraints struct {
+		IsCA       bool `asn1:"optional"`
+		MaxPathLen int  `asn1:"optional,default:-1"`
+	}
+	if err := unmarshalAll(value, &constraints); err != nil {
+		return nil, err
+	}
+
+	fields := []ExtensionField{{Name: "CA", Value: strings.ToUpper(fmt.Sprint(constraints.IsCA))}}
+	if constraints.IsCA {
+		pathLen := "unlimited"
+		if constraints.MaxPathLen >= 0 {
+			pathLen = fmt.Sprint(constraints.MaxPathLen)
+		}
+		fields = append(fields, ExtensionField{Name: "Path Length", Value: pathLen})
+	}
+	return fields, nil
+}
+
+func decodeGeneralNamesExtension(value []byte) ([]ExtensionField, error) {
+	names, err := parseSequence(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, name := range names {
+		kind, value := describeGeneralName(name)
+		fields = append(fields, ExtensionField{Name: kind, Value: value})
+	}
+	return fields, nil
+}
+
+func decodeNameConstraints(value []byte) ([]ExtensionField, error) {
+	elems, err := parseSequence(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, elem := range elems {
+		label := "Permitted"
+		if elem.Tag == 1 {
+			label = "Excluded"
+		}
+		subtrees, err := parseElements(elem.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		for _, subtree := range subtrees {
+			parts, err := parseElements(subtree.Bytes)
+			if err != nil || len(parts) == 0 {
+				return nil, fmt.Errorf("invalid general subtree")
+			}
+			kind, value := describeGeneralName(parts[0])
+			fields = append(fields, ExtensionField{Name: label, Value: kind + ": " + value})
+		}
+	}
+	return fields, nil
+}
+
+func decodeCRLDistributionPoints(value []byte) ([]ExtensionField, error) {
+	points, err := parseSequence(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, point := range points {
+		parts, err := parseElements(point.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range parts {
+			switch part.Tag {
+			case 0:
+				nameFields, err := decodeDistributionPointName(part)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, nameFields...)
+			case 1:
+				fields = append(fields, ExtensionField{Name: "Reasons", Value: strings.Join(bitNames(rawBitString(part), crlReasonFlags), ", ")})
+			case 2:
+				issuers, err := parseElements(part.Bytes)
+				if err != nil {
+					return nil, err
+				}
+				for _, issuer := range issuers {
+					fields = append(fields, ExtensionField{Name: "CRL Issuer", Value: describeLocation(issuer)})
+				}
+			}
+		}
+	}
+	return fields, nil
+}
+
+// decodeDistributionPointName decodes the [0] DistributionPointName shared
+// by CRL distribution points and the Issuing Distribution Point.
+func decodeDistributionPointName(part asn1.RawValue) ([]ExtensionField, error) {
+	// DistributionPointName is a CHOICE, so its tag is explicit
+	var name asn1.RawValue
+	if _, err := asn1.Unmarshal(part.Bytes, &name); err != nil {
+		return nil, err
+	}
+	if name.Tag != 0 {
+		return []ExtensionField{{Name: "Relative Name", Value: hexColon(name.Bytes)}}, nil
+	}
+	fullNames, err := parseElements(name.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	var fields []ExtensionField
+	for _, fullName := range fullNames {
+		fields = append(fields, ExtensionField{Name: "Distribution Point", Value: describeLocation(fullName)})
+	}
+	return fields, nil
+}
+
+// decodeIssuingDistributionPoint decodes the scope of a CRL (RFC 5280,
+// section 5.2.5). Flags are only listed when set.
+func decodeIssuingDistributionPoint(value []byte) ([]ExtensionField, error) {
+	parts, err := parseSequence(value)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := map[int]string{
+		1: "Only User Certificates",
+		2: "Only CA Certificates",
+		4: "Indirect CRL",
+		5: "Only Attribute Certificates",
+	}
+	var fields []ExtensionField
+	for _, part := range parts {
+		switch part.Tag {
+		case 0:
+			nameFields, err := decodeDistributionPointName(part)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nameFields...)
+		case 3:
+			fields = append(fields, ExtensionField{Name: "Only Some Reasons", Value: strings.Join(bitNames(rawBitString(part), crlReasonFlags), ", ")})
+		default:
+			if name, ok := flags[part.Tag]; ok && len(part.Bytes) == 1 && part.Bytes[0] != 0 {
+				fields = append(fields, ExtensionField{Name: name, Value: "TRUE"})
+			}
+		}
+	}
+	return fields, nil
+}
+
+func decodeReasonCode(value []byte) ([]ExtensionField, error) {
+	var reason asn1.Enumerated
+	if err := unmarshalAll(value, &reason); err != nil {
+		return nil, err
+	}
+	return []ExtensionField{{Name: "Reason", Value: revocationReasonName(int(reason))}}, nil
+}
+
+func decodeInvalidityDate(value []byte) ([]ExtensionField, error) {
+	var date time.Time
+	if err := unmarshalAll(value, &date); err != nil {
+		return nil, err
+	}
+	return []ExtensionField{{Name: "Invalidity Date", Value: date.UTC().Format("2006-01-02 15:04:05 UTC")}}, nil
+}
+
+func decodeCertificatePolicies(value []byte) ([]ExtensionField, error) {
+	policies, err := parseSequence(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, policy := range policies {
+		parts, err := parseElements(policy.Bytes)
+		if err != nil || len(parts) == 0 {
+			return nil, fmt.Errorf("invalid policy information")
+		}
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(parts[0].FullBytes, &oid); err != nil {
+			return nil, err
+		}
+		fields = append(fields, ExtensionField{Name: "Policy", Value: oidName(policyNames, oid.String())})
+
+		if len(parts) < 2 {
+			continue
+		}
+		qualifiers, err := parseElements(parts[1].Bytes)
+		if err != nil {
+			return nil, err
+		}
+		for _, qualifier := range qualifiers {
+			qualifierFields, err := decodePolicyQualifier(qualifier)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, qualifierFields...)
+		}
+	}
+	return fields, nil
+}
+
+func decodePolicyQualifier(qualifier asn1.RawValue) ([]ExtensionField, error) {
+	parts, err := parseElements(qualifier.Bytes)
+	if err != nil || len(parts) < 2 {
+		return nil, fmt.Errorf("invalid policy qualifier")
+	}
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(parts[0].FullBytes, &oid); err != nil {
+		return nil, err
+	}
+
+	switch oid.String() {
+	case "1.3.6.1.5.5.7.2.1":
+		cps, _ := asn1String(parts[1])
+		return []ExtensionField{{Name: "CPS", Value: cps}}, nil
+	case "1.3.6.1.5.5.7.2.2":
+		var fields []ExtensionField
+		notice, err := parseElements(parts[1].Bytes)
+		if err != nil {
+			return nil, err
+		}
+		for _, elem := range notice {
+			if text, ok := asn1String(elem); ok {
+				fields = append(fields, ExtensionField{Name: "User Notice", Value: text})
+				continue
+			}
+			// NoticeReference: organization and notice numbers
+			ref, err := parseElements(elem.Bytes)
+			if err != nil || len(ref) == 0 {
+				continue
+			}
+			organization, _ := asn1String(ref[0])
+			fields = append(fields, ExtensionField{Name: "Notice Reference", Value: organization})
+		}
+		return fields, nil
+	default:
+		return []ExtensionField{{Name: oidName(policyQualifierNames, oid.String()), Value: hexColon(parts[1].FullBytes)}}, nil
+	}
+}
+
+func decodePolicyMappings(value []byte) ([]ExtensionField, error) {
+	var mappings []struct {
+		IssuerDomainPolicy  asn1.ObjectIdentifier
+		SubjectDomainPolicy asn1.ObjectIdentifier
+	}
+	if err := unmarshalAll(value, &mappings); err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, mapping := range mappings {
+		fields = append(fields, ExtensionField{
+			Name:  "Mapping",
+			Value: oidName(policyNames, mapping.IssuerDomainPolicy.String()) + " → " + oidName(policyNames, mapping.SubjectDomainPolicy.String()),
+		})
+	}
+	return fields, nil
+}
+
+func decodePolicyConstraints(value []byte) ([]ExtensionField, error) {
+	elems, err := parseSequence(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, elem := range elems {
+		skip := new(big.Int).SetBytes(elem.Bytes).String()
+		switch elem.Tag {
+		case 0:
+			fields = append(fields, ExtensionField{Name: "Require Explicit Policy", Value: skip})
+		case 1:
+			fields = append(fields, ExtensionField{Name: "Inhibit Policy Mapping", Value: skip})
+		}
+	}
+	return fields, nil
+}
+
+func decodeAccessDescriptions(value []byte) ([]ExtensionField, error) {
+	descriptions, err := parseSequence(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, description := range descriptions {
+		parts, err := parseElements(description.Bytes)
+		if err != nil || len(parts) < 2 {
+			return nil, fmt.Errorf("invalid access description")
+		}
+		var method asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(parts[0].FullBytes, &method); err != nil {
+			return nil, err
+		}
+		name, ok := accessMethodNames[method.String()]
+		if !ok {
+			name = method.String()
+		}
+		fields = append(fields, ExtensionField{Name: name, Value: describeLocation(parts[1])})
+	}
+	return fields, nil
+}
+
+func decodeTLSFeature(value []byte) ([]ExtensionField, error) {
+	var features []int
+	if err := unmarshalAll(value, &features); err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, feature := range features {
+		name := fmt.Sprintf("extension %d", feature)
+		switch feature {
+		case 5:
+			name = "status_request (OCSP Must-Staple)"
+		case 17:
+			name = "status_request_v2"
+		}
+		fields = append(fields, ExtensionField{Name: "Feature", Value: name})
+	}
+	return fields, nil
+}
+
+func decodeSCTList(value []byte) ([]ExtensionField, error) {
+	scts, err := parseSCTExtension(value)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, _ := BundledCTLogList()
+	var fields []ExtensionField
+	for _, sct := range scts {
+		log := sct.LogIDBase64()
+		if known := logs.Lookup(sct.LogID); known != nil {
+			log = known.Description
+		}
+		fields = append(fields, ExtensionField{
+			Name: "SCT",
+			Value: fmt.Sprintf("v%d from %s at %s (%s/%s)",
+				sct.Version+1, log, sct.Timestamp.Format(time.RFC3339), sct.HashAlgorithm, sct.SigAlgorithm),
+		})
+	}
+	return fields, nil
+}
+
+func decodeCertificateTemplate(value []byte) ([]ExtensionField, error) {
+	var template struct {
+		ID           asn1.ObjectIdentifier
+		MajorVersion int `asn1:"optional"`
+		MinorVersion int `asn1:"optional"`
+	}
+	if err := unmarshalAll(value, &template); err != nil {
+		return nil, err
+	}
+	return []ExtensionField{
+		{Name: "Template", Value: template.ID.String()},
+		{Name: "Version", Value: fmt.Sprintf("%d.%d", template.MajorVersion, template.MinorVersion)},
+	}, nil
+}
+
+func decodeNetscapeCertType(value []byte) ([]ExtensionField, error) {
+	var bits asn1.BitString
+	if err := unmarshalAll(value, &bits); err != nil {
+		return nil, err
+	}
+
+	var fields []ExtensionField
+	for _, name := range bitNames(bits, netscapeCertTypes) {
+		fields = append(fields, ExtensionField{Name: "Type", Value: name})
+	}
+	return fields, nil
+}
+
+func decodeIntegerExtension(label string) extensionDecoder {
+	return func(value []byte) ([]ExtensionField, error) {
+		var n *big.Int
+		if err := unmarshalAll(value, &n); err != nil {
+			return nil, err
+		}
+		return []ExtensionField{{Name: label, Value: n.String()}}, nil
+	}
+}
+
+func decodeStringExtension(label string) extensionDecoder {
+	return func(value []byte) ([]ExtensionField, error) {
+		var raw asn1.RawValue
+		if err := unmarshalAll(value, &raw); err != nil {
+			return nil, err
+		}
+		s, ok := asn1String(raw)
+		if !ok {
+			return nil, fmt.Errorf("unexpected ASN.1 tag %d", raw.Tag)
+		}
+		return []ExtensionField{{Name: label, Value: s}}, nil
+	}
+}
+
+func decodeNote(note string) extensionDecoder {
+	return func(value []byte) ([]ExtensionField, error) {
+		return []ExtensionField{{Name: "Note", Value: note}}, nil
+	}
+}
+
+// describeGeneralName returns the kind and printable value of a GeneralName
+// (RFC 5280, section 4.2.1.6).
+func describeGeneralName(name asn1.RawValue) (string, string) {
+	if name.Class != asn1.ClassContextSpecific {
+		return "Unknown", hexColon(name.FullBytes)
+	}
+
+	switch name.Tag {
+	case 0:
+		oid, value, err := parseOtherName(name)
+		if err != nil {
+			return "otherName", hexColon(name.Bytes)
+		}
+		return "otherName", oidName(otherNameTypes, oid.String()) + ": " + value
+	case 1:
+		return "Email", string(name.Bytes)
+	case 2:
+		return "DNS", string(name.Bytes)
+	case 3:
+		return "X.400 Address", hexColon(name.Bytes)
+	case 4:
+		var rdns pkix.RDNSequence
+		if _, err := asn1.Unmarshal(name.Bytes, &rdns); err != nil {
+			return "Directory Name", hexColon(name.Bytes)
+		}
+		var dn pkix.Name
+		dn.FillFromRDNSequence(&rdns)
+		return "Directory Name", dn.String()
+	case 5:
+		return "EDI Party Name", hexColon(name.Bytes)
+	case 6:
+		return "URI", string(name.Bytes)
+	case 7:
+		return "IP", formatIPBytes(name.Bytes)
+	case 8:
+		oid, err := parseImplicitOID(name.Bytes)
+		if err != nil {
+			return "Registered ID", hexColon(name.Bytes)
+		}
+		return "Registered ID", oid.String()
+	default:
+		return "Unknown", hexColon(name.FullBytes)
+	}
+}
+
+// describeLocation prints URIs as-is and prefixes every other kind of
+// GeneralName with its type.
+func describeLocation(name asn1.RawValue) string {
+	kind, value := describeGeneralName(name)
+	if kind == "URI" {
+		return value
+	}
+	return kind + ": " + value
+}
+
+func parseOtherName(name asn1.RawValue) (asn1.ObjectIdentifier, string, error) {
+	parts, err := parseElements(name.Bytes)
+	if err != nil || len(parts) < 2 {
+		return nil, "", fmt.Errorf("invalid otherName")
+	}
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(parts[0].FullBytes, &oid); err != nil {
+		return nil, "", err
+	}
+	var value asn1.RawValue
+	if _, err := asn1.Unmarshal(parts[1].Bytes, &value); err != nil {
+		return nil, "", err
+	}
+	if s, ok := asn1String(value); ok {
+		return oid, s, nil
+	}
+	if oid.String() == oidKerberosPrincipal {
+		if s, ok := parseKerberosPrincipal(value); ok {
+			return oid, s, nil
+		}
+	}
+	return oid, hexColon(value.FullBytes), nil
+}
+
+const oidKerberosPrincipal = "1.3.6.1.5.2.2"
+
+// parseKerberosPrincipal renders a KRB5PrincipalName (RFC 4556) as
+// "name/instance@REALM".
+func parseKerberosPrincipal(value asn1.RawValue) (string, bool) {
+	var principal struct {
+		Realm string `asn1:"explicit,tag:0,ia5"`
+		Name  struct {
+			Type  int             `asn1:"explicit,tag:0"`
+			Parts []asn1.RawValue `asn1:"explicit,tag:1"`
+		} `asn1:"explicit,tag:1"`
+	}
+	if _, err := asn1.Unmarshal(value.FullBytes, &principal); err != nil {
+		return "", false
+	}
+	var parts []string
+	for _, part := range principal.Name.Parts {
+		s, ok := asn1String(part)
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, "/") + "@" + principal.Realm, true
+}
+
+func parseImplicitOID(content []byte) (asn1.ObjectIdentifier, error) {
+	der, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagOID, Bytes: content})
+	if err != nil {
+		return nil, err
+	}
+	var oid asn1.ObjectIdentifier
+	_, err = asn1.Unmarshal(der, &oid)
+	return oid, err
+}
+
+func formatIPBytes(b []byte) string {
+	switch len(b) {
+	case net.IPv4len, net.IPv6len:
+		return net.IP(b).String()
+	case 2 * net.IPv4len, 2 * net.IPv6len:
+		// Name constraints carry an address followed by a mask
+		half := len(b) / 2
+		ipNet := net.IPNet{IP: net.IP(b[:half]), Mask: net.IPMask(b[half:])}
+		return ipNet.String()
+	default:
+		return hexColon(b)
+	}
+}
+
+// asn1String decodes the ASN.1 string types found in certificates.
+func asn1String(v asn1.RawValue) (string, bool) {
+	if v.Class != asn1.ClassUniversal {
+		return "", false
+	}
+
+	switch v.Tag {
+	case asn1.TagUTF8String, asn1.TagPrintableString, asn1.TagIA5String, 26 /* VisibleString */, 27 /* GeneralString */ :
+		return string(v.Bytes), true
+	case asn1.TagT61String:
+		// Treated as Latin-1, as most implementations do
+		runes := make([]rune, len(v.Bytes))
+		for i, b := range v.Bytes {
+			runes[i] = rune(b)
+		}
+		return string(runes), true
+	case asn1.TagBMPString:
+		if len(v.Bytes)%2 != 0 {
+			return "", false
+		}
+		units := make([]uint16, len(v.Bytes)/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(v.Bytes[2*i:])
+		}
+		return string(utf16.Decode(units)), true
+	case 28: // UniversalString
+		if len(v.Bytes)%4 != 0 {
+			return "", false
+		}
+		runes := make([]rune, len(v.Bytes)/4)
+		for i := range runes {
+			runes[i] = rune(binary.BigEndian.Uint32(v.Bytes[4*i:]))
+		}
+		return string(runes), true
+	default:
+		return "", false
+	}
+}
+
+func parseSequence(data []byte) ([]asn1.RawValue, error) {
+	var seq asn1.RawValue
+	if err := unmarshalAll(data, &seq); err != nil {
+		return nil, err
+	}
+	if seq.Class != asn1.ClassUniversal || !seq.IsCompound {
+		return nil, fmt.Errorf("expected SEQUENCE, got tag %d", seq.Tag)
+	}
+	return parseElements(seq.Bytes)
+}
+
+func parseElements(data []byte) ([]asn1.RawValue, error) {
+	var elems []asn1.RawValue
+	for len(data) > 0 {
+		var elem asn1.RawValue
+		var err error
+		data, err = asn1.Unmarshal(data, &elem)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+	}
+	return elems, nil
+}
+
+func unmarshalAll(data []byte, v interface{}) error {
+	rest, err := asn1.Unmarshal(data, v)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("trailing data after ASN.1 value")
+	}
+	return nil
+}
+
+// rawBitString decodes a BIT STRING carried under an implicit tag.
+func rawBitString(v asn1.RawValue) asn1.BitString {
+	if len(v.Bytes) == 0 {
+		return asn1.BitString{}
+	}
+	return asn1.BitString{
+		Bytes:     v.Bytes[1:],
+		BitLength: (len(v.Bytes)-1)*8 - int(v.Bytes[0]),
+	}
+}
+
+func bitNames(bits asn1.BitString, names []string) []string {
+	var set []string
+	for i, name := range names {
+		if bits.At(i) != 0 {
+			set = append(set, name)
+		}
+	}
+	return set
+}
+
+func hexColon(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = fmt.Sprintf("%02X", c)
+	}
+	return strings.Join(parts, ":")
+}
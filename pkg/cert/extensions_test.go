@@ -0,0 +1,287 @@
+package cert
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtensionDecoders(t *testing.T) {
+	utf8 := func(s string) []byte { return der(asn1.ClassUniversal, asn1.TagUTF8String, false, []byte(s)) }
+	ia5 := func(s string) []byte { return der(asn1.ClassUniversal, asn1.TagIA5String, false, []byte(s)) }
+	explicit := func(tag int, content ...[]byte) []byte { return derContext(tag, true, content...) }
+	uri := func(s string) []byte { return derContext(6, false, []byte(s)) }
+	integer := func(n int) []byte {
+		encoded, _ := asn1.Marshal(n)
+		return encoded
+	}
+
+	tests := []struct {
+		name  string
+		oid   string
+		value []byte
+		want  []ExtensionField
+	}{
+		{
+			name:  "subject key identifier",
+			oid:   "2.5.29.14",
+			value: der(asn1.ClassUniversal, asn1.TagOctetString, false, []byte{0x01, 0xab, 0xff}),
+			want:  []ExtensionField{{"Key ID", "01:AB:FF"}},
+		},
+		{
+			name:  "key usage",
+			oid:   "2.5.29.15",
+			value: []byte{0x03, 0x02, 0x05, 0xa0},
+			want:  []ExtensionField{{"Usage", "Digital Signature"}, {"Usage", "Key Encipherment"}},
+		},
+		{
+			name: "subject alternative names",
+			oid:  "2.5.29.17",
+			value: derSequence(
+				derContext(2, false, []byte("www.example.com")),
+				derContext(7, false, []byte{192, 0, 2, 1}),
+				derContext(1, false, []byte("admin@example.com")),
+				uri("https://example.com/"),
+				derContext(0, true, derOID(t, "1.3.6.1.4.1.311.20.2.3"), explicit(0, utf8("user@example.com"))),
+			),
+			want: []ExtensionField{
+				{"DNS", "www.example.com"},
+				{"IP", "192.0.2.1"},
+				{"Email", "admin@example.com"},
+				{"URI", "https://example.com/"},
+				{"otherName", "User Principal Name (1.3.6.1.4.1.311.20.2.3): user@example.com"},
+			},
+		},
+		{
+			name: "Kerberos principal name",
+			oid:  "2.5.29.17",
+			value: derSequence(derContext(0, true, derOID(t, "1.3.6.1.5.2.2"), explicit(0, derSequence(
+				explicit(0, der(asn1.ClassUniversal, 27, false, []byte("EXAMPLE.COM"))),
+				explicit(1, derSequence(
+					explicit(0, integer(1)),
+					explicit(1, derSequence(
+						der(asn1.ClassUniversal, 27, false, []byte("host")),
+						der(asn1.ClassUniversal, 27, false, []byte("www.example.com")),
+					)),
+				)),
+			)))),
+			want: []ExtensionField{{"otherName", "Kerberos Principal Name (1.3.6.1.5.2.2): host/www.example.com@EXAMPLE.COM"}},
+		},
+		{
+			name:  "basic constraints of a CA with a path length",
+			oid:   "2.5.29.19",
+			value: []byte{0x30, 0x06, 0x01, 0x01, 0xff, 0x02, 0x01, 0x00},
+			want:  []ExtensionField{{"CA", "TRUE"}, {"Path Length", "0"}},
+		},
+		{
+			name:  "basic constraints of a CA without a path length",
+			oid:   "2.5.29.19",
+			value: []byte{0x30, 0x03, 0x01, 0x01, 0xff},
+			want:  []ExtensionField{{"CA", "TRUE"}, {"Path Length", "unlimited"}},
+		},
+		{
+			name:  "basic constraints of an end entity",
+			oid:   "2.5.29.19",
+			value: []byte{0x30, 0x00},
+			want:  []ExtensionField{{"CA", "FALSE"}},
+		},
+		{
+			name:  "CRL number",
+			oid:   "2.5.29.20",
+			value: integer(4242),
+			want:  []ExtensionField{{"CRL Number", "4242"}},
+		},
+		{
+			name:  "reason code",
+			oid:   "2.5.29.21",
+			value: der(asn1.ClassUniversal, asn1.TagEnum, false, []byte{0x01}),
+			want:  []ExtensionField{{"Reason", "keyCompromise"}},
+		},
+		{
+			name: "issuing distribution point",
+			oid:  "2.5.29.28",
+			value: derSequence(
+				explicit(0, derContext(0, true, uri("http://crl.example.com/ca.crl"))),
+				derContext(1, false, []byte{0xff}),
+				derContext(3, false, []byte{0x07, 0x80}),
+			),
+			want: []ExtensionField{
+				{"Distribution Point", "http://crl.example.com/ca.crl"},
+				{"Only User Certificates", "TRUE"},
+				{"Only Some Reasons", "Unused"},
+			},
+		},
+		{
+			name: "name constraints",
+			oid:  "2.5.29.30",
+			value: derSequence(
+				derContext(0, true, derSequence(derContext(2, false, []byte(".example.com")))),
+				derContext(1, true, derSequence(derContext(7, false, []byte{10, 0, 0, 0, 255, 0, 0, 0}))),
+			),
+			want: []ExtensionField{{"Permitted", "DNS: .example.com"}, {"Excluded", "IP: 10.0.0.0/8"}},
+		},
+		{
+			name: "CRL distribution points",
+			oid:  "2.5.29.31",
+			value: derSequence(derSequence(
+				explicit(0, derContext(0, true, uri("http://crl.example.com/ca.crl"))),
+				derContext(1, false, []byte{0x05, 0x60}),
+				derContext(2, true, explicit(4, derSequence())),
+			)),
+			want: []ExtensionField{
+				{"Distribution Point", "http://crl.example.com/ca.crl"},
+				{"Reasons", "Key Compromise, CA Compromise"},
+				{"CRL Issuer", "Directory Name: "},
+			},
+		},
+		{
+			name: "certificate policies",
+			oid:  "2.5.29.32",
+			value: derSequence(
+				derSequence(derOID(t, "2.23.140.1.2.1")),
+				derSequence(derOID(t, "1.3.6.1.4.1.99999.1"), derSequence(
+					derSequence(derOID(t, "1.3.6.1.5.5.7.2.1"), ia5("https://example.com/cps")),
+					derSequence(derOID(t, "1.3.6.1.5.5.7.2.2"), derSequence(utf8("Test notice"))),
+				)),
+			),
+			want: []ExtensionField{
+				{"Policy", "Domain Validated (CA/B Forum) (2.23.140.1.2.1)"},
+				{"Policy", "1.3.6.1.4.1.99999.1"},
+				{"CPS", "https://example.com/cps"},
+				{"User Notice", "Test notice"},
+			},
+		},
+		{
+			name:  "authority key identifier",
+			oid:   "2.5.29.35",
+			value: derSequence(derContext(0, false, []byte{0xde, 0xad})),
+			want:  []ExtensionField{{"Key ID", "DE:AD"}},
+		},
+		{
+			name: "extended key usage",
+			oid:  "2.5.29.37",
+			value: derSequence(
+				derOID(t, "1.3.6.1.5.5.7.3.1"),
+				derOID(t, "1.3.6.1.5.5.7.3.2"),
+				derOID(t, "1.2.3.4"),
+			),
+			want: []ExtensionField{
+				{"Purpose", "Server Authentication (1.3.6.1.5.5.7.3.1)"},
+				{"Purpose", "Client Authentication (1.3.6.1.5.5.7.3.2)"},
+				{"Purpose", "1.2.3.4"},
+			},
+		},
+		{
+			name: "authority information access",
+			oid:  "1.3.6.1.5.5.7.1.1",
+			value: derSequence(
+				derSequence(derOID(t, "1.3.6.1.5.5.7.48.1"), uri("http://ocsp.example.com")),
+				derSequence(derOID(t, "1.3.6.1.5.5.7.48.2"), uri("http://ca.example.com/ca.crt")),
+			),
+			want: []ExtensionField{{"OCSP", "http://ocsp.example.com"}, {"CA Issuers", "http://ca.example.com/ca.crt"}},
+		},
+		{
+			name:  "TLS feature",
+			oid:   "1.3.6.1.5.5.7.1.24",
+			value: derSequence(integer(5), integer(23)),
+			want:  []ExtensionField{{"Feature", "status_request (OCSP Must-Staple)"}, {"Feature", "extension 23"}},
+		},
+		{
+			name:  "certificate template name in a BMPString",
+			oid:   "1.3.6.1.4.1.311.20.2",
+			value: der(asn1.ClassUniversal, asn1.TagBMPString, false, []byte{0x00, 'W', 0x00, 'e', 0x00, 'b'}),
+			want:  []ExtensionField{{"Template", "Web"}},
+		},
+		{
+			name:  "Netscape certificate type",
+			oid:   "2.16.840.1.113730.1.1",
+			value: []byte{0x03, 0x02, 0x06, 0x40},
+			want:  []ExtensionField{{"Type", "SSL Server"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decode, ok := extensionDecoders[tt.oid]
+			if !ok {
+				t.Fatalf("no decoder for %s", tt.oid)
+			}
+			fields, err := decode(tt.value)
+			if err != nil {
+				t.Fatalf("decode %x: %v", tt.value, err)
+			}
+			if !reflect.DeepEqual(fields, tt.want) {
+				t.Errorf("got %q, want %q", fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtensionDecodersRejectMalformedValues(t *testing.T) {
+	malformed := [][]byte{nil, {0x30}, {0x30, 0x05, 0x02, 0x01}}
+	for oid, decode := range extensionDecoders {
+		for _, value := range malformed {
+			fields, err := decode(value)
+			// Notes do not look at the value
+			if err == nil && !(len(fields) == 1 && fields[0].Name == "Note") {
+				t.Errorf("%s: decoding %x succeeded with %q", oid, value, fields)
+			}
+		}
+	}
+}
+
+func TestAnalyzeExtension(t *testing.T) {
+	tests := []struct {
+		name       string
+		oid        asn1.ObjectIdentifier
+		value      []byte
+		wantName   string
+		wantValue  string
+		wantFields int
+	}{
+		{
+			name:       "decoded",
+			oid:        asn1.ObjectIdentifier{2, 5, 29, 19},
+			value:      []byte{0x30, 0x03, 0x01, 0x01, 0xff},
+			wantName:   "Basic Constraints",
+			wantValue:  "CA: TRUE\nPath Length: unlimited",
+			wantFields: 2,
+		},
+		{
+			name:       "malformed",
+			oid:        asn1.ObjectIdentifier{2, 5, 29, 19},
+			value:      []byte{0x04, 0x00},
+			wantName:   "Basic Constraints",
+			wantValue:  "Error: failed to decode: ",
+			wantFields: 1,
+		},
+		{
+			name:      "unknown",
+			oid:       asn1.ObjectIdentifier{1, 2, 3, 4},
+			value:     []byte{0x05, 0x00},
+			wantName:  "Unknown Extension",
+			wantValue: "0500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := analyzeExtension(pkix.Extension{Id: tt.oid, Value: tt.value})
+			if info.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", info.Name, tt.wantName)
+			}
+			if !strings.HasPrefix(info.Value, tt.wantValue) {
+				t.Errorf("value = %q, want prefix %q", info.Value, tt.wantValue)
+			}
+			if len(info.Fields) != tt.wantFields {
+				t.Errorf("got %d fields, want %d", len(info.Fields), tt.wantFields)
+			}
+			if info.Raw != hex.EncodeToString(tt.value) {
+				t.Errorf("raw = %q, want %x", info.Raw, tt.value)
+			}
+		})
+	}
+}
@@ -9,33 +9,202 @@ import (
 	"time"
 )
 
+const (
+	fetchTimeout = 10 * time.Second
+	ticketWait   = 500 * time.Millisecond
+)
+
+type FetchOptions struct {
+	// StartTLS names the plaintext protocol to upgrade before the TLS
+	// handshake (see StartTLSProtocols). Empty detects it from the port,
+	// StartTLSNone forces direct TLS.
+	StartTLS string
+	// Connect dials this address instead of the target, e.g. to test a
+	// backend before a DNS cutover. A missing port defaults to the
+	// target's port.
+	Connect string
+	// SNI overrides the server name sent in the handshake, which defaults
+	// to the target host. NoSNI omits the extension entirely.
+	SNI   string
+	NoSNI bool
+	// Timeout bounds the connection, STARTTLS upgrade and handshake.
+	// Zero uses the default of 10 seconds.
+	Timeout time.Duration
+	// ProbeResumption makes a second handshake to see whether the server
+	// resumes the session. It costs a connection and up to half a second
+	// waiting for TLS 1.3 tickets, so bulk scans leave it off.
+	ProbeResumption bool
+	// PublicNetworkOnly refuses to dial loopback, private and link-local
+	// addresses, whether named by the target or by Connect. Servers
+	// fetching targets chosen by others set it.
+	PublicNetworkOnly bool
+}
+
+// ConnectionInfo records where certificates were fetched from.
+type ConnectionInfo struct {
+	Target     string // host:port as requested
+	Address    string // address that was dialed
+	ServerName string // SNI sent in the ClientHello, empty if none
+	StartTLS   string // protocol used for the upgrade, empty for direct TLS
+}
+
+type FetchResult struct {
+	Certificates []*x509.Certificate
+	Connection   *ConnectionInfo
+	Handshake    *HandshakeInfo
+}
+
 func FetchCertificatesFromDomain(domainPort string) ([]*x509.Certificate, error) {
+	result, err := Fetch(domainPort, FetchOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return result.Certificates, nil
+}
+
+func Fetch(domainPort string, opts FetchOptions) (*FetchResult, error) {
+	t, err := resolveTarget(domainPort, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		ServerName:         t.serverName,
+		InsecureSkipVerify: true,
+	}
+	if opts.ProbeResumption {
+		config.ClientSessionCache = tls.NewLRUClientSessionCache(1)
+	}
+	if t.proto == "" {
+		// Offer ALPN only where HTTP is plausible
+		config.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	conn, err := t.handshake(config)
+	if err != nil {
+		return nil, err
+	}
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		conn.Close()
+		return nil, fmt.Errorf("no certificates received from %s", t.address)
+	}
+
+	if opts.ProbeResumption {
+		// TLS 1.3 tickets arrive after the handshake and are only processed
+		// while reading, so give the server a moment to send them.
+		conn.SetReadDeadline(time.Now().Add(ticketWait))
+		conn.Read(make([]byte, 1))
+	}
+	conn.Close()
+
+	info := newHandshakeInfo(state)
+	if opts.ProbeResumption {
+		info.ResumptionProbed = true
+		if resumed, err := t.handshake(config); err == nil {
+			info.Resumption = resumed.ConnectionState().DidResume
+			resumed.Close()
+		}
+	}
+
+	return &FetchResult{
+		Certificates: state.PeerCertificates,
+		Connection: &ConnectionInfo{
+			Target:     net.JoinHostPort(t.host, t.port),
+			Address:    t.address,
+			ServerName: t.serverName,
+			StartTLS:   t.proto,
+		},
+		Handshake: info,
+	}, nil
+}
+
+// target is a resolved fetch destination shared by Fetch and Scan.
+type target struct {
+	host       string
+	port       string
+	address    string // address to dial
+	serverName string // SNI, empty for none
+	proto      string // STARTTLS protocol, empty for direct TLS
+	timeout    time.Duration
+	publicOnly bool
+}
+
+func resolveTarget(domainPort string, opts FetchOptions) (*target, error) {
 	host, port, err := parseHostPort(domainPort)
 	if err != nil {
 		return nil, err
 	}
 
-	address := net.JoinHostPort(host, port)
+	t := &target{host: host, port: port, address: net.JoinHostPort(host, port), timeout: fetchTimeout, publicOnly: opts.PublicNetworkOnly}
+	if opts.Timeout > 0 {
+		t.timeout = opts.Timeout
+	}
+	if opts.Connect != "" {
+		t.address, err = connectAddress(opts.Connect, port)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t.proto, err = resolveStartTLS(opts.StartTLS, port)
+	if err != nil {
+		return nil, err
+	}
+
+	t.serverName = host
+	if opts.SNI != "" {
+		t.serverName = opts.SNI
+	}
+	// crypto/tls never sends IP addresses as SNI
+	if opts.NoSNI || net.ParseIP(t.serverName) != nil {
+		t.serverName = ""
+	}
+
+	return t, nil
+}
 
+// handshake dials the target, performs the STARTTLS upgrade if needed and
+// completes a TLS handshake.
+func (t *target) handshake(config *tls.Config) (*tls.Conn, error) {
 	dialer := &net.Dialer{
-		Timeout: 10 * time.Second,
+		Timeout: t.timeout,
+	}
+	if t.publicOnly {
+		dialer.Control = publicDialControl
 	}
 
-	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{
-		ServerName:         host,
-		InsecureSkipVerify: true,
-	})
+	rawConn, err := dialer.Dial("tcp", t.address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to %s: %v", address, err)
+		return nil, fmt.Errorf("failed to connect to %s: %v", t.address, err)
 	}
-	defer conn.Close()
+	rawConn.SetDeadline(time.Now().Add(t.timeout))
 
-	state := conn.ConnectionState()
-	if len(state.PeerCertificates) == 0 {
-		return nil, fmt.Errorf("no certificates received from %s", address)
+	if t.proto != "" {
+		if err := startTLS(rawConn, t.proto, t.host); err != nil {
+			rawConn.Close()
+			return nil, fmt.Errorf("STARTTLS (%s) with %s failed: %v", t.proto, t.address, err)
+		}
 	}
 
-	return state.PeerCertificates, nil
+	conn := tls.Client(rawConn, config)
+	if err := conn.Handshake(); err != nil {
+		rawConn.Close()
+		return nil, fmt.Errorf("TLS handshake with %s failed: %v", t.address, err)
+	}
+	return conn, nil
+}
+
+func connectAddress(connect, defaultPort string) (string, error) {
+	host, port, err := net.SplitHostPort(connect)
+	if err != nil {
+		// No port given; strip brackets from bare IPv6 literals
+		host, port = strings.Trim(connect, "[]"), defaultPort
+	}
+	if host == "" {
+		return "", fmt.Errorf("invalid connect address %q", connect)
+	}
+	return net.JoinHostPort(host, port), nil
 }
 
 func parseHostPort(domainPort string) (host, port string, err error) {
@@ -54,4 +223,4 @@ func parseHostPort(domainPort string) (host, port string, err error) {
 	}
 
 	return host, port, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,499 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+const (
+	FindingMisordered = "misordered"
+	FindingDuplicate  = "duplicate"
+	FindingUnused     = "unused"
+	FindingMissing    = "missing"
+	FindingTruncated  = "truncated"
+)
+
+type ChainFinding struct {
+	Kind        string
+	Certificate int // index in the input, -1 if not tied to one certificate
+	Message     string
+}
+
+// issuerGraph links every certificate to the certificates in the input that
+// issued it, independent of the order in which they were supplied.
+type issuerGraph struct {
+	certs      []*x509.Certificate
+	issuers    [][]int
+	issued     [][]int
+	duplicates map[int]int // index of a duplicate -> index of the first copy
+	leaves     []int
+}
+
+func buildIssuerGraph(certs []*x509.Certificate) *issuerGraph {
+	g := &issuerGraph{
+		certs:      certs,
+		issuers:    make([][]int, len(certs)),
+		issued:     make([][]int, len(certs)),
+		duplicates: make(map[int]int),
+	}
+
+	for i, cert := range certs {
+		for j := 0; j < i; j++ {
+			if _, dup := g.duplicates[j]; !dup && bytes.Equal(cert.Raw, certs[j].Raw) {
+				g.duplicates[i] = j
+				break
+			}
+		}
+	}
+
+	for i, child := range certs {
+		if g.isDuplicate(i) {
+			continue
+		}
+		for j, parent := range certs {
+			if i == j || g.isDuplicate(j) {
+				continue
+			}
+			if isIssuedBy(child, parent) {
+				g.issuers[i] = append(g.issuers[i], j)
+				g.issued[j] = append(g.issued[j], i)
+			}
+		}
+	}
+
+	g.leaves = g.findLeaves()
+	return g
+}
+
+func (g *issuerGraph) isDuplicate(i int) bool {
+	_, ok := g.duplicates[i]
+	return ok
+}
+
+// findLeaves returns the certificates that did not issue anything else in
+// the input. End-entity certificates are preferred over CAs so that an
+// unrelated root does not show up as a second leaf.
+func (g *issuerGraph) findLeaves() []int {
+	var endEntities, cas []int
+	for i, cert := range g.certs {
+		if g.isDuplicate(i) || len(g.issued[i]) > 0 {
+			continue
+		}
+		if cert.IsCA {
+			cas = append(cas, i)
+		} else {
+			endEntities = append(endEntities, i)
+		}
+	}
+
+	switch {
+	case len(endEntities) > 0:
+		return endEntities
+	case len(cas) > 0:
+		return cas[:1]
+	case len(g.certs) > 0:
+		return []int{0}
+	default:
+		return nil
+	}
+}
+
+// primaryPath follows the first issuer of each certificate, starting at the
+// given leaf, until it reaches a root or a certificate without an issuer.
+func (g *issuerGraph) primaryPath(leaf int) []int {
+	path := []int{leaf}
+	seen := map[int]bool{leaf: true}
+
+	for current := leaf; ; {
+		next := -1
+		for _, issuer := range g.issuers[current] {
+			if !seen[issuer] {
+				next = issuer
+				break
+			}
+		}
+		if next < 0 {
+			return path
+		}
+		path = append(path, next)
+		seen[next] = true
+		current = next
+	}
+}
+
+func (g *issuerGraph) reachable() map[int]bool {
+	reached := make(map[int]bool)
+	queue := append([]int(nil), g.leaves...)
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		if reached[i] {
+			continue
+		}
+		reached[i] = true
+		queue = append(queue, g.issuers[i]...)
+	}
+	return reached
+}
+
+func (g *issuerGraph) findings(store *TrustStore) []ChainFinding {
+	var findings []ChainFinding
+
+	for i := range g.certs {
+		if first, ok := g.duplicates[i]; ok {
+			findings = append(findings, ChainFinding{
+				Kind:        FindingDuplicate,
+				Certificate: i,
+				Message:     fmt.Sprintf("Certificate %d is a duplicate of certificate %d", i+1, first+1),
+			})
+		}
+	}
+
+	if len(g.leaves) > 0 && g.leaves[0] != 0 {
+		findings = append(findings, ChainFinding{
+			Kind:        FindingMisordered,
+			Certificate: g.leaves[0],
+			Message:     fmt.Sprintf("The leaf certificate is at position %d instead of first", g.leaves[0]+1),
+		})
+	}
+
+	reached := g.reachable()
+	for i, cert := range g.certs {
+		if g.isDuplicate(i) {
+			continue
+		}
+		if !reached[i] {
+			findings = append(findings, ChainFinding{
+				Kind:        FindingUnused,
+				Certificate: i,
+				Message:     fmt.Sprintf("Certificate %d (%s) is not part of any path from the leaf", i+1, cert.Subject),
+			})
+			continue
+		}
+
+		if len(g.issuers[i]) > 0 {
+			before := true
+			for _, issuer := range g.issuers[i] {
+				if issuer > i {
+					before = false
+					break
+				}
+			}
+			if before {
+				findings = append(findings, ChainFinding{
+					Kind:        FindingMisordered,
+					Certificate: i,
+					Message:     fmt.Sprintf("Certificate %d appears after its issuer (certificate %d)", i+1, g.issuers[i][0]+1),
+				})
+			}
+			continue
+		}
+
+		if isSelfSigned(cert) || issuedByTrustAnchor(cert, store) {
+			continue
+		}
+		findings = append(findings, ChainFinding{
+			Kind:        FindingMissing,
+			Certificate: i,
+			Message:     fmt.Sprintf("Issuer of certificate %d (%s) is neither in the input nor a trusted root", i+1, cert.Issuer),
+		})
+	}
+
+	return findings
+}
+
+func isIssuedBy(child, parent *x509.Certificate) bool {
+	if !bytes.Equal(child.RawIssuer, parent.RawSubject) {
+		return false
+	}
+	if len(child.AuthorityKeyId) > 0 && len(parent.SubjectKeyId) > 0 && !bytes.Equal(child.AuthorityKeyId, parent.SubjectKeyId) {
+		return false
+	}
+	return child.CheckSignatureFrom(parent) == nil
+}
+
+func issuedByTrustAnchor(cert *x509.Certificate, store *TrustStore) bool {
+	if store == nil {
+		return false
+	}
+	// Verify at a time the certificate is valid, so that an expired or
+	// not yet valid certificate is still recognised as issued by an anchor
+	at := time.Now()
+	if at.Before(cert.NotBefore) {
+		at = cert.NotBefore
+	} else if at.After(cert.NotAfter) {
+		at = cert.NotAfter
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:       store.Pool,
+		CurrentTime: at,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err == nil
+}
+
+// maxChainPaths bounds path enumeration for pathological inputs with many
+// cross-signed copies of the same CA.
+const maxChainPaths = 64
+
+// CertGraph is the directed graph of issuing relationships between the
+// input certificates and any trust store anchors that issued them. Edges
+// point from a certificate to its issuer.
+type CertGraph struct {
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+type GraphNode struct {
+	Certificate CertificateInfo
+	Index       int // position in the input, -1 for anchors from the trust store
+	IsAnchor    bool
+	IsTrusted   bool
+}
+
+type GraphEdge struct {
+	From      int
+	To        int
+	CrossSign bool
+}
+
+type CrossSignGroup struct {
+	Subject      string
+	Certificates []*x509.Certificate
+}
+
+func buildCertGraph(chain *ChainInfo, g *issuerGraph, store *TrustStore) *CertGraph {
+	graph := &CertGraph{}
+	nodeOf := make(map[int]int)
+
+	for i, cert := range g.certs {
+		if g.isDuplicate(i) {
+			continue
+		}
+		nodeOf[i] = len(graph.Nodes)
+		selfSigned := isSelfSigned(cert)
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			Certificate: chain.Certificates[i],
+			Index:       i,
+			IsAnchor:    selfSigned,
+			IsTrusted:   selfSigned && issuedByTrustAnchor(cert, store),
+		})
+	}
+
+	for i, issuers := range g.issuers {
+		for _, j := range issuers {
+			graph.Edges = append(graph.Edges, GraphEdge{From: nodeOf[i], To: nodeOf[j]})
+		}
+	}
+
+	// Anchors that are only in the trust store
+	for i, cert := range g.certs {
+		if g.isDuplicate(i) || isSelfSigned(cert) {
+			continue
+		}
+		for _, anchor := range storeIssuers(cert, store) {
+			if containsCertificate(g.certs, anchor) {
+				continue
+			}
+			node := graph.nodeFor(anchor)
+			if node < 0 {
+				node = len(graph.Nodes)
+				graph.Nodes = append(graph.Nodes, GraphNode{
+					Certificate: analyzeCertificate(anchor),
+					Index:       -1,
+					IsAnchor:    true,
+					IsTrusted:   true,
+				})
+			}
+			graph.Edges = append(graph.Edges, GraphEdge{From: nodeOf[i], To: node})
+		}
+	}
+
+	for _, group := range graph.crossSignGroups() {
+		// The self-signed copy, or else the first one, is the CA's own
+		// certificate; the issuers of the other copies are the cross-signers
+		primary := group[0]
+		for _, n := range group {
+			if isSelfSigned(graph.Nodes[n].Certificate.Certificate) {
+				primary = n
+				break
+			}
+		}
+		for e, edge := range graph.Edges {
+			if edge.From != primary && containsInt(group, edge.From) {
+				graph.Edges[e].CrossSign = true
+			}
+		}
+
+		for _, n := range group {
+			var others []*x509.Certificate
+			for _, m := range group {
+				if m != n {
+					others = append(others, graph.Nodes[m].Certificate.Certificate)
+				}
+			}
+			graph.Nodes[n].Certificate.CrossSigns = others
+			if index := graph.Nodes[n].Index; index >= 0 {
+				chain.Certificates[index].CrossSigns = others
+			}
+		}
+	}
+
+	return graph
+}
+
+func (graph *CertGraph) nodeFor(cert *x509.Certificate) int {
+	for n, node := range graph.Nodes {
+		if node.Certificate.Certificate.Equal(cert) {
+			return n
+		}
+	}
+	return -1
+}
+
+// crossSignGroups returns sets of nodes that share a subject and public key
+// but were issued by different CAs.
+func (graph *CertGraph) crossSignGroups() [][]int {
+	var groups [][]int
+	grouped := make(map[int]bool)
+
+	for n, node := range graph.Nodes {
+		if grouped[n] {
+			continue
+		}
+		group := []int{n}
+		issuers := map[string]bool{string(node.Certificate.Certificate.RawIssuer): true}
+		for m := n + 1; m < len(graph.Nodes); m++ {
+			other := graph.Nodes[m].Certificate.Certificate
+			if bytes.Equal(node.Certificate.Certificate.RawSubject, other.RawSubject) &&
+				bytes.Equal(node.Certificate.Certificate.RawSubjectPublicKeyInfo, other.RawSubjectPublicKeyInfo) {
+				group = append(group, m)
+				issuers[string(other.RawIssuer)] = true
+			}
+		}
+		if len(group) < 2 || len(issuers) < 2 {
+			continue
+		}
+
+		for _, m := range group {
+			grouped[m] = true
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+func (graph *CertGraph) crossSigning() []CrossSignGroup {
+	var groups []CrossSignGroup
+	for _, group := range graph.crossSignGroups() {
+		crossSign := CrossSignGroup{Subject: graph.Nodes[group[0]].Certificate.Subject}
+		for _, n := range group {
+			crossSign.Certificates = append(crossSign.Certificates, graph.Nodes[n].Certificate.Certificate)
+		}
+		groups = append(groups, crossSign)
+	}
+	return groups
+}
+
+// chainPaths enumerates every simple path from the given leaves along
+// issuer edges. A path ends at an anchor or at a certificate whose issuer
+// is unknown, in which case it is reported as incomplete. truncated is set
+// when paths beyond maxChainPaths were left out.
+func (graph *CertGraph) chainPaths(leaves []int) (paths []ChainPath, truncated bool) {
+	var visit func(path []int, crossSigns []bool)
+	visit = func(path []int, crossSigns []bool) {
+		if len(paths) >= maxChainPaths {
+			truncated = true
+			return
+		}
+		current := path[len(path)-1]
+
+		extended := false
+		if !graph.Nodes[current].IsAnchor {
+			for _, edge := range graph.Edges {
+				if edge.From != current || containsInt(path, edge.To) {
+					continue
+				}
+				extended = true
+				visit(append(append([]int(nil), path...), edge.To), append(append([]bool(nil), crossSigns...), edge.CrossSign))
+			}
+		}
+		if !extended {
+			paths = append(paths, graph.chainPath(len(paths)+1, path, crossSigns))
+		}
+	}
+
+	for _, leaf := range leaves {
+		for n, node := range graph.Nodes {
+			if node.Index == leaf {
+				visit([]int{n}, nil)
+			}
+		}
+	}
+
+	return paths, truncated
+}
+
+func (graph *CertGraph) chainPath(number int, nodes []int, crossSigns []bool) ChainPath {
+	path := ChainPath{CrossSigns: crossSigns}
+	for _, n := range nodes {
+		path.Path = append(path.Path, graph.Nodes[n].Certificate)
+	}
+	for _, crossSign := range crossSigns {
+		path.CrossSigned = path.CrossSigned || crossSign
+	}
+
+	top := graph.Nodes[nodes[len(nodes)-1]]
+	path.IsComplete = top.IsAnchor
+	path.IsTrusted = top.IsTrusted
+	if top.IsAnchor {
+		path.Anchor = top.Certificate.Subject
+		path.Description = fmt.Sprintf("Path %d via %s", number, displayName(top.Certificate.Certificate))
+	} else {
+		path.Description = fmt.Sprintf("Path %d (incomplete, ends at %s)", number, displayName(top.Certificate.Certificate))
+	}
+
+	return path
+}
+
+func storeIssuers(cert *x509.Certificate, store *TrustStore) []*x509.Certificate {
+	if store == nil {
+		return nil
+	}
+
+	// Look for issuers independently of the current time, so that expired
+	// anchors of a cross-sign still show up in the graph
+	midpoint := cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore) / 2)
+	chains, _ := cert.Verify(x509.VerifyOptions{
+		Roots:       store.Pool,
+		CurrentTime: midpoint,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+
+	var issuers []*x509.Certificate
+	for _, chain := range chains {
+		if len(chain) == 2 && !containsCertificate(issuers, chain[1]) {
+			issuers = append(issuers, chain[1])
+		}
+	}
+	return issuers
+}
+
+func displayName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+)
+
+func TestIssuerGraphMissingIssuer(t *testing.T) {
+	// The root must predate the expired leaves it issued
+	root := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Graph Test Root"},
+		NotBefore:             time.Now().Add(-72 * time.Hour),
+		NotAfter:              time.Now().Add(72 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil)
+	unknownRoot := newTestRoot(t, "Unknown Root")
+	store := newTestStore(root)
+
+	expiredLeaf := func(ca *testCA) *testCA {
+		return newTestCert(t, &x509.Certificate{
+			Subject:   pkix.Name{CommonName: "expired.example.com"},
+			DNSNames:  []string{"expired.example.com"},
+			NotBefore: time.Now().Add(-48 * time.Hour),
+			NotAfter:  time.Now().Add(-24 * time.Hour),
+		}, ca)
+	}
+	futureLeaf := newTestCert(t, &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "future.example.com"},
+		DNSNames:  []string{"future.example.com"},
+		NotBefore: time.Now().Add(time.Hour),
+		NotAfter:  time.Now().Add(48 * time.Hour),
+	}, root)
+
+	tests := []struct {
+		name        string
+		leaf        *testCA
+		store       *TrustStore
+		wantMissing bool
+	}{
+		{name: "issued by an anchor", leaf: newTestLeaf(t, "www.example.com", root), store: store},
+		{name: "expired, issued by an anchor", leaf: expiredLeaf(root), store: store},
+		{name: "not yet valid, issued by an anchor", leaf: futureLeaf, store: store},
+		{name: "unknown issuer", leaf: newTestLeaf(t, "www.example.com", unknownRoot), store: store, wantMissing: true},
+		{name: "expired, unknown issuer", leaf: expiredLeaf(unknownRoot), store: store, wantMissing: true},
+		{name: "no trust store", leaf: newTestLeaf(t, "www.example.com", root), wantMissing: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs := []*x509.Certificate{tt.leaf.cert}
+			var missing bool
+			for _, finding := range buildIssuerGraph(certs).findings(tt.store) {
+				if finding.Kind == FindingMissing && finding.Certificate == 0 {
+					missing = true
+				}
+			}
+			if missing != tt.wantMissing {
+				t.Errorf("missing issuer finding = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func newTestIntermediate(t *testing.T, name string, ca *testCA) *testCA {
+	t.Helper()
+	return newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, ca)
+}
+
+func TestBuildCertGraphCrossSign(t *testing.T) {
+	root := newTestRoot(t, "Graph Test Root")
+	oldRoot := newTestRoot(t, "Graph Test Old Root")
+	crossSign := newTestCrossSign(t, root, oldRoot, time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+	intermediate := newTestIntermediate(t, "Graph Test Intermediate", root)
+	leaf := newTestLeaf(t, "www.example.com", intermediate)
+	// An intermediate cross-signed by the old root has no self-signed copy
+	intermediateCrossSign := newTestCrossSign(t, intermediate, oldRoot, time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+
+	tests := []struct {
+		name          string
+		certs         []*x509.Certificate
+		store         *TrustStore
+		wantCrossSign *x509.Certificate // the only certificate with cross-sign edges
+	}{
+		{
+			name:          "both roots supplied",
+			certs:         []*x509.Certificate{leaf.cert, intermediate.cert, crossSign.cert, root.cert, oldRoot.cert},
+			store:         newTestStore(oldRoot),
+			wantCrossSign: crossSign.cert,
+		},
+		{
+			name:          "self-signed root from the store",
+			certs:         []*x509.Certificate{leaf.cert, intermediate.cert, crossSign.cert, oldRoot.cert},
+			store:         newTestStore(root, oldRoot),
+			wantCrossSign: crossSign.cert,
+		},
+		{
+			name:          "cross-sign listed after the root",
+			certs:         []*x509.Certificate{leaf.cert, intermediate.cert, root.cert, crossSign.cert, oldRoot.cert},
+			store:         newTestStore(root),
+			wantCrossSign: crossSign.cert,
+		},
+		{
+			name:          "cross-signed intermediate",
+			certs:         []*x509.Certificate{leaf.cert, intermediate.cert, intermediateCrossSign.cert, root.cert, oldRoot.cert},
+			store:         newTestStore(root, oldRoot),
+			wantCrossSign: intermediateCrossSign.cert,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := AnalyzeCertificateChainWithOptions(tt.certs, AnalyzeOptions{TrustStore: tt.store})
+			graph := chain.Graph
+			var crossSigns []string
+			for _, edge := range graph.Edges {
+				from, to := graph.Nodes[edge.From].Certificate.Certificate, graph.Nodes[edge.To].Certificate.Certificate
+				if edge.CrossSign {
+					crossSigns = append(crossSigns, from.Subject.CommonName+" -> "+to.Subject.CommonName)
+				}
+				if want := from.Equal(tt.wantCrossSign); edge.CrossSign != want {
+					t.Errorf("edge %s -> %s: cross-sign %v, want %v", from.Subject.CommonName, to.Subject.CommonName, edge.CrossSign, want)
+				}
+			}
+			if len(crossSigns) != 1 {
+				t.Errorf("cross-sign edges %q, want one", crossSigns)
+			}
+			if len(chain.CrossSigning) != 1 || len(chain.CrossSigning[0].Certificates) != 2 {
+				t.Errorf("cross-sign groups %+v, want one of two certificates", chain.CrossSigning)
+			}
+		})
+	}
+}
+
+func TestBuildCertGraphTrustedRoot(t *testing.T) {
+	expiredRoot := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Expired Graph Test Root"},
+		NotBefore:             time.Now().Add(-72 * time.Hour),
+		NotAfter:              time.Now().Add(-time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil)
+	root := newTestRoot(t, "Graph Test Root")
+
+	tests := []struct {
+		name        string
+		root        *testCA
+		store       *TrustStore
+		wantTrusted bool
+		wantExpired bool
+	}{
+		{name: "in the store", root: root, store: newTestStore(root), wantTrusted: true},
+		{name: "expired, in the store", root: expiredRoot, store: newTestStore(expiredRoot), wantTrusted: true, wantExpired: true},
+		{name: "not in the store", root: root, store: newTestStore(expiredRoot)},
+		{name: "expired, not in the store", root: expiredRoot, store: newTestStore(root), wantExpired: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs := []*x509.Certificate{tt.root.cert}
+			chain := &ChainInfo{Certificates: []CertificateInfo{analyzeCertificate(tt.root.cert)}}
+			graph := buildCertGraph(chain, buildIssuerGraph(certs), tt.store)
+			node := graph.Nodes[0]
+			if !node.IsAnchor || node.IsTrusted != tt.wantTrusted || node.Certificate.IsExpired != tt.wantExpired {
+				t.Errorf("anchor %v, trusted %v, expired %v; want trusted %v, expired %v",
+					node.IsAnchor, node.IsTrusted, node.Certificate.IsExpired, tt.wantTrusted, tt.wantExpired)
+			}
+		})
+	}
+}
+
+func TestChainPathsTruncated(t *testing.T) {
+	root := newTestRoot(t, "Graph Test Root")
+	// Every copy of the intermediate can follow every copy of the CA above
+	// it, so copies multiply the paths
+	copies := func(ca *testCA, parent *testCA, n int) []*x509.Certificate {
+		certs := []*x509.Certificate{ca.cert}
+		for len(certs) < n {
+			certs = append(certs, newTestCrossSign(t, ca, parent, ca.cert.NotBefore, ca.cert.NotAfter).cert)
+		}
+		return certs
+	}
+	upper := newTestIntermediate(t, "Graph Test Upper CA", root)
+	lower := newTestIntermediate(t, "Graph Test Lower CA", upper)
+	leaf := newTestLeaf(t, "www.example.com", lower)
+
+	tests := []struct {
+		name          string
+		upperCopies   int
+		lowerCopies   int
+		wantPaths     int
+		wantTruncated bool
+	}{
+		{name: "one path", upperCopies: 1, lowerCopies: 1, wantPaths: 1},
+		{name: "at the limit", upperCopies: 8, lowerCopies: 8, wantPaths: 64},
+		{name: "over the limit", upperCopies: 8, lowerCopies: 9, wantPaths: 64, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs := []*x509.Certificate{leaf.cert}
+			certs = append(certs, copies(lower, upper, tt.lowerCopies)...)
+			certs = append(certs, copies(upper, root, tt.upperCopies)...)
+			certs = append(certs, root.cert)
+
+			chain := AnalyzeCertificateChainWithOptions(certs, AnalyzeOptions{TrustStore: newTestStore(root)})
+			if len(chain.ChainPaths) != tt.wantPaths {
+				t.Errorf("%d paths, want %d", len(chain.ChainPaths), tt.wantPaths)
+			}
+			var truncated bool
+			for _, finding := range chain.Findings {
+				if finding.Kind == FindingTruncated {
+					truncated = true
+				}
+			}
+			if truncated != tt.wantTruncated {
+				t.Errorf("truncated finding = %v, want %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package cert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+)
+
+// HandshakeInfo captures the negotiated parameters of the TLS connection a
+// chain was fetched over.
+type HandshakeInfo struct {
+	Version     string
+	CipherSuite string
+	Group       string // key exchange group, empty for RSA key exchange
+	PostQuantum bool   // Group is a (hybrid) post-quantum KEM
+	ALPN        string
+	OCSPStaple  []byte // raw OCSP response stapled by the server
+	SCTs        []SCTInfo
+	// Resumption is true when a second handshake resumed the session
+	// established by the first one. ResumptionProbed says whether that
+	// second handshake was attempted (FetchOptions.ProbeResumption).
+	Resumption       bool
+	ResumptionProbed bool
+
+	rawSCTs [][]byte
+}
+
+// Key exchange groups from the IANA TLS Supported Groups registry.
+var tlsGroupNames = map[tls.CurveID]string{
+	0x0017: "secp256r1",
+	0x0018: "secp384r1",
+	0x0019: "secp521r1",
+	0x001d: "x25519",
+	0x001e: "x448",
+	0x0100: "ffdhe2048",
+	0x0101: "ffdhe3072",
+	0x0102: "ffdhe4096",
+	0x0200: "MLKEM512",
+	0x0201: "MLKEM768",
+	0x0202: "MLKEM1024",
+	0x11eb: "SecP256r1MLKEM768",
+	0x11ec: "X25519MLKEM768",
+	0x11ed: "SecP384r1MLKEM1024",
+	0x6399: "X25519Kyber768Draft00",
+}
+
+var postQuantumGroups = map[tls.CurveID]bool{
+	0x0200: true,
+	0x0201: true,
+	0x0202: true,
+	0x11eb: true,
+	0x11ec: true,
+	0x11ed: true,
+	0x6399: true,
+}
+
+func newHandshakeInfo(state tls.ConnectionState) *HandshakeInfo {
+	info := &HandshakeInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ALPN:        state.NegotiatedProtocol,
+		OCSPStaple:  state.OCSPResponse,
+		rawSCTs:     state.SignedCertificateTimestamps,
+	}
+	if state.CurveID != 0 {
+		info.Group = groupName(state.CurveID)
+		info.PostQuantum = postQuantumGroups[state.CurveID]
+	}
+	return info
+}
+
+func groupName(id tls.CurveID) string {
+	if name, ok := tlsGroupNames[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", uint16(id))
+}
+
+// analyzeTLSSCTs checks the SCTs delivered in the TLS extension, which are
+// signed over the leaf certificate itself rather than a precertificate.
+func analyzeTLSSCTs(raw [][]byte, leaf *x509.Certificate, logs *CTLogList) []SCTInfo {
+	var infos []SCTInfo
+	for _, data := range raw {
+		sct, err := parseSCT(data)
+		if err != nil {
+			continue
+		}
+		info := SCTInfo{SCT: sct, Log: logs.Lookup(sct.LogID)}
+		if info.Log == nil {
+			info.SignatureError = logs.unknownLogError()
+		} else if err := verifySCT(sct, info.Log, x509Entry(leaf.Raw)); err != nil {
+			info.SignatureError = err.Error()
+		} else {
+			info.SignatureValid = true
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
@@ -0,0 +1,149 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA is a certificate and the key that signs with it.
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+var testSerial int64 = 1000
+
+// newTestCert issues a certificate from template, self-signed when parent
+// is nil. A missing serial number or validity period is filled in.
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCA) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if template.SerialNumber == nil {
+		testSerial++
+		template.SerialNumber = big.NewInt(testSerial)
+	}
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+		template.NotAfter = time.Now().Add(24 * time.Hour)
+	}
+
+	issuer, signer := template, crypto.Signer(key)
+	if parent != nil {
+		issuer, signer = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func newTestRoot(t *testing.T, name string) *testCA {
+	t.Helper()
+	return newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil)
+}
+
+func newTestLeaf(t *testing.T, name string, ca *testCA) *testCA {
+	t.Helper()
+	return newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: name},
+		DNSNames:    []string{name},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+}
+
+// newTestCrossSign reissues ca's certificate, with the same subject and
+// key, from another parent.
+func newTestCrossSign(t *testing.T, ca, parent *testCA, notBefore, notAfter time.Time) *testCA {
+	t.Helper()
+	testSerial++
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(testSerial),
+		Subject:               ca.cert.Subject,
+		SubjectKeyId:          ca.cert.SubjectKeyId,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent.cert, ca.key.Public(), parent.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: ca.key}
+}
+
+func newTestStore(roots ...*testCA) *TrustStore {
+	store := &TrustStore{Name: "test", Pool: x509.NewCertPool()}
+	for _, root := range roots {
+		store.Pool.AddCert(root.cert)
+		store.Certificates = append(store.Certificates, root.cert)
+	}
+	return store
+}
+
+// der encodes one ASN.1 element with the concatenated content.
+func der(class, tag int, compound bool, content ...[]byte) []byte {
+	var value []byte
+	for _, c := range content {
+		value = append(value, c...)
+	}
+	encoded, err := asn1.Marshal(asn1.RawValue{Class: class, Tag: tag, IsCompound: compound, Bytes: value})
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+func derSequence(content ...[]byte) []byte {
+	return der(asn1.ClassUniversal, asn1.TagSequence, true, content...)
+}
+
+// derContext encodes an implicitly tagged context-specific element.
+func derContext(tag int, compound bool, content ...[]byte) []byte {
+	return der(asn1.ClassContextSpecific, tag, compound, content...)
+}
+
+func derOID(t *testing.T, oid string) []byte {
+	t.Helper()
+	var parsed asn1.ObjectIdentifier
+	for _, part := range strings.Split(oid, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			t.Fatalf("invalid OID %q", oid)
+		}
+		parsed = append(parsed, n)
+	}
+	encoded, err := asn1.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
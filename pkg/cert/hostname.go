@@ -0,0 +1,140 @@
+package cert
+
+import (
+	"crypto/x509"
+	"net"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// HostnameCheck is the result of matching a host name or IP address
+// against the leaf certificate (RFC 6125, RFC 9525).
+type HostnameCheck struct {
+	Host        string // as requested
+	Normalized  string // lower-case A-label form, or the canonical IP
+	Certificate int    // index of the certificate that was checked
+	Matched     bool
+	MatchedName string // SAN entry or Common Name that matched
+	MatchType   string // "DNS SAN", "Wildcard DNS SAN", "IP SAN" or "Common Name"
+	// Deprecated is set when the match relied on the Subject Common Name,
+	// which browsers and Go's crypto/x509 no longer accept.
+	Deprecated bool
+	Message    string
+}
+
+// Match types reported in HostnameCheck.MatchType.
+const (
+	MatchDNS        = "DNS SAN"
+	MatchWildcard   = "Wildcard DNS SAN"
+	MatchIP         = "IP SAN"
+	MatchCommonName = "Common Name"
+)
+
+// VerifyHostname checks whether cert is valid for host, which may be a DNS
+// name (Unicode or punycode), an IPv4 address or a bracketed IPv6 address.
+func VerifyHostname(cert *x509.Certificate, host string) *HostnameCheck {
+	check := &HostnameCheck{Host: host}
+
+	candidate := strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if ip := net.ParseIP(candidate); ip != nil {
+		check.Normalized = ip.String()
+		for _, san := range cert.IPAddresses {
+			if san.Equal(ip) {
+				check.Matched, check.MatchedName, check.MatchType = true, san.String(), MatchIP
+				check.Message = "IP address is listed in the Subject Alternative Names"
+				return check
+			}
+		}
+		check.Message = "IP address is not listed in the IP Subject Alternative Names"
+		if len(cert.IPAddresses) == 0 {
+			check.Message = "Certificate has no IP Subject Alternative Names"
+		}
+		return check
+	}
+
+	name, err := hostProfile.ToASCII(candidate)
+	if err != nil {
+		check.Message = "Invalid host name: " + err.Error()
+		return check
+	}
+	check.Normalized = name
+
+	for _, san := range cert.DNSNames {
+		pattern := normalizeDNSName(san)
+		if pattern == name {
+			check.Matched, check.MatchedName, check.MatchType = true, san, MatchDNS
+			check.Message = "Host name is listed in the Subject Alternative Names"
+			return check
+		}
+		if matchWildcard(pattern, name) {
+			check.Matched, check.MatchedName, check.MatchType = true, san, MatchWildcard
+			check.Message = "Host name is covered by a wildcard Subject Alternative Name"
+			return check
+		}
+	}
+
+	// The Common Name is only consulted when no SANs of any identifier
+	// type are present (RFC 6125, section 6.4.4).
+	if !hasSubjectAltNames(cert) && cert.Subject.CommonName != "" {
+		cn := normalizeDNSName(cert.Subject.CommonName)
+		if cn == name || matchWildcard(cn, name) {
+			check.Matched, check.MatchedName, check.MatchType = true, cert.Subject.CommonName, MatchCommonName
+			check.Deprecated = true
+			check.Message = "Host name matches only the Subject Common Name, which browsers and Go no longer accept"
+			return check
+		}
+	}
+
+	check.Message = "Host name is not covered by the certificate"
+	if len(cert.DNSNames) == 0 {
+		check.Message = "Certificate has no DNS Subject Alternative Names"
+	}
+	return check
+}
+
+// hasSubjectAltNames reports whether the certificate has a Subject
+// Alternative Name extension, including one holding only name types
+// crypto/x509 does not parse.
+func hasSubjectAltNames(cert *x509.Certificate) bool {
+	if len(cert.DNSNames) > 0 || len(cert.IPAddresses) > 0 || len(cert.URIs) > 0 || len(cert.EmailAddresses) > 0 {
+		return true
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.String() == "2.5.29.17" {
+			return true
+		}
+	}
+	return false
+}
+
+// hostProfile is idna.Lookup plus label length checks, so that malformed
+// names such as "a..b" are rejected rather than compared. Underscores are
+// allowed, as internal host names often contain them.
+var hostProfile = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.VerifyDNSLength(true), idna.StrictDomainName(false))
+
+// normalizeDNSName lower-cases a presented identifier and converts any
+// U-labels to punycode so it compares against the normalized host.
+func normalizeDNSName(name string) string {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	if ascii, err := idna.ToASCII(name); err == nil {
+		return ascii
+	}
+	return name
+}
+
+// matchWildcard applies the RFC 6125 wildcard rules: only a complete
+// left-most "*" label is honored, it matches exactly one non-empty label,
+// and it may not sit directly on a public suffix such as "*.co.uk".
+func matchWildcard(pattern, name string) bool {
+	base, ok := strings.CutPrefix(pattern, "*.")
+	if !ok || base == "" || strings.Contains(base, "*") {
+		return false
+	}
+	if suffix, _ := publicsuffix.PublicSuffix(base); suffix == base {
+		return false
+	}
+	label, rest, ok := strings.Cut(name, ".")
+	return ok && label != "" && rest == base
+}
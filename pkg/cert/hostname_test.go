@@ -0,0 +1,96 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestVerifyHostname(t *testing.T) {
+	sans := func(names ...string) *x509.Certificate {
+		return &x509.Certificate{Subject: pkix.Name{CommonName: "ignored.example.com"}, DNSNames: names}
+	}
+	ips := &x509.Certificate{
+		DNSNames:    []string{"www.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("192.0.2.10"), net.ParseIP("2001:db8::1")},
+	}
+	commonName := &x509.Certificate{Subject: pkix.Name{CommonName: "Legacy.Example.com"}}
+	uriOnly := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "legacy.example.com"},
+		URIs:    []*url.URL{{Scheme: "spiffe", Host: "example.com", Path: "/service"}},
+	}
+	// A SAN extension with only a registeredID, which crypto/x509 drops
+	otherNameOnly := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "legacy.example.com"},
+		Extensions: []pkix.Extension{{
+			Id:    asn1.ObjectIdentifier{2, 5, 29, 17},
+			Value: derSequence(derContext(8, false, derOID(t, "1.2.3.4")[2:])),
+		}},
+	}
+
+	tests := []struct {
+		name        string
+		cert        *x509.Certificate
+		host        string
+		want        bool
+		wantType    string
+		wantNorm    string
+		wantMessage string
+	}{
+		{name: "exact", cert: sans("www.example.com"), host: "www.example.com", want: true, wantType: MatchDNS},
+		{name: "case and trailing dot", cert: sans("WWW.Example.COM."), host: "www.example.com.", want: true, wantType: MatchDNS, wantNorm: "www.example.com"},
+		{name: "other name", cert: sans("www.example.com"), host: "api.example.com", wantMessage: "not covered"},
+		{name: "wildcard", cert: sans("*.example.com"), host: "api.example.com", want: true, wantType: MatchWildcard},
+		{name: "wildcard spans one label", cert: sans("*.example.com"), host: "a.b.example.com"},
+		{name: "wildcard does not cover the base", cert: sans("*.example.com"), host: "example.com"},
+		{name: "partial wildcard", cert: sans("w*.example.com"), host: "www.example.com"},
+		{name: "wildcard not left-most", cert: sans("www.*.example.com"), host: "www.api.example.com"},
+		{name: "double wildcard", cert: sans("*.*.example.com"), host: "a.b.example.com"},
+		{name: "wildcard on a TLD", cert: sans("*.com"), host: "example.com"},
+		{name: "wildcard on a public suffix", cert: sans("*.co.uk"), host: "example.co.uk"},
+		{name: "wildcard on a private public suffix", cert: sans("*.github.io"), host: "example.github.io"},
+		{name: "wildcard below a public suffix", cert: sans("*.example.co.uk"), host: "www.example.co.uk", want: true, wantType: MatchWildcard},
+		{name: "U-label host, A-label SAN", cert: sans("xn--bcher-kva.example"), host: "bücher.example", want: true, wantType: MatchDNS, wantNorm: "xn--bcher-kva.example"},
+		{name: "upper-case U-label host", cert: sans("xn--bcher-kva.example"), host: "BÜCHER.example", want: true, wantType: MatchDNS, wantNorm: "xn--bcher-kva.example"},
+		{name: "A-label host, U-label SAN", cert: sans("bücher.example"), host: "xn--bcher-kva.example", want: true, wantType: MatchDNS},
+		{name: "U-label host, A-label wildcard", cert: sans("*.xn--bcher-kva.example"), host: "www.bücher.example", want: true, wantType: MatchWildcard},
+		{name: "wildcard on an IDN public suffix", cert: sans("*.xn--p1ai"), host: "пример.рф"},
+		{name: "look-alike", cert: sans("xn--bcher-kva.example"), host: "bucher.example"},
+		{name: "malformed host", cert: sans("a.example"), host: "a..example", wantMessage: "Invalid host name"},
+		{name: "other host on an IP certificate", cert: ips, host: "api.example.com", wantMessage: "not covered"},
+		{name: "IPv4", cert: ips, host: "192.0.2.10", want: true, wantType: MatchIP},
+		{name: "bracketed IPv6", cert: ips, host: "[2001:DB8::1]", want: true, wantType: MatchIP, wantNorm: "2001:db8::1"},
+		{name: "IP not listed", cert: ips, host: "192.0.2.11", wantMessage: "not listed in the IP Subject Alternative Names"},
+		{name: "IP against DNS SANs only", cert: sans("192.0.2.10"), host: "192.0.2.10", wantMessage: "no IP Subject Alternative Names"},
+		{name: "Common Name fallback", cert: commonName, host: "legacy.example.com", want: true, wantType: MatchCommonName},
+		{name: "Common Name ignored with SANs", cert: sans("www.example.com"), host: "ignored.example.com"},
+		{name: "Common Name ignored with URI SANs", cert: uriOnly, host: "legacy.example.com", wantMessage: "no DNS Subject Alternative Names"},
+		{name: "Common Name ignored with unparsed SANs", cert: otherNameOnly, host: "legacy.example.com", wantMessage: "no DNS Subject Alternative Names"},
+		{name: "Common Name mismatch", cert: commonName, host: "www.example.com", wantMessage: "no DNS Subject Alternative Names"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := VerifyHostname(tt.cert, tt.host)
+			if check.Matched != tt.want {
+				t.Fatalf("matched = %v, want %v (%s)", check.Matched, tt.want, check.Message)
+			}
+			if check.MatchType != tt.wantType {
+				t.Errorf("match type = %q, want %q", check.MatchType, tt.wantType)
+			}
+			if check.Deprecated != (tt.wantType == MatchCommonName) {
+				t.Errorf("deprecated = %v", check.Deprecated)
+			}
+			if tt.wantNorm != "" && check.Normalized != tt.wantNorm {
+				t.Errorf("normalized = %q, want %q", check.Normalized, tt.wantNorm)
+			}
+			if !strings.Contains(check.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want it to contain %q", check.Message, tt.wantMessage)
+			}
+		})
+	}
+}
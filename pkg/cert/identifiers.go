@@ -0,0 +1,123 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Identifiers are the values certificates are usually looked up by in
+// inventories, trust store directories and crt.sh. Hashes and key IDs are
+// colon-separated upper-case hex.
+type Identifiers struct {
+	SHA1       string // fingerprint of the DER certificate
+	SHA256     string
+	SPKISHA1   string // hash of the DER SubjectPublicKeyInfo
+	SPKISHA256 string
+	// SubjectHash and IssuerHash match `openssl x509 -subject_hash` and
+	// `-issuer_hash`, the names used for c_rehash symlinks.
+	SubjectHash    string
+	IssuerHash     string
+	SubjectKeyID   string
+	AuthorityKeyID string
+}
+
+func analyzeIdentifiers(cert *x509.Certificate) Identifiers {
+	sha1Sum := sha1.Sum(cert.Raw)
+	sha256Sum := sha256.Sum256(cert.Raw)
+	spkiSHA1 := sha1.Sum(cert.RawSubjectPublicKeyInfo)
+	spkiSHA256 := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return Identifiers{
+		SHA1:           hexColon(sha1Sum[:]),
+		SHA256:         hexColon(sha256Sum[:]),
+		SPKISHA1:       hexColon(spkiSHA1[:]),
+		SPKISHA256:     hexColon(spkiSHA256[:]),
+		SubjectHash:    opensslNameHash(cert.RawSubject),
+		IssuerHash:     opensslNameHash(cert.RawIssuer),
+		SubjectKeyID:   hexColon(cert.SubjectKeyId),
+		AuthorityKeyID: hexColon(cert.AuthorityKeyId),
+	}
+}
+
+type canonAVA struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// The "SET" suffix makes encoding/asn1 parse a SET OF.
+type canonRDNSET []canonAVA
+
+// opensslNameHash computes X509_NAME_hash: the first four bytes of the
+// SHA-1 of the canonical name encoding, read as a little-endian integer.
+func opensslNameHash(rawName []byte) string {
+	var rdns []canonRDNSET
+	if rest, err := asn1.Unmarshal(rawName, &rdns); err != nil || len(rest) > 0 {
+		return ""
+	}
+
+	// The canonical encoding is the concatenation of the RDN SETs without
+	// the enclosing SEQUENCE.
+	var canon []byte
+	for _, rdn := range rdns {
+		var avas [][]byte
+		for _, ava := range rdn {
+			der, err := asn1.Marshal(canonAVA{Type: ava.Type, Value: canonicalValue(ava.Value)})
+			if err != nil {
+				return ""
+			}
+			avas = append(avas, der)
+		}
+		// DER orders the members of a SET OF by their encoding
+		sort.Slice(avas, func(i, j int) bool { return bytes.Compare(avas[i], avas[j]) < 0 })
+		set, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: bytes.Join(avas, nil)})
+		if err != nil {
+			return ""
+		}
+		canon = append(canon, set...)
+	}
+
+	sum := sha1.Sum(canon)
+	return fmt.Sprintf("%08x", binary.LittleEndian.Uint32(sum[:4]))
+}
+
+// canonicalValue applies OpenSSL's name canonicalization to string values:
+// convert to UTF8String, trim and collapse ASCII whitespace and lower-case
+// ASCII letters. Other value types are kept as they are.
+func canonicalValue(value asn1.RawValue) asn1.RawValue {
+	switch value.Tag {
+	case asn1.TagUTF8String, asn1.TagPrintableString, asn1.TagT61String, asn1.TagIA5String,
+		26 /* VisibleString */, 28 /* UniversalString */, asn1.TagBMPString:
+	default:
+		return value
+	}
+	s, ok := asn1String(value)
+	if !ok {
+		return value
+	}
+
+	var b strings.Builder
+	space := false
+	for _, r := range strings.Trim(s, " \t\n\v\f\r") {
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\v' || r == '\f' || r == '\r':
+			space = true
+			continue
+		case space:
+			b.WriteByte(' ')
+			space = false
+		}
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagUTF8String, Bytes: []byte(b.String())}
+}
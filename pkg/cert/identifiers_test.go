@@ -0,0 +1,102 @@
+package cert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestOpenSSLNameHash(t *testing.T) {
+	// Expected values from `openssl x509 -noout -subject_hash` (OpenSSL 3.0)
+	tests := []struct {
+		name string
+		der  string
+		want string
+	}{
+		{
+			name: "UTF8String attributes",
+			der:  "303d3118301606035504030c0f4578616d706c6520526f6f7420434131143012060355040a0c0b4578616d706c6520496e63310b3009060355040613025553",
+			want: "7d29b355",
+		},
+		{
+			name: "PrintableString attributes",
+			der:  "303d310b300906035504061302555331143012060355040a130b4578616d706c6520496e63311830160603550403130f4578616d706c6520526f6f74204341",
+			want: "cecdab90",
+		},
+		{
+			name: "extra white space and upper case",
+			der:  "3036311e301c06035504030c1520204578616d706c65202020526f6f74202043412031143012060355040a0c0b4558414d504c4520696e63",
+			want: "633541f6",
+		},
+		{
+			name: "canonical form of the previous name",
+			der:  "30303118301606035504030c0f6578616d706c6520726f6f7420636131143012060355040a0c0b4578616d706c6520496e63",
+			want: "633541f6",
+		},
+		{
+			name: "multi-valued RDN",
+			der:  "30383129300a060355040a0c034f7267300c06035504030c054d756c7469300d060355040b0c06556e69742042310b3009060355040613024445",
+			want: "77e917f3",
+		},
+		{
+			name: "non-ASCII UTF-8 keeps its case",
+			der:  "302c3118301606035504030c0f42c3bc63686572205ac3bc726963683110300e060355040a0c0753747261c39f65",
+			want: "05573d7b",
+		},
+		{
+			name: "IA5String email address",
+			der:  "30313120301e06092a864886f70d010901161141646d696e404578616d706c652e434f4d310d300b06035504030c046d61696c",
+			want: "2c824c3f",
+		},
+		{
+			name: "BMPString and T61String",
+			der:  "302d3119301706035504031e100042004d00500020004e00e4006d00653110300e060355040a1407543631204f7267",
+			want: "5f92094c",
+		},
+		{
+			name: "tabs inside an IA5String",
+			der:  "3017311530130603550403160c2020494135094e616d652020",
+			want: "044d0352",
+		},
+		{
+			name: "non-string attribute",
+			der:  "3019310b3009060355042d030200ab310a300806035504030c0158",
+			want: "ed552536",
+		},
+		{name: "empty name", der: "3000", want: "eea339da"},
+		{name: "malformed", der: "3005310330", want: ""},
+		{name: "trailing data", der: "300000", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			der, err := hex.DecodeString(tt.der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := opensslNameHash(der); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeIdentifiers(t *testing.T) {
+	root := newTestRoot(t, "Identifier Test Root")
+	leaf := newTestLeaf(t, "id.example.com", root)
+
+	ids := analyzeIdentifiers(leaf.cert)
+	sum := sha256.Sum256(leaf.cert.Raw)
+	if ids.SHA256 != hexColon(sum[:]) {
+		t.Errorf("SHA-256 = %s, want %s", ids.SHA256, hexColon(sum[:]))
+	}
+	if ids.IssuerHash != analyzeIdentifiers(root.cert).SubjectHash {
+		t.Errorf("issuer hash %s differs from the root's subject hash", ids.IssuerHash)
+	}
+	if ids.AuthorityKeyID == "" || ids.AuthorityKeyID != hexColon(root.cert.SubjectKeyId) {
+		t.Errorf("authority key ID = %q, want the root's key ID %q", ids.AuthorityKeyID, hexColon(root.cert.SubjectKeyId))
+	}
+	if len(ids.SHA1) != 59 || len(ids.SPKISHA256) != 95 {
+		t.Errorf("unexpected hash lengths: %q, %q", ids.SHA1, ids.SPKISHA256)
+	}
+}
@@ -0,0 +1,208 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"math/big"
+)
+
+// KeyInfo describes a private key supplied alongside the certificates.
+// The key material itself is never kept.
+type KeyInfo struct {
+	PublicKey        PublicKeyDetails // of the key's public half, as for certificates
+	MatchesLeaf      bool
+	CertificateIndex int // index of the certificate holding the public key, -1 if none
+}
+
+func analyzePrivateKey(key crypto.PrivateKey, certs []*x509.Certificate, leaves []int) *KeyInfo {
+	info := &KeyInfo{PublicKey: PublicKeyDetails{Algorithm: "Unknown"}, CertificateIndex: -1}
+	var pub crypto.PublicKey
+	switch k := key.(type) {
+	case crypto.Signer:
+		pub = k.Public()
+	case *dsa.PrivateKey:
+		pub = &k.PublicKey
+	default:
+		return info
+	}
+
+	for i, cert := range certs {
+		if samePublicKey(cert.PublicKey, pub) {
+			info.CertificateIndex = i
+			break
+		}
+	}
+	for _, leaf := range leaves {
+		if info.CertificateIndex == leaf {
+			info.MatchesLeaf = true
+		}
+	}
+
+	if info.CertificateIndex >= 0 {
+		info.PublicKey = analyzePublicKey(certs[info.CertificateIndex])
+	} else {
+		// Without a certificate to take it from, encode the SPKI ourselves
+		raw, _ := marshalPublicKey(pub)
+		info.PublicKey = analyzeSPKI(raw, publicKeyAlgorithmOf(pub), pub)
+	}
+	return info
+}
+
+// samePublicKey compares keys with their Equal method, which DSA keys
+// lack.
+func samePublicKey(a, b crypto.PublicKey) bool {
+	if k, ok := a.(interface{ Equal(crypto.PublicKey) bool }); ok {
+		return k.Equal(b)
+	}
+	x, ok := a.(*dsa.PublicKey)
+	y, ok2 := b.(*dsa.PublicKey)
+	return ok && ok2 && x.Y.Cmp(y.Y) == 0 && x.P.Cmp(y.P) == 0 && x.Q.Cmp(y.Q) == 0 && x.G.Cmp(y.G) == 0
+}
+
+// marshalPublicKey encodes a SubjectPublicKeyInfo, including for DSA keys,
+// which x509.MarshalPKIXPublicKey refuses (RFC 3279, section 2.3.2).
+func marshalPublicKey(pub crypto.PublicKey) ([]byte, error) {
+	k, ok := pub.(*dsa.PublicKey)
+	if !ok {
+		return x509.MarshalPKIXPublicKey(pub)
+	}
+	params, err := asn1.Marshal(struct{ P, Q, G *big.Int }{k.P, k.Q, k.G})
+	if err != nil {
+		return nil, err
+	}
+	y, err := asn1.Marshal(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 10040, 4, 1},
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		PublicKey: asn1.BitString{Bytes: y, BitLength: 8 * len(y)},
+	})
+}
+
+func publicKeyAlgorithmOf(pub crypto.PublicKey) x509.PublicKeyAlgorithm {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return x509.RSA
+	case *dsa.PublicKey:
+		return x509.DSA
+	case *ecdsa.PublicKey:
+		return x509.ECDSA
+	case ed25519.PublicKey:
+		return x509.Ed25519
+	default:
+		return x509.UnknownPublicKeyAlgorithm
+	}
+}
+
+// PublicKeyDetails describes a certificate's subject public key.
+type PublicKeyDetails struct {
+	Algorithm string // "RSA", "RSA-PSS", "ECDSA", "Ed25519", "Ed448", ...
+	Size      int    // RSA modulus bits, EC field size, or key length
+	Curve     string // named curve for EC and EdDSA keys
+	Exponent  int    // RSA public exponent
+	// SPKISHA256 is the base64 SHA-256 of the SubjectPublicKeyInfo, the
+	// value used for HPKP-style pins.
+	SPKISHA256 string
+}
+
+// SEC 2 names of the NIST curves crypto/x509 supports.
+var curveAliases = map[string]string{
+	"P-224": "secp224r1",
+	"P-256": "secp256r1",
+	"P-384": "secp384r1",
+	"P-521": "secp521r1",
+}
+
+type publicKeyAlgorithm struct {
+	name  string
+	curve string
+}
+
+// Key algorithms by SubjectPublicKeyInfo OID, for keys crypto/x509 does not
+// parse and to name the EdDSA curves.
+var publicKeyAlgorithms = map[string]publicKeyAlgorithm{
+	"1.2.840.113549.1.1.10":   {"RSA-PSS", ""},
+	"1.3.101.110":             {"X25519", "Curve25519"},
+	"1.3.101.111":             {"X448", "Curve448"},
+	"1.3.101.112":             {"Ed25519", "edwards25519"},
+	"1.3.101.113":             {"Ed448", "edwards448"},
+	"2.16.840.1.101.3.4.3.17": {"ML-DSA-44", ""},
+	"2.16.840.1.101.3.4.3.18": {"ML-DSA-65", ""},
+	"2.16.840.1.101.3.4.3.19": {"ML-DSA-87", ""},
+}
+
+func analyzePublicKey(cert *x509.Certificate) PublicKeyDetails {
+	return analyzeSPKI(cert.RawSubjectPublicKeyInfo, cert.PublicKeyAlgorithm, cert.PublicKey)
+}
+
+// analyzeSPKI describes a SubjectPublicKeyInfo and the key crypto/x509
+// parsed from it, which is nil for unsupported algorithms. raw is empty
+// for keys crypto/x509 cannot encode, such as DSA private keys.
+func analyzeSPKI(raw []byte, algorithm x509.PublicKeyAlgorithm, publicKey crypto.PublicKey) PublicKeyDetails {
+	details := PublicKeyDetails{Algorithm: algorithm.String()}
+	if algorithm == x509.UnknownPublicKeyAlgorithm {
+		details.Algorithm = "Unknown"
+	}
+	if len(raw) > 0 {
+		sum := sha256.Sum256(raw)
+		details.SPKISHA256 = base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	var oid string
+	if _, err := asn1.Unmarshal(raw, &spki); err == nil {
+		oid = spki.Algorithm.Algorithm.String()
+		if algorithm == x509.UnknownPublicKeyAlgorithm {
+			details.Algorithm = "Unknown (" + oid + ")"
+		}
+		if alg, ok := publicKeyAlgorithms[oid]; ok {
+			details.Algorithm, details.Curve = alg.name, alg.curve
+		}
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		details.Size, details.Exponent = key.N.BitLen(), key.E
+	case *dsa.PublicKey:
+		details.Size = key.P.BitLen()
+	case *ecdsa.PublicKey:
+		params := key.Curve.Params()
+		details.Size, details.Curve = params.BitSize, params.Name
+		if alias, ok := curveAliases[params.Name]; ok {
+			details.Curve += " (" + alias + ")"
+		}
+	case ed25519.PublicKey:
+		details.Size = len(key) * 8
+	case nil:
+		details.Size = spki.PublicKey.BitLength
+		if oid == "1.2.840.113549.1.1.10" {
+			var key struct {
+				N *big.Int
+				E int
+			}
+			if _, err := asn1.Unmarshal(spki.PublicKey.RightAlign(), &key); err == nil {
+				details.Size, details.Exponent = key.N.BitLen(), key.E
+			}
+		}
+	}
+
+	return details
+}
@@ -0,0 +1,399 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Lint severities, from most to least serious.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityNotice  = "notice"
+)
+
+// Certificate roles a lint rule can apply to.
+const (
+	RoleLeaf         = "leaf"
+	RoleIntermediate = "intermediate"
+	RoleRoot         = "root"
+)
+
+// LintFinding is one rule violation found in one certificate.
+type LintFinding struct {
+	ID          string
+	Severity    string
+	Citation    string
+	Certificate int // index in the input
+	Message     string
+}
+
+// LintCertificate is what a rule sees: the analyzed certificate and its
+// role in the chain.
+type LintCertificate struct {
+	Index int
+	Info  *CertificateInfo
+	Role  string
+}
+
+// LintRule checks one requirement. Roles limits the certificates it runs
+// against (nil means all of them); Check returns a message describing the
+// violation, or "" when the certificate passes.
+type LintRule struct {
+	ID          string
+	Severity    string
+	Citation    string
+	Description string
+	Roles       []string
+	Applies     func(c LintCertificate) bool // optional extra filter
+	Check       func(c LintCertificate) string
+}
+
+var lintRules []LintRule
+
+// RegisterLintRule adds a rule to the set run by every analysis.
+func RegisterLintRule(rule LintRule) {
+	lintRules = append(lintRules, rule)
+}
+
+// LintRules returns the registered rules in the order they run.
+func LintRules() []LintRule {
+	return append([]LintRule(nil), lintRules...)
+}
+
+func lintChain(certs []CertificateInfo) []LintFinding {
+	var findings []LintFinding
+	for i := range certs {
+		c := LintCertificate{Index: i, Info: &certs[i], Role: certificateRole(certs[i].Certificate)}
+		for _, rule := range lintRules {
+			if !rule.appliesTo(c) {
+				continue
+			}
+			if message := rule.Check(c); message != "" {
+				findings = append(findings, LintFinding{
+					ID:          rule.ID,
+					Severity:    rule.Severity,
+					Citation:    rule.Citation,
+					Certificate: i,
+					Message:     message,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func (rule LintRule) appliesTo(c LintCertificate) bool {
+	if rule.Roles != nil {
+		found := false
+		for _, role := range rule.Roles {
+			if role == c.Role {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return rule.Applies == nil || rule.Applies(c)
+}
+
+func certificateRole(cert *x509.Certificate) string {
+	switch {
+	// v1 roots predate basicConstraints
+	case isSelfSigned(cert) && (cert.IsCA || cert.Version < 3):
+		return RoleRoot
+	case cert.IsCA:
+		return RoleIntermediate
+	default:
+		return RoleLeaf
+	}
+}
+
+// isServerCertificate limits the Baseline Requirements subscriber rules to
+// TLS server certificates; S/MIME and code signing leaves follow other
+// requirements.
+func isServerCertificate(c LintCertificate) bool {
+	cert := c.Info.Certificate
+	if len(cert.ExtKeyUsage) == 0 && len(cert.UnknownExtKeyUsage) == 0 {
+		return true
+	}
+	for _, usage := range cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageServerAuth || usage == x509.ExtKeyUsageAny {
+			return true
+		}
+	}
+	return false
+}
+
+// Dates the Baseline Requirements validity limits took effect.
+var (
+	validity398Date = time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC)
+	validity825Date = time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func init() {
+	RegisterLintRule(LintRule{
+		ID:          "leaf_validity_period",
+		Severity:    SeverityError,
+		Citation:    "CA/B Forum BR §6.3.2",
+		Description: "Subscriber certificates issued on or after 2020-09-01 must not be valid for more than 398 days",
+		Roles:       []string{RoleLeaf},
+		Applies:     isServerCertificate,
+		Check: func(c LintCertificate) string {
+			cert := c.Info.Certificate
+			limit := 0
+			switch {
+			case !cert.NotBefore.Before(validity398Date):
+				limit = 398
+			case !cert.NotBefore.Before(validity825Date):
+				limit = 825
+			default:
+				return ""
+			}
+			// The validity period includes both NotBefore and NotAfter
+			validity := cert.NotAfter.Sub(cert.NotBefore) + time.Second
+			if validity > time.Duration(limit)*24*time.Hour {
+				return fmt.Sprintf("Validity period of %.0f days exceeds the %d day maximum", validity.Hours()/24, limit)
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "serial_number_invalid",
+		Severity:    SeverityError,
+		Citation:    "RFC 5280 §4.1.2.2",
+		Description: "Serial numbers must be positive and at most 20 octets long",
+		Check: func(c LintCertificate) string {
+			serial := c.Info.Certificate.SerialNumber
+			switch {
+			case serial.Sign() <= 0:
+				return fmt.Sprintf("Serial number %s is not positive", serial)
+			case len(serial.Bytes()) > 20:
+				return fmt.Sprintf("Serial number is %d octets long", len(serial.Bytes()))
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "serial_number_entropy",
+		Severity:    SeverityWarning,
+		Citation:    "CA/B Forum BR §7.1",
+		Description: "Serial numbers must contain at least 64 bits of CSPRNG output",
+		Roles:       []string{RoleLeaf, RoleIntermediate},
+		Check: func(c LintCertificate) string {
+			if bits := c.Info.Certificate.SerialNumber.BitLen(); bits < 64 {
+				return fmt.Sprintf("Serial number is only %d bits long, too short to hold 64 bits of entropy", bits)
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "leaf_ca_key_usage",
+		Severity:    SeverityError,
+		Citation:    "CA/B Forum BR §7.1.2.3(e)",
+		Description: "Subscriber certificates must not assert keyCertSign or cRLSign",
+		Roles:       []string{RoleLeaf},
+		Check: func(c LintCertificate) string {
+			usage := c.Info.Certificate.KeyUsage
+			var bad []string
+			if usage&x509.KeyUsageCertSign != 0 {
+				bad = append(bad, "Certificate Sign")
+			}
+			if usage&x509.KeyUsageCRLSign != 0 {
+				bad = append(bad, "CRL Sign")
+			}
+			if len(bad) > 0 {
+				return "End-entity certificate asserts " + strings.Join(bad, " and ")
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "ca_missing_cert_sign",
+		Severity:    SeverityError,
+		Citation:    "RFC 5280 §4.2.1.3",
+		Description: "CA certificates must assert keyCertSign in the key usage extension",
+		Roles:       []string{RoleIntermediate, RoleRoot},
+		Check: func(c LintCertificate) string {
+			if c.Info.Certificate.KeyUsage&x509.KeyUsageCertSign == 0 {
+				return "CA certificate does not assert Certificate Sign"
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "intermediate_missing_crl",
+		Severity:    SeverityWarning,
+		Citation:    "CA/B Forum BR §7.1.2.2(b)",
+		Description: "Subordinate CA certificates must include a CRL distribution point",
+		Roles:       []string{RoleIntermediate},
+		Check: func(c LintCertificate) string {
+			if len(c.Info.Certificate.CRLDistributionPoints) == 0 {
+				return "Intermediate has no CRL Distribution Points"
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "intermediate_missing_aia",
+		Severity:    SeverityWarning,
+		Citation:    "CA/B Forum BR §7.1.2.2(c)",
+		Description: "Subordinate CA certificates must include Authority Information Access",
+		Roles:       []string{RoleIntermediate},
+		Check: func(c LintCertificate) string {
+			cert := c.Info.Certificate
+			if len(cert.OCSPServer) == 0 && len(cert.IssuingCertificateURL) == 0 {
+				return "Intermediate has no Authority Information Access (OCSP or CA Issuers)"
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "leaf_missing_revocation",
+		Severity:    SeverityWarning,
+		Citation:    "CA/B Forum BR §7.1.2.3(b)-(c)",
+		Description: "Subscriber certificates must point to an OCSP responder or CRL",
+		Roles:       []string{RoleLeaf},
+		Applies:     isServerCertificate,
+		Check: func(c LintCertificate) string {
+			cert := c.Info.Certificate
+			if len(cert.OCSPServer) == 0 && len(cert.CRLDistributionPoints) == 0 {
+				return "Certificate has neither an OCSP responder nor a CRL Distribution Point"
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "weak_signature_hash",
+		Severity:    SeverityError,
+		Citation:    "CA/B Forum BR §7.1.3",
+		Description: "Certificates must not be signed with MD2, MD5 or SHA-1",
+		// A root's self-signature is not relied upon
+		Roles: []string{RoleLeaf, RoleIntermediate},
+		Check: func(c LintCertificate) string {
+			switch c.Info.Certificate.SignatureAlgorithm {
+			case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+				return fmt.Sprintf("Signed with %s", c.Info.Certificate.SignatureAlgorithm)
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "weak_key",
+		Severity:    SeverityError,
+		Citation:    "CA/B Forum BR §6.1.5",
+		Description: "RSA keys must be at least 2048 bits and ECDSA keys must use P-256, P-384 or P-521",
+		Check: func(c LintCertificate) string {
+			return weakKey(c.Info.Certificate.PublicKey)
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "leaf_missing_san",
+		Severity:    SeverityError,
+		Citation:    "CA/B Forum BR §7.1.4.2.1",
+		Description: "Subscriber certificates must contain a Subject Alternative Name extension",
+		Roles:       []string{RoleLeaf},
+		Applies:     isServerCertificate,
+		Check: func(c LintCertificate) string {
+			cert := c.Info.Certificate
+			if len(cert.DNSNames) == 0 && len(cert.IPAddresses) == 0 {
+				return "Certificate has no DNS or IP Subject Alternative Names"
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "cn_not_in_san",
+		Severity:    SeverityError,
+		Citation:    "CA/B Forum BR §7.1.4.2.2(a)",
+		Description: "A subscriber Common Name must repeat one of the Subject Alternative Names",
+		Roles:       []string{RoleLeaf},
+		Applies:     isServerCertificate,
+		Check: func(c LintCertificate) string {
+			cert := c.Info.Certificate
+			return commonNameNotInSANs(cert.Subject.CommonName, cert.DNSNames, cert.IPAddresses)
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "ca_missing_ski",
+		Severity:    SeverityWarning,
+		Citation:    "RFC 5280 §4.2.1.2",
+		Description: "CA certificates must include a Subject Key Identifier",
+		Roles:       []string{RoleIntermediate, RoleRoot},
+		Check: func(c LintCertificate) string {
+			if len(c.Info.Certificate.SubjectKeyId) == 0 {
+				return "CA certificate has no Subject Key Identifier"
+			}
+			return ""
+		},
+	})
+
+	RegisterLintRule(LintRule{
+		ID:          "missing_aki",
+		Severity:    SeverityWarning,
+		Citation:    "RFC 5280 §4.2.1.1",
+		Description: "Certificates other than self-signed roots must include an Authority Key Identifier",
+		Applies: func(c LintCertificate) bool {
+			return !isSelfSigned(c.Info.Certificate)
+		},
+		Check: func(c LintCertificate) string {
+			if len(c.Info.Certificate.AuthorityKeyId) == 0 {
+				return "Certificate has no Authority Key Identifier"
+			}
+			return ""
+		},
+	})
+}
+
+// weakKey describes why key is too weak under BR §6.1.5, or returns "".
+func weakKey(key crypto.PublicKey) string {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if bits := key.N.BitLen(); bits < 2048 {
+			return fmt.Sprintf("RSA key is only %d bits", bits)
+		}
+	case *ecdsa.PublicKey:
+		if key.Curve.Params().BitSize < 256 {
+			return fmt.Sprintf("ECDSA curve %s is not allowed", key.Curve.Params().Name)
+		}
+	}
+	return ""
+}
+
+func commonNameNotInSANs(cn string, dnsNames []string, ips []net.IP) string {
+	if cn == "" {
+		return ""
+	}
+	if ip := net.ParseIP(cn); ip != nil {
+		for _, san := range ips {
+			if san.Equal(ip) {
+				return ""
+			}
+		}
+	}
+	for _, san := range dnsNames {
+		if strings.EqualFold(san, cn) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("Common Name %q is not among the Subject Alternative Names", cn)
+}
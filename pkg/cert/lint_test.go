@@ -0,0 +1,300 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLintChain(t *testing.T) {
+	day := 24 * time.Hour
+	now := time.Now().Truncate(time.Second)
+	randomSerial := func() *big.Int {
+		serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return serial.SetBit(serial, 126, 1)
+	}
+	caTemplate := func(name string) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          randomSerial(),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             now.Add(-365 * day),
+			NotAfter:              now.Add(365 * day),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			CRLDistributionPoints: []string{"http://crl.example.com/root.crl"},
+			OCSPServer:            []string{"http://ocsp.example.com"},
+		}
+	}
+	root := newTestCert(t, caTemplate("Lint Test Root"), nil)
+	intermediate := newTestCert(t, caTemplate("Lint Test Intermediate"), root)
+	rsa1024, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A leaf that passes every rule; each case changes it.
+	leafTemplate := func() *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          randomSerial(),
+			Subject:               pkix.Name{CommonName: "www.example.com"},
+			DNSNames:              []string{"www.example.com", "example.com"},
+			NotBefore:             now,
+			NotAfter:              now.Add(90 * day),
+			KeyUsage:              x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			OCSPServer:            []string{"http://ocsp.example.com"},
+			CRLDistributionPoints: []string{"http://crl.example.com/int.crl"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		template func() *x509.Certificate // issued by the intermediate
+		ca       bool                     // check the intermediate instead
+		mutate   func(*x509.Certificate)  // for values x509 refuses to encode
+		want     []string
+	}{
+		{name: "compliant leaf"},
+		{name: "compliant intermediate", ca: true},
+		{
+			name: "398 days",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.NotAfter = c.NotBefore.Add(398*day - time.Second)
+				return c
+			},
+		},
+		{
+			name: "399 days",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.NotAfter = c.NotBefore.Add(398 * day)
+				return c
+			},
+			want: []string{"leaf_validity_period"},
+		},
+		{
+			name: "825 days before September 2020",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.NotBefore = time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+				c.NotAfter = c.NotBefore.Add(825*day - time.Second)
+				return c
+			},
+		},
+		{
+			name: "two years before September 2020",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.NotBefore = time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+				c.NotAfter = c.NotBefore.Add(900 * day)
+				return c
+			},
+			want: []string{"leaf_validity_period"},
+		},
+		{
+			name: "S/MIME certificate",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.Subject.CommonName, c.DNSNames = "Jane Doe", nil
+				c.EmailAddresses = []string{"jane@example.com"}
+				c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection}
+				c.OCSPServer, c.CRLDistributionPoints = nil, nil
+				c.NotAfter = c.NotBefore.Add(3 * 365 * day)
+				return c
+			},
+		},
+		{
+			name: "short serial",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.SerialNumber = big.NewInt(0x1234)
+				return c
+			},
+			want: []string{"serial_number_entropy"},
+		},
+		{
+			name:   "negative serial",
+			mutate: func(c *x509.Certificate) { c.SerialNumber = big.NewInt(-1) },
+			want:   []string{"serial_number_entropy", "serial_number_invalid"},
+		},
+		{
+			name:   "21 octet serial",
+			mutate: func(c *x509.Certificate) { c.SerialNumber = new(big.Int).Lsh(big.NewInt(1), 160) },
+			want:   []string{"serial_number_invalid"},
+		},
+		{
+			name: "leaf with CA key usages",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.KeyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+				return c
+			},
+			want: []string{"leaf_ca_key_usage"},
+		},
+		{
+			name: "no SANs",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.DNSNames = nil
+				return c
+			},
+			want: []string{"cn_not_in_san", "leaf_missing_san"},
+		},
+		{
+			name: "Common Name not in the SANs",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.Subject.CommonName = "mail.example.com"
+				return c
+			},
+			want: []string{"cn_not_in_san"},
+		},
+		{
+			name: "Common Name in another case",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.Subject.CommonName = "WWW.Example.com"
+				return c
+			},
+		},
+		{
+			name: "IP address Common Name",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.Subject.CommonName = "192.0.2.1"
+				c.IPAddresses = []net.IP{net.ParseIP("192.0.2.1")}
+				return c
+			},
+		},
+		{
+			name: "no revocation pointers",
+			template: func() *x509.Certificate {
+				c := leafTemplate()
+				c.OCSPServer, c.CRLDistributionPoints = nil, nil
+				return c
+			},
+			want: []string{"leaf_missing_revocation"},
+		},
+		{
+			name:   "SHA-1 signature",
+			mutate: func(c *x509.Certificate) { c.SignatureAlgorithm = x509.SHA1WithRSA },
+			want:   []string{"weak_signature_hash"},
+		},
+		{
+			name:   "1024-bit RSA key",
+			mutate: func(c *x509.Certificate) { c.PublicKey = &rsa1024.PublicKey },
+			want:   []string{"weak_key"},
+		},
+		{
+			name:   "P-224 key",
+			mutate: func(c *x509.Certificate) { c.PublicKey = &p224.PublicKey },
+			want:   []string{"weak_key"},
+		},
+		{
+			name:   "no authority key identifier",
+			mutate: func(c *x509.Certificate) { c.AuthorityKeyId = nil },
+			want:   []string{"missing_aki"},
+		},
+		{
+			name: "intermediate without revocation pointers",
+			ca:   true,
+			mutate: func(c *x509.Certificate) {
+				c.CRLDistributionPoints, c.OCSPServer = nil, nil
+			},
+			want: []string{"intermediate_missing_aia", "intermediate_missing_crl"},
+		},
+		{
+			name:   "intermediate without certificate signing",
+			ca:     true,
+			mutate: func(c *x509.Certificate) { c.KeyUsage = x509.KeyUsageCRLSign },
+			want:   []string{"ca_missing_cert_sign"},
+		},
+		{
+			name:   "intermediate without subject key identifier",
+			ca:     true,
+			mutate: func(c *x509.Certificate) { c.SubjectKeyId = nil },
+			want:   []string{"ca_missing_ski"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cert x509.Certificate
+			switch {
+			case tt.ca:
+				cert = *intermediate.cert
+			case tt.template != nil:
+				cert = *newTestCert(t, tt.template(), intermediate).cert
+			default:
+				cert = *newTestCert(t, leafTemplate(), intermediate).cert
+			}
+			if tt.mutate != nil {
+				tt.mutate(&cert)
+			}
+
+			certs := []CertificateInfo{{Certificate: &cert}, {Certificate: root.cert}}
+			var got []string
+			for _, finding := range lintChain(certs) {
+				if finding.Certificate != 0 {
+					t.Errorf("unexpected finding for the root: %s: %s", finding.ID, finding.Message)
+					continue
+				}
+				if finding.Message == "" || finding.Severity == "" || finding.Citation == "" {
+					t.Errorf("incomplete finding %+v", finding)
+				}
+				got = append(got, finding.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCertificateRole(t *testing.T) {
+	root := newTestRoot(t, "Role Test Root")
+	intermediate := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Role Test Intermediate"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, root)
+	selfSignedLeaf := newTestCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "localhost"}}, nil)
+
+	tests := []struct {
+		name string
+		cert *x509.Certificate
+		want string
+	}{
+		{name: "root", cert: root.cert, want: RoleRoot},
+		{name: "intermediate", cert: intermediate.cert, want: RoleIntermediate},
+		{name: "leaf", cert: newTestLeaf(t, "www.example.com", root).cert, want: RoleLeaf},
+		{name: "self-signed leaf", cert: selfSignedLeaf.cert, want: RoleLeaf},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := certificateRole(tt.cert); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
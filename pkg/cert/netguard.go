@@ -0,0 +1,73 @@
+package cert
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+)
+
+// Download clients for URLs taken from certificates. publicDownloadClient
+// is used when AnalyzeOptions.PublicNetworkOnly is set, so that a server
+// analyzing certificates submitted by others cannot be made to reach
+// internal services through the OCSP and CRL URLs they contain.
+var (
+	defaultDownloadClient = &http.Client{Timeout: fetchTimeout}
+	publicDownloadClient  = &http.Client{
+		Timeout: fetchTimeout,
+		// No proxy: the address check must see the real destination
+		Transport: &http.Transport{
+			DialContext:         (&net.Dialer{Timeout: fetchTimeout, Control: publicDialControl}).DialContext,
+			TLSHandshakeTimeout: fetchTimeout,
+		},
+	}
+)
+
+// Special-purpose ranges that netip does not classify (RFC 6890).
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // shared address space
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+}
+
+func downloadClient(publicOnly bool) *http.Client {
+	if publicOnly {
+		return publicDownloadClient
+	}
+	return defaultDownloadClient
+}
+
+// publicDialControl runs after name resolution, for every address dialed
+// (including redirects), so DNS cannot be used to get around it.
+func publicDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddr(addr) {
+		return fmt.Errorf("refusing to connect to %s: not a public address", host)
+	}
+	return nil
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package cert
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false}, // cloud metadata
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestFetchPublicNetworkOnly(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	address := server.Listener.Addr().String()
+
+	tests := []struct {
+		name      string
+		target    string
+		opts      FetchOptions
+		wantError string
+	}{
+		{name: "loopback target", target: address},
+		{name: "loopback connect address", target: "www.example.com:443", opts: FetchOptions{Connect: address}},
+		{
+			name:      "loopback target refused",
+			target:    address,
+			opts:      FetchOptions{PublicNetworkOnly: true},
+			wantError: "not a public address",
+		},
+		{
+			name:      "loopback connect address refused",
+			target:    "www.example.com:443",
+			opts:      FetchOptions{Connect: address, PublicNetworkOnly: true},
+			wantError: "not a public address",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.StartTLS = StartTLSNone
+			result, err := Fetch(tt.target, tt.opts)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("error %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Connection.Address != address || len(result.Certificates) == 0 {
+				t.Errorf("dialed %s and got %d certificates", result.Connection.Address, len(result.Certificates))
+			}
+		})
+	}
+
+	if _, err := Scan(address, FetchOptions{StartTLS: StartTLSNone, PublicNetworkOnly: true}); err == nil {
+		t.Error("Scan reached a loopback address")
+	}
+}
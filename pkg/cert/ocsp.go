@@ -0,0 +1,206 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// OCSP response sources.
+const (
+	OCSPStapled   = "stapled"
+	OCSPResponder = "responder"
+)
+
+// maxOCSPResponse bounds the size of a responder reply.
+const maxOCSPResponse = 1 << 20
+
+// OCSPInfo is the outcome of checking one OCSP response. Status is empty
+// when no usable response was obtained; Error then says why.
+type OCSPInfo struct {
+	Source      string // OCSPStapled or OCSPResponder
+	Certificate int    // index of the certificate the response is for
+	URL         string // responder queried, empty for stapled responses
+	Status      string // "good", "revoked" or "unknown"
+	ProducedAt  time.Time
+	ThisUpdate  time.Time
+	NextUpdate  time.Time // zero if the responder gave none
+	RevokedAt   time.Time
+	Reason      string
+	// Signer is the subject of the certificate that signed the response.
+	// Delegated is set when that is an OCSP signing certificate issued by
+	// the CA rather than the CA itself.
+	Signer         string
+	Delegated      bool
+	SignatureValid bool
+	IsStale        bool // NextUpdate has passed
+	Error          string
+}
+
+// CRLReason codes from RFC 5280, section 5.3.1.
+var revocationReasons = map[int]string{
+	0:  "unspecified",
+	1:  "keyCompromise",
+	2:  "cACompromise",
+	3:  "affiliationChanged",
+	4:  "superseded",
+	5:  "cessationOfOperation",
+	6:  "certificateHold",
+	8:  "removeFromCRL",
+	9:  "privilegeWithdrawn",
+	10: "aACompromise",
+}
+
+func revocationReasonName(code int) string {
+	if name, ok := revocationReasons[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (%d)", code)
+}
+
+// analyzeOCSP checks the response stapled to the handshake and, when client
+// is not nil, asks the AIA OCSP responder of every certificate that has one.
+func analyzeOCSP(certs []*x509.Certificate, graph *issuerGraph, trust *TrustInfo, handshake *HandshakeInfo, client *http.Client) []OCSPInfo {
+	var infos []OCSPInfo
+
+	// The staple covers the certificate the server presented first
+	if handshake != nil && len(handshake.OCSPStaple) > 0 && len(certs) > 0 {
+		info := checkOCSPResponse(handshake.OCSPStaple, certs[0], chainIssuer(0, certs, graph, trust))
+		info.Source, info.Certificate = OCSPStapled, 0
+		infos = append(infos, info)
+	}
+
+	if client == nil {
+		return infos
+	}
+	for i, cert := range certs {
+		if _, dup := graph.duplicates[i]; dup || len(cert.OCSPServer) == 0 || isSelfSigned(cert) {
+			continue
+		}
+		info := OCSPInfo{Source: OCSPResponder, Certificate: i, URL: cert.OCSPServer[0]}
+		issuer := chainIssuer(i, certs, graph, trust)
+		if issuer == nil {
+			info.Error = "issuer certificate not present"
+		} else if der, err := queryOCSP(client, info.URL, cert, issuer); err != nil {
+			info.Error = err.Error()
+		} else {
+			info = checkOCSPResponse(der, cert, issuer)
+			info.Source, info.Certificate, info.URL = OCSPResponder, i, cert.OCSPServer[0]
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// chainIssuer finds the issuer of certs[i] in the input or, for the top of
+// the chain, in a verified trust path.
+func chainIssuer(i int, certs []*x509.Certificate, graph *issuerGraph, trust *TrustInfo) *x509.Certificate {
+	if len(graph.issuers[i]) > 0 {
+		return certs[graph.issuers[i][0]]
+	}
+	if trust == nil {
+		return nil
+	}
+	for _, path := range trust.Paths {
+		for j := 0; j+1 < len(path.Path); j++ {
+			if path.Path[j].Certificate.Equal(certs[i]) {
+				return path.Path[j+1].Certificate
+			}
+		}
+	}
+	return nil
+}
+
+func queryOCSP(client *http.Client, url string, cert, issuer *x509.Certificate) ([]byte, error) {
+	request, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCSP request: %v", err)
+	}
+
+	resp, err := client.Post(url, "application/ocsp-request", bytes.NewReader(request))
+	if err != nil {
+		return nil, fmt.Errorf("OCSP request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OCSP responder returned %s", resp.Status)
+	}
+
+	der, err := io.ReadAll(io.LimitReader(resp.Body, maxOCSPResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCSP response: %v", err)
+	}
+	return der, nil
+}
+
+// checkOCSPResponse parses a DER OCSP response for cert and verifies that it
+// was signed by issuer or by a responder certificate that issuer authorized
+// for OCSP signing (RFC 6960, section 4.2.2.2).
+func checkOCSPResponse(der []byte, cert, issuer *x509.Certificate) OCSPInfo {
+	var info OCSPInfo
+	if issuer == nil {
+		info.Error = "issuer certificate not present, response cannot be verified"
+		return info
+	}
+
+	// Signature checks are done below so that delegated responders and
+	// responses that embed the issuer itself are handled alike. Without an
+	// issuer the parser still checks the signature of an embedded signer.
+	resp, err := ocsp.ParseResponseForCert(der, cert, nil)
+	var respErr ocsp.ResponseError
+	if errors.As(err, &respErr) {
+		info.Error = "OCSP responder returned " + respErr.Status.String()
+		return info
+	} else if err != nil {
+		info.Error = fmt.Sprintf("invalid OCSP response: %v", err)
+		return info
+	}
+
+	info.ProducedAt = resp.ProducedAt
+	info.ThisUpdate = resp.ThisUpdate
+	info.NextUpdate = resp.NextUpdate
+	info.IsStale = !resp.NextUpdate.IsZero() && time.Now().After(resp.NextUpdate)
+	switch resp.Status {
+	case ocsp.Good:
+		info.Status = "good"
+	case ocsp.Revoked:
+		info.Status = "revoked"
+		info.RevokedAt = resp.RevokedAt
+		info.Reason = revocationReasonName(resp.RevocationReason)
+	default:
+		info.Status = "unknown"
+	}
+
+	signer := resp.Certificate
+	if signer == nil || signer.Equal(issuer) {
+		info.Signer = issuer.Subject.String()
+		if err := resp.CheckSignatureFrom(issuer); err != nil {
+			info.Error = fmt.Sprintf("OCSP signature does not verify with the issuer: %v", err)
+			return info
+		}
+		info.SignatureValid = true
+		return info
+	}
+
+	info.Signer = signer.Subject.String()
+	info.Delegated = true
+	now := time.Now()
+	switch {
+	case signer.CheckSignatureFrom(issuer) != nil:
+		info.Error = "delegated OCSP signer is not issued by the certificate's issuer"
+	case !slices.Contains(signer.ExtKeyUsage, x509.ExtKeyUsageOCSPSigning):
+		info.Error = "delegated OCSP signer lacks the OCSP Signing extended key usage"
+	case now.Before(signer.NotBefore) || now.After(signer.NotAfter):
+		info.Error = "delegated OCSP signer certificate is outside its validity period"
+	default:
+		info.SignatureValid = true
+	}
+	return info
+}
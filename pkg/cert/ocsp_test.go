@@ -0,0 +1,244 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// ocspTestResponder answers every request with the response built by
+// respond for the requested serial number.
+func ocspTestResponder(t *testing.T, respond func(serial *big.Int) []byte) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		request, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		w.Write(respond(request.SerialNumber))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAnalyzeOCSPResponder(t *testing.T) {
+	root := newTestRoot(t, "OCSP Test Root")
+	otherRoot := newTestRoot(t, "Other Root")
+	delegated := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "OCSP Responder"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}, root)
+	noEKU := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "Responder Without EKU"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, root)
+	expired := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "Expired Responder"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		NotBefore:   time.Now().Add(-48 * time.Hour),
+		NotAfter:    time.Now().Add(-24 * time.Hour),
+	}, root)
+	foreign := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "Foreign Responder"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}, otherRoot)
+	wrongKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revokedAt := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name      string
+		status    int
+		reason    int
+		signer    *testCA // nil signs with the root itself
+		key       crypto.Signer
+		serial    *big.Int // overrides the requested serial
+		want      string
+		wantValid bool
+		delegated bool
+		wantError string
+	}{
+		{name: "good", status: ocsp.Good, want: "good", wantValid: true},
+		{name: "revoked", status: ocsp.Revoked, reason: ocsp.KeyCompromise, want: "revoked", wantValid: true},
+		{name: "unknown", status: ocsp.Unknown, want: "unknown", wantValid: true},
+		{name: "delegated signer", status: ocsp.Good, signer: delegated, want: "good", wantValid: true, delegated: true},
+		{name: "delegated signer without OCSP signing", status: ocsp.Good, signer: noEKU, want: "good", delegated: true,
+			wantError: "lacks the OCSP Signing extended key usage"},
+		{name: "expired delegated signer", status: ocsp.Good, signer: expired, want: "good", delegated: true,
+			wantError: "outside its validity period"},
+		{name: "delegated signer from another CA", status: ocsp.Good, signer: foreign, want: "good", delegated: true,
+			wantError: "not issued by the certificate's issuer"},
+		{name: "bad signature", status: ocsp.Good, key: wrongKey, want: "good",
+			wantError: "OCSP signature does not verify with the issuer"},
+		{name: "serial mismatch", status: ocsp.Good, serial: big.NewInt(1), wantError: "no response matching"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaf := newTestLeaf(t, "ocsp.example.com", root)
+			server := ocspTestResponder(t, func(serial *big.Int) []byte {
+				template := ocsp.Response{
+					Status:           tt.status,
+					SerialNumber:     serial,
+					ThisUpdate:       time.Now().Add(-time.Hour),
+					NextUpdate:       time.Now().Add(time.Hour),
+					RevokedAt:        revokedAt,
+					RevocationReason: tt.reason,
+				}
+				if tt.serial != nil {
+					template.SerialNumber = tt.serial
+				}
+				responder, key := root.cert, root.key
+				if tt.signer != nil {
+					responder, key = tt.signer.cert, tt.signer.key
+					template.Certificate = tt.signer.cert
+				}
+				if tt.key != nil {
+					key = tt.key
+				}
+				der, err := ocsp.CreateResponse(root.cert, responder, template, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return der
+			})
+			leaf.cert.OCSPServer = []string{server.URL}
+
+			certs := []*x509.Certificate{leaf.cert, root.cert}
+			infos := analyzeOCSP(certs, buildIssuerGraph(certs), nil, nil, defaultDownloadClient)
+			if len(infos) != 1 {
+				t.Fatalf("got %d OCSP results, want 1", len(infos))
+			}
+			info := infos[0]
+
+			if info.Source != OCSPResponder || info.Certificate != 0 || info.URL != server.URL {
+				t.Errorf("source = %q, certificate %d, URL %q", info.Source, info.Certificate, info.URL)
+			}
+			if info.Status != tt.want {
+				t.Errorf("status = %q, want %q", info.Status, tt.want)
+			}
+			if info.SignatureValid != tt.wantValid {
+				t.Errorf("signature valid = %v, want %v (error %q)", info.SignatureValid, tt.wantValid, info.Error)
+			}
+			if info.Delegated != tt.delegated {
+				t.Errorf("delegated = %v, want %v", info.Delegated, tt.delegated)
+			}
+			if tt.wantError == "" && info.Error != "" {
+				t.Errorf("unexpected error %q", info.Error)
+			}
+			if !strings.Contains(info.Error, tt.wantError) {
+				t.Errorf("error = %q, want it to contain %q", info.Error, tt.wantError)
+			}
+			if tt.want == "revoked" {
+				if !info.RevokedAt.Equal(revokedAt) || info.Reason != "keyCompromise" {
+					t.Errorf("revoked at %v (%s), want %v (keyCompromise)", info.RevokedAt, info.Reason, revokedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckOCSPResponseErrors(t *testing.T) {
+	root := newTestRoot(t, "OCSP Test Root")
+	leaf := newTestLeaf(t, "ocsp.example.com", root)
+
+	stale, err := ocsp.CreateResponse(root.cert, root.cert, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: leaf.cert.SerialNumber,
+		ThisUpdate:   time.Now().Add(-48 * time.Hour),
+		NextUpdate:   time.Now().Add(-24 * time.Hour),
+	}, root.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		der       []byte
+		issuer    *x509.Certificate
+		wantError string
+		wantStale bool
+	}{
+		{name: "responder error", der: ocsp.UnauthorizedErrorResponse, issuer: root.cert, wantError: "OCSP responder returned unauthorized"},
+		{name: "malformed", der: []byte{0x30, 0x03, 0x0a, 0x01, 0x00}, issuer: root.cert, wantError: "invalid OCSP response"},
+		{name: "missing issuer", der: stale, wantError: "issuer certificate not present"},
+		{name: "stale", der: stale, issuer: root.cert, wantStale: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := checkOCSPResponse(tt.der, leaf.cert, tt.issuer)
+			if tt.wantError == "" && info.Error != "" {
+				t.Errorf("unexpected error %q", info.Error)
+			}
+			if !strings.Contains(info.Error, tt.wantError) {
+				t.Errorf("error = %q, want it to contain %q", info.Error, tt.wantError)
+			}
+			if info.IsStale != tt.wantStale {
+				t.Errorf("stale = %v, want %v", info.IsStale, tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestAnalyzeOCSPStapled(t *testing.T) {
+	root := newTestRoot(t, "OCSP Test Root")
+	leaf := newTestLeaf(t, "ocsp.example.com", root)
+	staple, err := ocsp.CreateResponse(root.cert, root.cert, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: leaf.cert.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Hour),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}, root.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a client, only the staple is checked and nothing is fetched
+	leaf.cert.OCSPServer = []string{"http://192.0.2.1/unreachable"}
+	certs := []*x509.Certificate{leaf.cert, root.cert}
+	infos := analyzeOCSP(certs, buildIssuerGraph(certs), nil, &HandshakeInfo{OCSPStaple: staple}, nil)
+	if len(infos) != 1 {
+		t.Fatalf("got %d OCSP results, want 1", len(infos))
+	}
+	if info := infos[0]; info.Source != OCSPStapled || info.Status != "good" || !info.SignatureValid {
+		t.Errorf("got %+v, want a valid good stapled response", info)
+	}
+}
+
+func TestAnalyzeOCSPPublicNetworkOnly(t *testing.T) {
+	root := newTestRoot(t, "OCSP Test Root")
+	leaf := newTestLeaf(t, "ocsp.example.com", root)
+	server := ocspTestResponder(t, func(serial *big.Int) []byte {
+		t.Error("responder on a loopback address was queried")
+		return nil
+	})
+	leaf.cert.OCSPServer = []string{server.URL}
+
+	certs := []*x509.Certificate{leaf.cert, root.cert}
+	infos := analyzeOCSP(certs, buildIssuerGraph(certs), nil, nil, downloadClient(true))
+	if len(infos) != 1 || !strings.Contains(infos[0].Error, "not a public address") {
+		t.Errorf("got %+v, want the loopback responder to be refused", infos)
+	}
+}
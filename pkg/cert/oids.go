@@ -0,0 +1,133 @@
+package cert
+
+var extensionNames = map[string]string{
+	"2.5.29.9":                "Subject Directory Attributes",
+	"2.5.29.14":               "Subject Key Identifier",
+	"2.5.29.15":               "Key Usage",
+	"2.5.29.16":               "Private Key Usage Period",
+	"2.5.29.17":               "Subject Alternative Name",
+	"2.5.29.18":               "Issuer Alternative Name",
+	"2.5.29.19":               "Basic Constraints",
+	"2.5.29.20":               "CRL Number",
+	"2.5.29.21":               "CRL Reason Code",
+	"2.5.29.23":               "Hold Instruction Code",
+	"2.5.29.24":               "Invalidity Date",
+	"2.5.29.27":               "Delta CRL Indicator",
+	"2.5.29.28":               "Issuing Distribution Point",
+	"2.5.29.29":               "Certificate Issuer",
+	"2.5.29.30":               "Name Constraints",
+	"2.5.29.31":               "CRL Distribution Points",
+	"2.5.29.32":               "Certificate Policies",
+	"2.5.29.33":               "Policy Mappings",
+	"2.5.29.35":               "Authority Key Identifier",
+	"2.5.29.36":               "Policy Constraints",
+	"2.5.29.37":               "Extended Key Usage",
+	"2.5.29.46":               "Freshest CRL",
+	"2.5.29.54":               "Inhibit Any Policy",
+	"2.5.29.55":               "Target Information",
+	"2.5.29.56":               "No Revocation Available",
+	"1.3.6.1.5.5.7.1.1":       "Authority Information Access",
+	"1.3.6.1.5.5.7.1.2":       "Biometric Information",
+	"1.3.6.1.5.5.7.1.3":       "Qualified Certificate Statements",
+	"1.3.6.1.5.5.7.1.7":       "IP Address Blocks",
+	"1.3.6.1.5.5.7.1.8":       "AS Identifiers",
+	"1.3.6.1.5.5.7.1.11":      "Subject Information Access",
+	"1.3.6.1.5.5.7.1.12":      "Logotype",
+	"1.3.6.1.5.5.7.1.24":      "TLS Feature",
+	"1.3.6.1.5.5.7.48.1.2":    "OCSP Nonce",
+	"1.3.6.1.5.5.7.48.1.5":    "OCSP No Check",
+	"1.3.6.1.4.1.11129.2.4.2": "Signed Certificate Timestamp List",
+	"1.3.6.1.4.1.11129.2.4.3": "Precertificate Poison",
+	"1.3.6.1.4.1.11129.2.4.4": "Precertificate Signing Certificate",
+	"1.3.6.1.4.1.311.20.2":    "Microsoft Certificate Template Name",
+	"1.3.6.1.4.1.311.21.1":    "Microsoft CA Version",
+	"1.3.6.1.4.1.311.21.2":    "Microsoft Previous CA Certificate Hash",
+	"1.3.6.1.4.1.311.21.7":    "Microsoft Certificate Template",
+	"1.3.6.1.4.1.311.21.10":   "Microsoft Application Policies",
+	"1.3.6.1.4.1.311.25.2":    "Microsoft NTDS CA Security",
+	"2.16.840.1.113730.1.1":   "Netscape Certificate Type",
+	"2.16.840.1.113730.1.13":  "Netscape Comment",
+	"1.2.840.113549.1.9.15":   "S/MIME Capabilities",
+	"2.23.140.3.1":            "CA/B Forum Organization Identifier",
+	"1.3.6.1.4.1.57264.1.1":   "Sigstore OIDC Issuer",
+}
+
+var extKeyUsageNames = map[string]string{
+	"2.5.29.37.0":             "Any Extended Key Usage",
+	"1.3.6.1.5.5.7.3.1":       "Server Authentication",
+	"1.3.6.1.5.5.7.3.2":       "Client Authentication",
+	"1.3.6.1.5.5.7.3.3":       "Code Signing",
+	"1.3.6.1.5.5.7.3.4":       "Email Protection",
+	"1.3.6.1.5.5.7.3.5":       "IPSec End System",
+	"1.3.6.1.5.5.7.3.6":       "IPSec Tunnel",
+	"1.3.6.1.5.5.7.3.7":       "IPSec User",
+	"1.3.6.1.5.5.7.3.8":       "Time Stamping",
+	"1.3.6.1.5.5.7.3.9":       "OCSP Signing",
+	"1.3.6.1.5.5.7.3.17":      "IPSec IKE",
+	"1.3.6.1.5.5.7.3.21":      "SSH Client",
+	"1.3.6.1.5.5.7.3.22":      "SSH Server",
+	"1.3.6.1.5.5.7.3.36":      "Document Signing",
+	"1.3.6.1.4.1.311.10.3.3":  "Microsoft Server Gated Crypto",
+	"1.3.6.1.4.1.311.10.3.4":  "Microsoft Encrypting File System",
+	"1.3.6.1.4.1.311.10.3.12": "Microsoft Document Signing",
+	"1.3.6.1.4.1.311.20.2.2":  "Microsoft Smart Card Logon",
+	"1.3.6.1.4.1.311.61.1.1":  "Microsoft Kernel Mode Code Signing",
+	"2.16.840.1.113730.4.1":   "Netscape Server Gated Crypto",
+	"1.3.6.1.4.1.11129.2.4.4": "Certificate Transparency",
+	"1.3.6.1.5.2.3.4":         "Kerberos PKINIT Client",
+	"1.3.6.1.5.2.3.5":         "Kerberos PKINIT KDC",
+}
+
+var policyNames = map[string]string{
+	"2.5.29.32.0":                "Any Policy",
+	"2.23.140.1.1":               "Extended Validation (CA/B Forum)",
+	"2.23.140.1.2.1":             "Domain Validated (CA/B Forum)",
+	"2.23.140.1.2.2":             "Organization Validated (CA/B Forum)",
+	"2.23.140.1.2.3":             "Individual Validated (CA/B Forum)",
+	"2.23.140.1.3":               "EV Code Signing (CA/B Forum)",
+	"2.23.140.1.4.1":             "Code Signing (CA/B Forum)",
+	"2.23.140.1.5.1.1":           "S/MIME Mailbox Validated Legacy (CA/B Forum)",
+	"2.23.140.1.5.1.2":           "S/MIME Mailbox Validated Multipurpose (CA/B Forum)",
+	"2.23.140.1.5.1.3":           "S/MIME Mailbox Validated Strict (CA/B Forum)",
+	"2.23.140.1.31":              "Onion EV (CA/B Forum)",
+	"1.3.6.1.4.1.44947.1.1.1":    "ISRG Domain Validated",
+	"1.3.6.1.4.1.11129.2.5.3":    "Google Trust Services",
+	"2.16.840.1.114412.1.1":      "DigiCert OV",
+	"2.16.840.1.114412.2.1":      "DigiCert EV",
+	"1.3.6.1.4.1.6449.1.2.1.5.1": "Sectigo / Comodo EV",
+	"1.3.6.1.4.1.4146.1.1":       "GlobalSign EV",
+}
+
+var accessMethodNames = map[string]string{
+	"1.3.6.1.5.5.7.48.1":  "OCSP",
+	"1.3.6.1.5.5.7.48.2":  "CA Issuers",
+	"1.3.6.1.5.5.7.48.3":  "Time Stamping",
+	"1.3.6.1.5.5.7.48.5":  "CA Repository",
+	"1.3.6.1.5.5.7.48.10": "RPKI Manifest",
+	"1.3.6.1.5.5.7.48.11": "Signed Object",
+	"1.3.6.1.5.5.7.48.13": "RPKI Notify",
+}
+
+// otherName type-ids seen in Subject Alternative Names.
+var otherNameTypes = map[string]string{
+	"1.3.6.1.4.1.311.20.2.3": "User Principal Name",
+	"1.3.6.1.4.1.311.25.1":   "Microsoft DS Object GUID",
+	"1.3.6.1.5.2.2":          "Kerberos Principal Name",
+	"1.3.6.1.5.5.7.8.3":      "Permanent Identifier",
+	"1.3.6.1.5.5.7.8.4":      "Hardware Module Name",
+	"1.3.6.1.5.5.7.8.5":      "XMPP Address",
+	"1.3.6.1.5.5.7.8.7":      "DNS SRV Name",
+	"1.3.6.1.5.5.7.8.9":      "SMTP UTF8 Mailbox",
+}
+
+var policyQualifierNames = map[string]string{
+	"1.3.6.1.5.5.7.2.1": "CPS",
+	"1.3.6.1.5.5.7.2.2": "User Notice",
+}
+
+func oidName(names map[string]string, oid string) string {
+	if name, ok := names[oid]; ok {
+		return name + " (" + oid + ")"
+	}
+	return oid
+}
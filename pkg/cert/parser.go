@@ -1,6 +1,7 @@
 package cert
 
 import (
+	"crypto"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -8,6 +9,15 @@ import (
 	"strings"
 )
 
+// Bundle holds everything extracted from a certificate file: plain
+// certificates as well as the contents of PKCS#7 and PKCS#12 containers.
+type Bundle struct {
+	Certificates []*x509.Certificate
+	CRLs         []*x509.RevocationList
+	Requests     []*x509.CertificateRequest
+	PrivateKey   crypto.PrivateKey
+}
+
 func ParseCertificateFile(filename string) ([]*x509.Certificate, error) {
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
@@ -18,47 +28,108 @@ func ParseCertificateFile(filename string) ([]*x509.Certificate, error) {
 }
 
 func ParseCertificateData(data []byte) ([]*x509.Certificate, error) {
-	var certificates []*x509.Certificate
+	bundle, err := ParseBundleData(data, "")
+	if err != nil {
+		return nil, err
+	}
 
-	if isPEM(data) {
-		certificates, err := parsePEMData(data)
-		if err != nil {
-			return nil, err
+	if len(bundle.Certificates) == 0 {
+		if len(bundle.Requests) > 0 {
+			return nil, fmt.Errorf("input contains certificate requests but no certificates")
 		}
-		return certificates, nil
+		if len(bundle.CRLs) > 0 {
+			return nil, fmt.Errorf("input contains CRLs but no certificates")
+		}
+		return nil, fmt.Errorf("no certificates found in input")
 	}
 
-	cert, err := x509.ParseCertificate(data)
+	return bundle.Certificates, nil
+}
+
+// The password is only used to decrypt PKCS#12 keystores.
+func ParseBundleFile(filename, password string) (*Bundle, error) {
+	data, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse DER certificate: %v", err)
+		return nil, fmt.Errorf("failed to read file: %v", err)
 	}
-	certificates = append(certificates, cert)
 
-	return certificates, nil
+	return ParseBundleData(data, password)
+}
+
+func ParseBundleData(data []byte, password string) (*Bundle, error) {
+	if isPEM(data) {
+		return parsePEMData(data)
+	}
+
+	cert, err := x509.ParseCertificate(data)
+	if err == nil {
+		return &Bundle{Certificates: []*x509.Certificate{cert}}, nil
+	}
+
+	if isPKCS7(data) {
+		return parsePKCS7(data)
+	}
+
+	if isPKCS12(data) {
+		return parsePKCS12(data, password)
+	}
+
+	if crl, crlErr := x509.ParseRevocationList(data); crlErr == nil {
+		return &Bundle{CRLs: []*x509.RevocationList{crl}}, nil
+	}
+
+	if csr, csrErr := x509.ParseCertificateRequest(data); csrErr == nil {
+		return &Bundle{Requests: []*x509.CertificateRequest{csr}}, nil
+	}
+
+	return nil, fmt.Errorf("failed to parse DER certificate: %v", err)
 }
 
 func isPEM(data []byte) bool {
-	return strings.Contains(string(data), "-----BEGIN CERTIFICATE-----")
+	return strings.Contains(string(data), "-----BEGIN ")
 }
 
-func parsePEMData(data []byte) ([]*x509.Certificate, error) {
-	var certificates []*x509.Certificate
+func parsePEMData(data []byte) (*Bundle, error) {
+	bundle := &Bundle{}
 	block, rest := pem.Decode(data)
 
 	for block != nil {
-		if block.Type == "CERTIFICATE" {
+		switch block.Type {
+		case "CERTIFICATE":
 			cert, err := x509.ParseCertificate(block.Bytes)
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse PEM certificate: %v", err)
 			}
-			certificates = append(certificates, cert)
+			bundle.Certificates = append(bundle.Certificates, cert)
+
+		case "PKCS7", "CMS":
+			p7, err := parsePKCS7(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			bundle.Certificates = append(bundle.Certificates, p7.Certificates...)
+			bundle.CRLs = append(bundle.CRLs, p7.CRLs...)
+
+		case "X509 CRL":
+			crl, err := x509.ParseRevocationList(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse PEM CRL: %v", err)
+			}
+			bundle.CRLs = append(bundle.CRLs, crl)
+
+		case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+			csr, err := x509.ParseCertificateRequest(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse PEM certificate request: %v", err)
+			}
+			bundle.Requests = append(bundle.Requests, csr)
 		}
 		block, rest = pem.Decode(rest)
 	}
 
-	if len(certificates) == 0 {
-		return nil, fmt.Errorf("no valid certificates found in PEM data")
+	if len(bundle.Certificates) == 0 && len(bundle.CRLs) == 0 && len(bundle.Requests) == 0 {
+		return nil, fmt.Errorf("no valid certificates, CRLs or certificate requests found in PEM data")
 	}
 
-	return certificates, nil
-}
\ No newline at end of file
+	return bundle, nil
+}
@@ -0,0 +1,49 @@
+package cert
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+type pfxPDU struct {
+	Version  int
+	AuthSafe pkcs7ContentInfo
+	MacData  asn1.RawValue `asn1:"optional"`
+}
+
+func isPKCS12(data []byte) bool {
+	var pfx pfxPDU
+	if _, err := asn1.Unmarshal(data, &pfx); err != nil {
+		return false
+	}
+	return pfx.Version == 3
+}
+
+func parsePKCS12(data []byte, password string) (*Bundle, error) {
+	key, leaf, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err == nil {
+		return &Bundle{
+			Certificates: append([]*x509.Certificate{leaf}, caCerts...),
+			PrivateKey:   key,
+		}, nil
+	}
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) || errors.Is(err, pkcs12.ErrDecryption) {
+		if password == "" {
+			return nil, fmt.Errorf("PKCS#12 file is password protected, a password is required")
+		}
+		return nil, fmt.Errorf("incorrect PKCS#12 password")
+	}
+
+	// Keystores without a private key (e.g. Java trust stores) cannot be
+	// read by DecodeChain
+	certs, trustErr := pkcs12.DecodeTrustStore(data, password)
+	if trustErr == nil && len(certs) > 0 {
+		return &Bundle{Certificates: certs}, nil
+	}
+
+	return nil, fmt.Errorf("failed to decode PKCS#12 file: %v", err)
+}
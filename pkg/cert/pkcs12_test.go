@@ -0,0 +1,289 @@
+package cert
+
+import (
+	"crypto/dsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// PKCS#12 files written by OpenSSL 3.0.17 for a P-256 leaf
+// (www.example.com) issued by "PKCS12 Test CA", with the password
+// "secret" unless noted:
+//
+//	openssl pkcs12 -export -inkey leaf.key -in leaf.pem -certfile ca.pem
+//	openssl pkcs12 -export ... -certpbe PBE-SHA1-3DES -keypbe PBE-SHA1-3DES -macalg sha1
+//	openssl pkcs12 -export -inkey leaf.key -in leaf.pem -passout pass:
+const (
+	// ca.pem in DER
+	pkcs12TestCA = `
+MIIBmjCCAT+gAwIBAgIUW1nLMgvatudK+5Hq/P9ev4Qcw5QwCgYIKoZIzj0EAwIwGTEXMBUGA1UE
+AwwOUEtDUzEyIFRlc3QgQ0EwIBcNMjYxMDE4MDY0NjM5WhgPMjEyNjA5MjQwNjQ2MzlaMBkxFzAV
+BgNVBAMMDlBLQ1MxMiBUZXN0IENBMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEF8j4xD2AbAdc
+1VnJvNWJunYrXlHhpRdhbbwJN30Nrx1VVxANpOUX/xmBxIrGyAs0UwpexiUax4RuGIGMMb3NdqNj
+MGEwHQYDVR0OBBYEFCe4uoYaVnd4ja+4N8TeibIc4R9ZMB8GA1UdIwQYMBaAFCe4uoYaVnd4ja+4
+N8TeibIc4R9ZMA8GA1UdEwEB/wQFMAMBAf8wDgYDVR0PAQH/BAQDAgEGMAoGCCqGSM49BAMCA0kA
+MEYCIQCk2Ta18eLrQRM4Gl2iGdtH1dvksXMHZWxdZWYJcQZyqwIhALq5qKmrKk6QlzGd1ds6IV/d
+lI2hE8Oj42g+8IIYmXIy`
+
+	modernPKCS12 = `
+MIIGDAIBAzCCBcIGCSqGSIb3DQEHAaCCBbMEggWvMIIFqzCCBGIGCSqGSIb3DQEHBqCCBFMwggRP
+AgEAMIIESAYJKoZIhvcNAQcBMFcGCSqGSIb3DQEFDTBKMCkGCSqGSIb3DQEFDDAcBAhthkn91rRf
+EwICCAAwDAYIKoZIhvcNAgkFADAdBglghkgBZQMEASoEEDgT9yDnDh1fvNGfnjnBG7KAggPgWQdv
+1vnSx57Q41f+Ua1u1aV1HGvWVbkVZKA4SXXIZlN3EMmBnUP0ViRCEoqHdgsepCZT9qZm7oxveLx6
+R6I2CpA02LpOowwZWWCJZBcPE50yuAzQEundvGM5eFA+mSWhBWx0HI9QyLCC9PgEFvhkZ+oje4lF
+BCxw3GT6eJGr66wb9o2yxtEPgsYQjsbSo3FgOLyvj9+9pzY3U0B9AR1sEsouFkWODh4lfWYpbo3E
+FaJ/oMlh0kc/8HXE2esgpfgqDQT7eyTNHawkaPcP/2cNdWuWzBKpD+rjxjGqnF+p39lA143g0GAp
+Rjx8Yn3tt3sOrZR4o0etZa1OY8S4wTZ3AaQ+tI8KIwW9btd+cwNu38q+mwah/jeLF6G/tW7gqDow
+ILFR9rwZe1ni6X3AFCJaMW/C77EKYUFReCSH1bjkXUgdvyYrmMoNQJDInLHhlWWScR3kjNNaKSTL
+mhOEJydQ9XSNiOpAwcxoJpqfM0vaO4kyKwGP/scobe5tJqZOUnIHCo6q8L8trVBjfbkdc1FpClPa
+eIJDG59tZwraQvgGCGfnha5yoqYLk3F7otM/OzVMrkGLaPbStaWjJV5Y5aYKxsGuy2BD5alovhoF
+OPG/7NeUf842KAnMbNayt/NXsjHz0JYLaE4ZiLQph7rDjBG/4HgLMdiRxTTmeLeRiUTIGhOzPhqT
+2fRBTZkqiRCG5GwdIoutpWV+woN9PjCf+sQp57YHSSSp1/0yz/yNJyYZDkIh/E83Fzo6B2h+Vcew
+nm9idy43mSLbsamar8ziWoyW5wMQASlnMtF5MHKE3ZBlwqEAWHnsh4+T/1iSHixala26NzePrUJa
+tjiQDFhtSdZyj03FkEGq3NkKhU3oios9iIIfwrun/dEbbDU/53BbRYSQmrfUwAl5Yi1Hx6+Rfdse
+Dz9xBJ8bxgm/BJ/yy+ubcfph/C0/EiLPRcARtSYna4TOECfTRvZTWyVeBr0bE7qPcUn4wI6EtIxp
+dqmuadVBD8sN98CJm2uQJGVp9ypYCPOoHoNEKzaLCPvU8SBgWor5hzbyVneKv90cyHLSDKnONm50
+yOrZTd7uH99ia1iehBD3qt8XFExM/LHhRRsWT7QmCxAiiLNXYrVOmBd6oFUpLlI2XUYKm43IZ9fN
+x4PA3s3WwVb+BVBDG35A6Skuhlm5AFCJxWfV0cWzTWt5BWISZgB6yfDcQpHssFWRTh3T+a/TmbFU
+qc9ddEJobcCOstuRvjwyGJBcXbrVD7zbMAVIEbg29BhN444hLBvdnNRNTOpLDgO86RvZPoO14Aay
+/cPOFWzWXxTvy0vM4iX1OvWqAlwwggFBBgkqhkiG9w0BBwGgggEyBIIBLjCCASowggEmBgsqhkiG
+9w0BDAoBAqCB7zCB7DBXBgkqhkiG9w0BBQ0wSjApBgkqhkiG9w0BBQwwHAQI1oQX14jNNegCAggA
+MAwGCCqGSIb3DQIJBQAwHQYJYIZIAWUDBAEqBBCs60Be4jiaWgy+FJ1aaIRsBIGQ91pceQtKGMTk
+WgTy/t9OvfF3ANFUjyr8TwhkkU3SnKBRBLdlprUutYlOH/yGihQdzolRM4m8d7NllPhQKtJv14zL
+f6GpCSw1e6J/wSpcTDpkku5r7o7//QT8PI/DR+1p/55AszO2xW4qbTx7rs8DI/66mt5PVI6pzH55
+5Npi3Io6wFmeNpQeUhigAxOBiNyNMSUwIwYJKoZIhvcNAQkVMRYEFHh4Z6i3rRmTnLWRPZSyZVeU
+pabkMEEwMTANBglghkgBZQMEAgEFAAQg3hKoQtipVBRcV0ypGzLlyYRHRs8LCS0mzNi5xIM8r4ME
+CA043ZjrikV5AgIIAA==`
+
+	legacyPKCS12 = `
+MIIFegIBAzCCBUAGCSqGSIb3DQEHAaCCBTEEggUtMIIFKTCCBB8GCSqGSIb3DQEHBqCCBBAwggQM
+AgEAMIIEBQYJKoZIhvcNAQcBMBwGCiqGSIb3DQEMAQMwDgQIyyV+KZhQwfsCAggAgIID2A32mfVJ
+bzaMnDz7Ka5ZQLItqjzJ2jU5e/xblNW55uYXwtAKXjDLnVi5zwtCz2pOMpyV5ZT3Umo3qu89MKBy
+D5Xc/Dscc/zNushZURV09LsbC0VhumcxwEWFWX4nrgxn0lxJW14Uw5Qf0Wx6Hjnohkcds6soXbfs
+wcLXFU5qkBiav7HUTpnBsJutJ9u4Hi3M+RwaYQzw+sfai5sJjEtpNqGBWeqDypK0ZEo+4/e5Co7t
+IBQGDVCcjpWdJjiry/nfQ/LHfRSqZqGmF4T3QFU6HJB/SPHbAWR5KaihGr/aZEECHeLpWp5rdZYV
+nftYfsjjlYVtxW4yipND4KakSTW0lRNx9MDACq8vQS5Gj8p9YXy1gut2xnv2D8gAIpIyS2zjI9HB
+kga5ovjKaQwZda6N9N0S6EcoOd0i1zFL95NpL8qI5jjvhjGs5k5mtJKtrYxWvYyAs72AbmYHjdZL
+7/bHVeDb8HtbhBZ4zXpzuDWu+pzt+JRaRaTonwB4x5/9+zaP4wOKzEGiIW9evwyFOvt3oUM5JIpX
+7yhCCHkeIvtSBAZnl+MfvxG8+4RfVJQZ0+6nHov4gF3fn28u7Dn+FyDRKFqhaZza1H+GYVzHYZfS
+LY4mQyJ3GgVXwNAYPnx3vsqJo1hwgW4xcaqwJbdpIU4z1/mmRBSl6r/TS/C9l/8uv9IP8JAY3+Ap
+X4tf38c9o76oRDoulITxlJprC3QqLZLnqjjl4dVZYd/7//MS//LAXFhzgpop9Tu3DPZuTb4GGNiz
+u5t7rKqgYRjuxWQmVDuslf020moK47i9QdxKiBat7ucn6fbRkbNnjBbNOd9XKxzb7uRr3fgsmVWT
+uYro9egb0x7PMEQV9SGr1/b1L2fQSZC1zlCrdb0INa45hB3WHLygkRTwtqd0If7AQh2LZRiWKu4T
+zXJerWGBGeEXkFPZ1gk2JqTrGDORlqWAp+4kzbESEMi8G1H4k5jXlB3kOwuRPZQtGRC/S0BBPCre
+qBfvyy+wTC8c5OmadTsq6XK0Zg6iTTEreunYA/xRF3a0Omvk5yiavefPnlKV7MQVSWCcHFpa1hGa
+c0eCHh50nBYpreApPkmt/RGL/HHoyUcjMs/IAJ/SNNYFW5THpC3d9h1t5huLB8O5sihE+oG5Mmyh
+D2QAU9BNL3p2njk7Iw2o+kxuM1SAikNuPgX6H11NQZB8igx4IVfVUtqm5PxsL6AOdhzTzlYHLUJA
+VcRPLpF80jFoORtIyPRTFC6jtyM7m5PJqLDOOIWO6ikY1rQ6etypLUkSt/FtQOn7ScruSDiykzHk
+b7WzJu1wDWiueDCCAQIGCSqGSIb3DQEHAaCB9ASB8TCB7jCB6wYLKoZIhvcNAQwKAQKggbQwgbEw
+HAYKKoZIhvcNAQwBAzAOBAjHph/BoYCBmQICCAAEgZBh8OzuXW6q8BNzSHeZZCwPiso3vucmsLzq
+vmmoxN5fqJ2n+MwtrfL9+omqQVZN5ORpDQmAzdsLicp8drsfENaWnqN6bEUbpgMmMj55waNVUq3X
+rnuGdRKM4Ev5sw2pP5lfdjZ7wrQBbfDCnGI7aT7YbgQBF/kZylZapFzmFV/BuFKTv6SvMexGFTF7
+OiGBjFExJTAjBgkqhkiG9w0BCRUxFgQUeHhnqLetGZOctZE9lLJlV5SlpuQwMTAhMAkGBSsOAwIa
+BQAEFInetKgaWAQ8FQG692d55E1gmtcLBAgZCYAGT2AyIwICCAA=`
+
+	noPasswordPKCS12 = `
+MIIEPAIBAzCCA/IGCSqGSIb3DQEHAaCCA+MEggPfMIID2zCCApIGCSqGSIb3DQEHBqCCAoMwggJ/
+AgEAMIICeAYJKoZIhvcNAQcBMFcGCSqGSIb3DQEFDTBKMCkGCSqGSIb3DQEFDDAcBAgiVJAfUm0N
+ZwICCAAwDAYIKoZIhvcNAgkFADAdBglghkgBZQMEASoEEB40CgyntCpNXO9ChceX+jOAggIQZXtz
+pBwfhY6hhXr6DNVnrKMXnQhp0QC4WiT3Z5cF19EdJOZjrm6NpeOELuDUZVnMUT2cYyJ8CsrPF4Un
+MqZrbv8p4g9dosAVTwd5rNerhpcyrCcOMF2s+c1L5YJfoKqHLEIcNWJnznoF7oDZ3Y6fs8+xyYqK
+G0irwqZyi5BY4x2uvxsJjMLUFhdeyFh03P+uQuf4Shz49GOdEBk+9MxGjp9fIE/U2im7ihohKqtl
+5+ZhYSDOdTB+LHvh5cAF2nTxf8z5Rg5B31yEFB6QPJH7aat7isE2Iri+hm9KH0yj3To0G15k2WqZ
+fv0fKYNq7CoIJmnIX031kqIk5uClMRk79wm4W8pvYcCSCp8RvaqN0TSQbtvB8FMJcbwAVOyQg1iw
+HwvgfU0DZ9Bf6Gkm9sHW056TKB4SlVMEsM6HqHksDQ2MlHeYEp4+lGt1pobhjgR+8z1kl1DULxm1
+klKSjHCbRxWYQ0aygOsBmDxAz1kJ0ntadnGmNfCpxySMoijE12RBrDGViRnRoMAGCRZWg+qScuIB
+Vwhob6NrDPVVotXiA5odONv32gOq1RmzYT8p6ugucaDfRANLwYyxKAMXJ5HBX9GoO1iA6rUxtoJh
+NlyxJUD6oXrqlA5DOX2l4P221HRlB9Lqa+ua8uPRSl0fZS/s15wTiQTvUAMbKtHHXzIPwYbrm2oT
+NQvYqkrehaoeR3QvMIIBQQYJKoZIhvcNAQcBoIIBMgSCAS4wggEqMIIBJgYLKoZIhvcNAQwKAQKg
+ge8wgewwVwYJKoZIhvcNAQUNMEowKQYJKoZIhvcNAQUMMBwECAnc/e1KFY4zAgIIADAMBggqhkiG
+9w0CCQUAMB0GCWCGSAFlAwQBKgQQ8wjpQ2jQBqr94/WwdNStzwSBkM1RULuHMO1J0Zbsk0mp6ujg
+C2jktGeo/znWUWM/aBZYq95DZ8fwaohBpIdQe++j3xBhG8O8wd6tKmygCqbA+ndLivPHxyu69Osg
+QrtzmOMrA9BAAEi8PiOsNoy+B/Efxu1cx54/WUvyPOQDTj4EUTceydPMf7BCoKnjMHwTkJEtuNtk
+I0suDS8gobTPxD+mkjElMCMGCSqGSIb3DQEJFTEWBBR4eGeot60Zk5y1kT2UsmVXlKWm5DBBMDEw
+DQYJYIZIAWUDBAIBBQAEING5tD6CFPwRzpVLHHy2GHvD18hBiQRvTgSkGo2/UxZ0BAjlD6yQBC80
+QAICCAA=`
+)
+
+func decodeFixture(t *testing.T, data string) []byte {
+	t.Helper()
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestParsePKCS12(t *testing.T) {
+	modern := decodeFixture(t, modernPKCS12)
+	legacy := decodeFixture(t, legacyPKCS12)
+	// OpenSSL 3.0 cannot mark certificates as trusted for Java, which
+	// DecodeTrustStore requires
+	ca, err := x509.ParseCertificate(decodeFixture(t, pkcs12TestCA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trustStore, err := pkcs12.Modern.EncodeTrustStore([]*x509.Certificate{ca}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		password  string
+		wantCerts []string
+		wantKey   bool
+		wantError string
+	}{
+		{
+			name:      "AES-256 and PBKDF2",
+			data:      modern,
+			password:  "secret",
+			wantCerts: []string{"www.example.com", "PKCS12 Test CA"},
+			wantKey:   true,
+		},
+		{
+			name:      "3DES and SHA-1",
+			data:      legacy,
+			password:  "secret",
+			wantCerts: []string{"www.example.com", "PKCS12 Test CA"},
+			wantKey:   true,
+		},
+		{name: "trust store without key", data: trustStore, password: "secret", wantCerts: []string{"PKCS12 Test CA"}},
+		{name: "empty password", data: decodeFixture(t, noPasswordPKCS12), wantCerts: []string{"www.example.com"}, wantKey: true},
+		{name: "wrong password", data: modern, password: "Secret", wantError: "incorrect PKCS#12 password"},
+		{name: "legacy wrong password", data: legacy, password: "Secret", wantError: "incorrect PKCS#12 password"},
+		{name: "missing password", data: modern, wantError: "a password is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !isPKCS12(tt.data) {
+				t.Fatal("not recognized as PKCS#12")
+			}
+			bundle, err := ParseBundleData(tt.data, tt.password)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("error %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, cert := range bundle.Certificates {
+				got = append(got, cert.Subject.CommonName)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantCerts, ",") {
+				t.Errorf("certificates %q, want %q", got, tt.wantCerts)
+			}
+			if (bundle.PrivateKey != nil) != tt.wantKey {
+				t.Fatalf("private key %T, want one: %v", bundle.PrivateKey, tt.wantKey)
+			}
+			if !tt.wantKey {
+				return
+			}
+
+			// The key view must describe the key like the certificate view
+			info := analyzePrivateKey(bundle.PrivateKey, bundle.Certificates, []int{0})
+			if !info.MatchesLeaf || info.CertificateIndex != 0 {
+				t.Errorf("key matches certificate %d, leaf %v", info.CertificateIndex, info.MatchesLeaf)
+			}
+			if want := analyzePublicKey(bundle.Certificates[0]); info.PublicKey != want {
+				t.Errorf("key described as %+v, certificate as %+v", info.PublicKey, want)
+			}
+		})
+	}
+}
+
+func TestAnalyzePrivateKey(t *testing.T) {
+	root := newTestRoot(t, "Key Test Root")
+	leaf := newTestLeaf(t, "www.example.com", root)
+	unmatched := newTestLeaf(t, "other.example.com", root)
+	rsaKey, err := ParsePrivateKeyData([]byte(rsaPKCS1PEM), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dsaKey := new(dsa.PrivateKey)
+	if err := dsa.GenerateParameters(&dsaKey.Parameters, rand.Reader, dsa.L1024N160); err != nil {
+		t.Fatal(err)
+	}
+	if err := dsa.GenerateKey(dsaKey, rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := marshalPublicKey(&dsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err := x509.ParsePKIXPublicKey(raw); err != nil || !samePublicKey(parsed, &dsaKey.PublicKey) {
+		t.Fatalf("DSA SPKI does not round-trip: %v", err)
+	}
+	certs := []*x509.Certificate{leaf.cert, root.cert}
+
+	tests := []struct {
+		name      string
+		key       any
+		wantIndex int
+		wantLeaf  bool
+		want      PublicKeyDetails // an empty SPKISHA256 is only checked to be set
+	}{
+		{
+			name:      "leaf key",
+			key:       leaf.key,
+			wantIndex: 0,
+			wantLeaf:  true,
+			want:      analyzePublicKey(leaf.cert),
+		},
+		{
+			name:      "root key",
+			key:       root.key,
+			wantIndex: 1,
+			want:      analyzePublicKey(root.cert),
+		},
+		{
+			name:      "unmatched EC key",
+			key:       unmatched.key,
+			wantIndex: -1,
+			want:      analyzePublicKey(unmatched.cert),
+		},
+		{
+			name:      "unmatched RSA key",
+			key:       rsaKey,
+			wantIndex: -1,
+			want:      PublicKeyDetails{Algorithm: "RSA", Size: 1024, Exponent: 65537},
+		},
+		{
+			name:      "DSA key",
+			key:       dsaKey,
+			wantIndex: -1,
+			want:      PublicKeyDetails{Algorithm: "DSA", Size: 1024},
+		},
+		{name: "not a key", key: "secret", wantIndex: -1, want: PublicKeyDetails{Algorithm: "Unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := analyzePrivateKey(tt.key, certs, []int{0})
+			if info.CertificateIndex != tt.wantIndex || info.MatchesLeaf != tt.wantLeaf {
+				t.Errorf("matches certificate %d (leaf %v), want %d (leaf %v)", info.CertificateIndex, info.MatchesLeaf, tt.wantIndex, tt.wantLeaf)
+			}
+			got := info.PublicKey
+			if tt.want.SPKISHA256 == "" && tt.want.Algorithm != "Unknown" {
+				if got.SPKISHA256 == "" {
+					t.Error("no SPKI pin")
+				}
+				got.SPKISHA256 = ""
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
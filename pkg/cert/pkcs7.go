@@ -0,0 +1,93 @@
+package cert
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+)
+
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// Only the certificate and CRL sets are used; the signer infos of a
+// degenerate (certs-only) bundle are empty and are not verified.
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+func isPKCS7(data []byte) bool {
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return false
+	}
+	return info.ContentType.Equal(oidSignedData)
+}
+
+func parsePKCS7(data []byte) (*Bundle, error) {
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#7 content info: %v", err)
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unsupported PKCS#7 content type %s", info.ContentType)
+	}
+
+	var signedData pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signedData); err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#7 signed data: %v", err)
+	}
+
+	bundle := &Bundle{}
+
+	rest := signedData.Certificates.Bytes
+	for len(rest) > 0 {
+		var raw asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PKCS#7 certificate set: %v", err)
+		}
+		// Skip attribute certificates and other non-X.509 choices
+		if raw.Class != asn1.ClassUniversal || raw.Tag != asn1.TagSequence {
+			continue
+		}
+		cert, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PKCS#7 certificate: %v", err)
+		}
+		bundle.Certificates = append(bundle.Certificates, cert)
+	}
+
+	rest = signedData.CRLs.Bytes
+	for len(rest) > 0 {
+		var raw asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PKCS#7 CRL set: %v", err)
+		}
+		if raw.Class != asn1.ClassUniversal || raw.Tag != asn1.TagSequence {
+			continue
+		}
+		crl, err := x509.ParseRevocationList(raw.FullBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PKCS#7 CRL: %v", err)
+		}
+		bundle.CRLs = append(bundle.CRLs, crl)
+	}
+
+	if len(bundle.Certificates) == 0 && len(bundle.CRLs) == 0 {
+		return nil, fmt.Errorf("PKCS#7 bundle contains no certificates or CRLs")
+	}
+
+	return bundle, nil
+}
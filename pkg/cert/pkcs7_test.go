@@ -0,0 +1,158 @@
+package cert
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+// encodePKCS7 wraps the certificates and CRLs in a degenerate SignedData
+// bundle, as produced by openssl crl2pkcs7. Nil sets are left out.
+func encodePKCS7(t *testing.T, contentType string, certs, crls [][]byte) []byte {
+	t.Helper()
+	fields := [][]byte{
+		{0x02, 0x01, 0x01}, // version
+		der(asn1.ClassUniversal, asn1.TagSet, true),
+		derSequence(derOID(t, "1.2.840.113549.1.7.1")),
+	}
+	if certs != nil {
+		fields = append(fields, derContext(0, true, certs...))
+	}
+	if crls != nil {
+		fields = append(fields, derContext(1, true, crls...))
+	}
+	fields = append(fields, der(asn1.ClassUniversal, asn1.TagSet, true))
+	return derSequence(derOID(t, contentType), derContext(0, true, derSequence(fields...)))
+}
+
+func TestParsePKCS7(t *testing.T) {
+	const signedData = "1.2.840.113549.1.7.2"
+	root := newTestRoot(t, "PKCS7 Test Root")
+	leaf := newTestLeaf(t, "www.example.com", root)
+	crl := testCRL{number: 1, revoked: -1}.create(t, root, leaf.cert)
+	attributeCert := derContext(2, true, derSequence())
+
+	tests := []struct {
+		name      string
+		data      []byte
+		wantCerts []string
+		wantCRLs  int
+		wantError string
+	}{
+		{
+			name:      "certificates",
+			data:      encodePKCS7(t, signedData, [][]byte{leaf.cert.Raw, root.cert.Raw}, nil),
+			wantCerts: []string{"www.example.com", "PKCS7 Test Root"},
+		},
+		{
+			name:      "certificates and CRL",
+			data:      encodePKCS7(t, signedData, [][]byte{leaf.cert.Raw}, [][]byte{crl.Raw}),
+			wantCerts: []string{"www.example.com"},
+			wantCRLs:  1,
+		},
+		{
+			name:     "CRL only",
+			data:     encodePKCS7(t, signedData, nil, [][]byte{crl.Raw}),
+			wantCRLs: 1,
+		},
+		{
+			name:      "attribute certificate skipped",
+			data:      encodePKCS7(t, signedData, [][]byte{attributeCert, leaf.cert.Raw}, nil),
+			wantCerts: []string{"www.example.com"},
+		},
+		{
+			name:      "empty",
+			data:      encodePKCS7(t, signedData, [][]byte{}, nil),
+			wantError: "contains no certificates or CRLs",
+		},
+		{
+			name:      "unsupported content type",
+			data:      encodePKCS7(t, "1.2.840.113549.1.7.3", [][]byte{leaf.cert.Raw}, nil),
+			wantError: "unsupported PKCS#7 content type 1.2.840.113549.1.7.3",
+		},
+		{
+			name:      "malformed certificate",
+			data:      encodePKCS7(t, signedData, [][]byte{derSequence([]byte{0x02, 0x01, 0x00})}, nil),
+			wantError: "failed to parse PKCS#7 certificate",
+		},
+		{
+			name:      "malformed CRL",
+			data:      encodePKCS7(t, signedData, nil, [][]byte{derSequence([]byte{0x02, 0x01, 0x00})}),
+			wantError: "failed to parse PKCS#7 CRL",
+		},
+		{
+			name:      "truncated certificate set",
+			data:      encodePKCS7(t, signedData, [][]byte{leaf.cert.Raw[:8]}, nil),
+			wantError: "failed to parse PKCS#7 certificate set",
+		},
+		{
+			name:      "malformed signed data",
+			data:      derSequence(derOID(t, signedData), derContext(0, true, derSequence([]byte{0x05, 0x00}))),
+			wantError: "failed to parse PKCS#7 signed data",
+		},
+		{
+			name:      "not ASN.1",
+			data:      []byte("not a bundle"),
+			wantError: "failed to parse PKCS#7 content info",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := parsePKCS7(tt.data)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("error %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, cert := range bundle.Certificates {
+				got = append(got, cert.Subject.CommonName)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantCerts, ",") {
+				t.Errorf("certificates %q, want %q", got, tt.wantCerts)
+			}
+			if len(bundle.CRLs) != tt.wantCRLs {
+				t.Errorf("got %d CRLs, want %d", len(bundle.CRLs), tt.wantCRLs)
+			}
+		})
+	}
+}
+
+func TestParseBundleDataPKCS7(t *testing.T) {
+	root := newTestRoot(t, "PKCS7 Test Root")
+	leaf := newTestLeaf(t, "www.example.com", root)
+	crl := testCRL{number: 1, revoked: -1}.create(t, root, leaf.cert)
+	p7 := encodePKCS7(t, "1.2.840.113549.1.7.2", [][]byte{leaf.cert.Raw, root.cert.Raw}, [][]byte{crl.Raw})
+
+	if !isPKCS7(p7) || isPKCS7(leaf.cert.Raw) {
+		t.Fatal("isPKCS7 does not tell the bundle from a certificate")
+	}
+	for _, label := range []string{"PKCS7", "CMS"} {
+		t.Run(label, func(t *testing.T) {
+			data := pem.EncodeToMemory(&pem.Block{Type: label, Bytes: p7})
+			data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.cert.Raw})...)
+			bundle, err := ParseBundleData(data, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(bundle.Certificates) != 3 || len(bundle.CRLs) != 1 {
+				t.Errorf("got %d certificates and %d CRLs, want 3 and 1", len(bundle.Certificates), len(bundle.CRLs))
+			}
+		})
+	}
+	t.Run("DER", func(t *testing.T) {
+		bundle, err := ParseBundleData(p7, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bundle.Certificates) != 2 || len(bundle.CRLs) != 1 {
+			t.Errorf("got %d certificates and %d CRLs, want 2 and 1", len(bundle.Certificates), len(bundle.CRLs))
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package cert
+
+import (
+	"crypto/dsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Check statuses, numbered as Nagios plugin exit codes.
+const (
+	StatusOK       = 0
+	StatusWarning  = 1
+	StatusCritical = 2
+	StatusUnknown  = 3
+)
+
+var statusNames = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+func StatusName(status int) string {
+	if status < 0 || status >= len(statusNames) {
+		return statusNames[StatusUnknown]
+	}
+	return statusNames[status]
+}
+
+// Policy holds the thresholds a chain is checked against. Zero values
+// disable a check.
+type Policy struct {
+	WarnDays          int // warn when a certificate expires within this many days
+	CritDays          int
+	RequireValidChain bool
+	MinKeyBits        int // minimum RSA and DSA key size
+	// DisallowedSignatureAlgorithms are matched case-insensitively against
+	// the algorithm name with dashes removed, so "sha1" matches SHA1-RSA
+	// and ECDSA-SHA1.
+	DisallowedSignatureAlgorithms []string
+}
+
+type CheckProblem struct {
+	Status  int
+	Message string
+}
+
+type CheckResult struct {
+	Status   int
+	Problems []CheckProblem
+	// Expiry and DaysLeft describe the certificate that expires first
+	// among the leaf and its path (see servedPathCertificates).
+	Expiry      time.Time
+	DaysLeft    int
+	Certificate int
+}
+
+func (r *CheckResult) add(status int, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, CheckProblem{Status: status, Message: fmt.Sprintf(format, args...)})
+	if status > r.Status {
+		r.Status = status
+	}
+}
+
+// Evaluate checks the analyzed chain against the policy.
+func (p Policy) Evaluate(chain *ChainInfo, now time.Time) *CheckResult {
+	result := &CheckResult{Certificate: -1}
+	if len(chain.Certificates) == 0 {
+		result.add(StatusUnknown, "no certificates")
+		return result
+	}
+
+	for _, i := range servedPathCertificates(chain) {
+		if info := chain.Certificates[i]; result.Certificate < 0 || info.NotAfter.Before(result.Expiry) {
+			result.Certificate, result.Expiry = i, info.NotAfter
+		}
+	}
+	result.DaysLeft = int(math.Floor(result.Expiry.Sub(now).Hours() / 24))
+	first := chain.Certificates[result.Certificate]
+	switch {
+	case !now.Before(result.Expiry):
+		result.add(StatusCritical, "certificate %d (%s) expired %d day(s) ago", result.Certificate+1, first.Subject, -result.DaysLeft)
+	case p.CritDays > 0 && result.DaysLeft < p.CritDays:
+		result.add(StatusCritical, "certificate %d (%s) expires in %d day(s)", result.Certificate+1, first.Subject, result.DaysLeft)
+	case p.WarnDays > 0 && result.DaysLeft < p.WarnDays:
+		result.add(StatusWarning, "certificate %d (%s) expires in %d day(s)", result.Certificate+1, first.Subject, result.DaysLeft)
+	}
+
+	if p.RequireValidChain && !chain.IsValid {
+		message := "chain is not valid"
+		if len(chain.Errors) > 0 {
+			message += ": " + chain.Errors[0]
+		}
+		result.add(StatusCritical, "%s", message)
+	}
+
+	if h := chain.Hostname; h != nil {
+		if !h.Matched {
+			result.add(StatusCritical, "certificate is not valid for %s", h.Host)
+		} else if h.Deprecated {
+			result.add(StatusWarning, "%s matches only the Subject Common Name", h.Host)
+		}
+	}
+
+	if key := chain.PrivateKey; key != nil && !key.MatchesLeaf {
+		if key.CertificateIndex >= 0 {
+			result.add(StatusCritical, "private key matches certificate %d, not the leaf", key.CertificateIndex+1)
+		} else {
+			result.add(StatusCritical, "private key does not match any certificate")
+		}
+	}
+
+	revoked := make(map[int]bool)
+	for _, info := range chain.OCSP {
+		if info.Status == "revoked" && info.SignatureValid && !revoked[info.Certificate] {
+			revoked[info.Certificate] = true
+			result.add(StatusCritical, "certificate %d is revoked (%s)", info.Certificate+1, info.Reason)
+		}
+	}
+	for _, check := range chain.CRLChecks {
+		if check.Status == "revoked" && !revoked[check.Certificate] {
+			revoked[check.Certificate] = true
+			result.add(StatusCritical, "certificate %d is revoked (%s)", check.Certificate+1, check.Reason)
+		}
+	}
+
+	for i, info := range chain.Certificates {
+		cert := info.Certificate
+		if p.MinKeyBits > 0 {
+			bits := 0
+			switch key := cert.PublicKey.(type) {
+			case *rsa.PublicKey:
+				bits = key.N.BitLen()
+			case *dsa.PublicKey:
+				bits = key.P.BitLen()
+			}
+			if bits > 0 && bits < p.MinKeyBits {
+				result.add(StatusCritical, "certificate %d has a %d-bit %s key", i+1, bits, info.PublicKey.Algorithm)
+			}
+		}
+
+		// A root's self-signature is not relied upon
+		if isSelfSigned(cert) {
+			continue
+		}
+		algorithm := strings.ToLower(strings.ReplaceAll(info.SignatureAlg, "-", ""))
+		for _, disallowed := range p.DisallowedSignatureAlgorithms {
+			token := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(disallowed), "-", ""))
+			if token != "" && strings.Contains(algorithm, token) {
+				result.add(StatusCritical, "certificate %d is signed with %s", i+1, info.SignatureAlg)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// servedPathCertificates returns the indexes of the certificates whose
+// expiry matters: each leaf and the input certificates on its verified
+// path, or on its issuer chain when it does not verify. Extra cross-signs
+// and legacy roots in the input that no path uses are left out. When a
+// leaf verifies through several paths, the one that stays valid longest
+// is used.
+func servedPathCertificates(chain *ChainInfo) []int {
+	certs := make([]*x509.Certificate, len(chain.Certificates))
+	for i, info := range chain.Certificates {
+		certs[i] = info.Certificate
+	}
+	index := func(cert *x509.Certificate) int {
+		for i, c := range certs {
+			if c.Equal(cert) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	leaves := chain.Leaves
+	if len(leaves) == 0 {
+		leaves = []int{0}
+	}
+
+	var graph *issuerGraph
+	seen := make(map[int]bool)
+	var result []int
+	for _, leaf := range leaves {
+		var best []int
+		var bestExpiry time.Time
+		if chain.Trust != nil {
+			for _, path := range chain.Trust.Paths {
+				if len(path.Path) == 0 || !path.Path[0].Certificate.Equal(certs[leaf]) {
+					continue
+				}
+				var indexes []int
+				var expiry time.Time
+				for _, info := range path.Path {
+					i := index(info.Certificate)
+					if i < 0 {
+						continue // anchor from the trust store
+					}
+					indexes = append(indexes, i)
+					if expiry.IsZero() || info.NotAfter.Before(expiry) {
+						expiry = info.NotAfter
+					}
+				}
+				if best == nil || expiry.After(bestExpiry) {
+					best, bestExpiry = indexes, expiry
+				}
+			}
+		}
+		if best == nil {
+			if graph == nil {
+				graph = buildIssuerGraph(certs)
+			}
+			best = graph.primaryPath(leaf)
+		}
+		for _, i := range best {
+			if !seen[i] {
+				seen[i] = true
+				result = append(result, i)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPolicyEvaluateExpiry(t *testing.T) {
+	now := time.Now()
+	days := func(n int) time.Time { return now.Add(time.Duration(n) * 24 * time.Hour) }
+	validity := func(notAfter time.Time) (time.Time, time.Time) { return days(-365), notAfter }
+
+	ca := func(name string, notAfter time.Time, parent *testCA) *testCA {
+		notBefore, notAfter := validity(notAfter)
+		return newTestCert(t, &x509.Certificate{
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             notBefore,
+			NotAfter:              notAfter,
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		}, parent)
+	}
+	leaf := func(parent *testCA, notAfter time.Time) *testCA {
+		notBefore, notAfter := validity(notAfter)
+		return newTestCert(t, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: "policy.example.com"},
+			DNSNames:    []string{"policy.example.com"},
+			NotBefore:   notBefore,
+			NotAfter:    notAfter,
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}, parent)
+	}
+
+	root := ca("Policy Root", days(3650), nil)
+	oldRoot := ca("Legacy Root", days(-10), nil)
+	intermediate := ca("Policy Intermediate", days(1000), root)
+	expiredCross := newTestCrossSign(t, intermediate, oldRoot, days(-400), days(-5))
+	shortIntermediate := ca("Short Intermediate", days(3), root)
+	server := leaf(intermediate, days(90))
+
+	tests := []struct {
+		name        string
+		chain       []*testCA
+		store       *TrustStore
+		wantStatus  int
+		wantCert    int
+		wantMessage string
+	}{
+		{
+			name:       "verified path",
+			chain:      []*testCA{server, intermediate, root},
+			store:      newTestStore(root),
+			wantStatus: StatusOK,
+			wantCert:   0,
+		},
+		{
+			name:       "expired legacy root not on the path",
+			chain:      []*testCA{server, intermediate, root, oldRoot},
+			store:      newTestStore(root),
+			wantStatus: StatusOK,
+			wantCert:   0,
+		},
+		{
+			name:       "expired cross-sign not on the path",
+			chain:      []*testCA{server, intermediate, expiredCross, oldRoot},
+			store:      newTestStore(root),
+			wantStatus: StatusOK,
+			wantCert:   0,
+		},
+		{
+			name:        "unverified chain falls back to the issuer chain",
+			chain:       []*testCA{leaf(shortIntermediate, days(90)), shortIntermediate, oldRoot},
+			store:       newTestStore(),
+			wantStatus:  StatusCritical,
+			wantCert:    1,
+			wantMessage: "certificate 2 (CN=Short Intermediate) expires in",
+		},
+		{
+			name:        "expired leaf",
+			chain:       []*testCA{leaf(intermediate, days(-1)), intermediate, root},
+			store:       newTestStore(root),
+			wantStatus:  StatusCritical,
+			wantCert:    0,
+			wantMessage: "certificate 1 (CN=policy.example.com) expired",
+		},
+		{
+			name:        "intermediate on the verified path expiring soon",
+			chain:       []*testCA{leaf(shortIntermediate, days(90)), shortIntermediate, root},
+			store:       newTestStore(root),
+			wantStatus:  StatusCritical,
+			wantCert:    1,
+			wantMessage: "certificate 2 (CN=Short Intermediate) expires in",
+		},
+	}
+
+	policy := Policy{WarnDays: 30, CritDays: 7}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var certs []*x509.Certificate
+			for _, c := range tt.chain {
+				certs = append(certs, c.cert)
+			}
+			chain := AnalyzeCertificateChainWithOptions(certs, AnalyzeOptions{TrustStore: tt.store})
+			result := policy.Evaluate(chain, now)
+
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s (problems %+v)", StatusName(result.Status), StatusName(tt.wantStatus), result.Problems)
+			}
+			if result.Certificate != tt.wantCert {
+				t.Errorf("expiry reported for certificate %d, want %d", result.Certificate, tt.wantCert)
+			}
+			if tt.wantMessage != "" {
+				found := false
+				for _, problem := range result.Problems {
+					found = found || strings.Contains(problem.Message, tt.wantMessage)
+				}
+				if !found {
+					t.Errorf("problems %+v, want one containing %q", result.Problems, tt.wantMessage)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA224 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+var errKeyPassword = errors.New("incorrect private key password")
+
+// Limits on the key derivation parameters of an encrypted key, which come
+// from the key itself. They are well above what OpenSSL writes (2048
+// PBKDF2 iterations, 16 MiB for scrypt) but keep an uploaded key from
+// tying up the server.
+const (
+	maxPBKDF2Iterations  = 1 << 20
+	maxScryptMemory      = 64 << 20 // N * r * 128 bytes
+	maxScryptParallelism = 4
+)
+
+// encryptedPrivateKeyInfo is the PKCS#8 ENCRYPTED PRIVATE KEY structure.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"` // default hmacWithSHA1
+}
+
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+// ParsePrivateKeyFile reads a PEM private key. The password is only used
+// when the key is encrypted.
+func ParsePrivateKeyFile(filename, password string) (crypto.PrivateKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	return ParsePrivateKeyData(data, password)
+}
+
+// ParsePrivateKeyData returns the first private key in PEM data: PKCS#1,
+// SEC 1 or PKCS#8, encrypted with PBES2 or with the legacy OpenSSL
+// Proc-Type header. Other blocks, such as certificates, are skipped.
+func ParsePrivateKeyData(data []byte, password string) (crypto.PrivateKey, error) {
+	block, rest := pem.Decode(data)
+	for block != nil {
+		switch block.Type {
+		case "RSA PRIVATE KEY", "EC PRIVATE KEY", "PRIVATE KEY", "ENCRYPTED PRIVATE KEY":
+			return parsePrivateKeyBlock(block, password)
+		}
+		block, rest = pem.Decode(rest)
+	}
+
+	return nil, fmt.Errorf("no private key found in PEM data")
+}
+
+func parsePrivateKeyBlock(block *pem.Block, password string) (crypto.PrivateKey, error) {
+	der := block.Bytes
+	encrypted := block.Type == "ENCRYPTED PRIVATE KEY" || x509.IsEncryptedPEMBlock(block)
+	if encrypted && password == "" {
+		return nil, fmt.Errorf("private key is encrypted, a password is required")
+	}
+
+	var err error
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		der, err = decryptPKCS8(der, []byte(password))
+	} else if encrypted {
+		der, err = x509.DecryptPEMBlock(block, []byte(password))
+		if errors.Is(err, x509.IncorrectPasswordError) {
+			err = errKeyPassword
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var key crypto.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(der)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(der)
+	}
+	if err != nil {
+		// CBC padding only catches most wrong passwords
+		if encrypted {
+			return nil, errKeyPassword
+		}
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	return key, nil
+}
+
+// decryptPKCS8 decrypts a PBES2 EncryptedPrivateKeyInfo (RFC 8018) with
+// PBKDF2 or scrypt and AES or 3DES in CBC mode, as written by OpenSSL.
+func decryptPKCS8(der, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted private key: %v", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported private key encryption %s, only PBES2 is supported", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse PBES2 parameters: %v", err)
+	}
+
+	var newCipher func([]byte) (cipher.Block, error)
+	var keyLen int
+	switch scheme := params.EncryptionScheme.Algorithm; {
+	case scheme.Equal(oidAES128CBC):
+		newCipher, keyLen = aes.NewCipher, 16
+	case scheme.Equal(oidAES192CBC):
+		newCipher, keyLen = aes.NewCipher, 24
+	case scheme.Equal(oidAES256CBC):
+		newCipher, keyLen = aes.NewCipher, 32
+	case scheme.Equal(oidDESEDE3CBC):
+		newCipher, keyLen = des.NewTripleDESCipher, 24
+	default:
+		return nil, fmt.Errorf("unsupported private key cipher %s", scheme)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("failed to parse cipher IV: %v", err)
+	}
+
+	key, err := deriveKey(params.KeyDerivationFunc, password, keyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	size := block.BlockSize()
+	data := info.EncryptedData
+	if len(iv) != size || len(data) == 0 || len(data)%size != 0 {
+		return nil, fmt.Errorf("malformed encrypted private key")
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > size {
+		return nil, errKeyPassword
+	}
+	for _, b := range plain[len(plain)-padding:] {
+		if int(b) != padding {
+			return nil, errKeyPassword
+		}
+	}
+	return plain[:len(plain)-padding], nil
+}
+
+func deriveKey(kdf pkix.AlgorithmIdentifier, password []byte, keyLen int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("failed to parse PBKDF2 parameters: %v", err)
+		}
+		var h func() hash.Hash
+		switch prf := params.PRF.Algorithm; {
+		case len(prf) == 0, prf.Equal(oidHMACWithSHA1):
+			h = sha1.New
+		case prf.Equal(oidHMACWithSHA224):
+			h = sha256.New224
+		case prf.Equal(oidHMACWithSHA256):
+			h = sha256.New
+		case prf.Equal(oidHMACWithSHA384):
+			h = sha512.New384
+		case prf.Equal(oidHMACWithSHA512):
+			h = sha512.New
+		default:
+			return nil, fmt.Errorf("unsupported PBKDF2 PRF %s", prf)
+		}
+		if params.IterationCount < 1 || params.IterationCount > maxPBKDF2Iterations {
+			return nil, fmt.Errorf("unsupported PBKDF2 iteration count %d, the limit is %d", params.IterationCount, maxPBKDF2Iterations)
+		}
+		if params.KeyLength != 0 && params.KeyLength != keyLen {
+			return nil, fmt.Errorf("PBKDF2 key length %d does not match the cipher's %d", params.KeyLength, keyLen)
+		}
+		return pbkdf2.Key(h, string(password), params.Salt, params.IterationCount, keyLen)
+
+	case kdf.Algorithm.Equal(oidScrypt):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("failed to parse scrypt parameters: %v", err)
+		}
+		n, r, p := params.CostParameter, params.BlockSize, params.ParallelizationParameter
+		if n < 2 || n&(n-1) != 0 || r < 1 || p < 1 {
+			return nil, fmt.Errorf("invalid scrypt parameters N=%d r=%d p=%d", n, r, p)
+		}
+		if r > maxScryptMemory/128 || n > maxScryptMemory/128/r {
+			return nil, fmt.Errorf("scrypt parameters N=%d r=%d need more than the %d MiB limit", n, r, maxScryptMemory>>20)
+		}
+		if p > maxScryptParallelism {
+			return nil, fmt.Errorf("unsupported scrypt parallelization %d, the limit is %d", p, maxScryptParallelism)
+		}
+		if params.KeyLength != 0 && params.KeyLength != keyLen {
+			return nil, fmt.Errorf("scrypt key length %d does not match the cipher's %d", params.KeyLength, keyLen)
+		}
+		return scrypt.Key(password, params.Salt, params.CostParameter, params.BlockSize, params.ParallelizationParameter, keyLen)
+
+	default:
+		return nil, fmt.Errorf("unsupported key derivation function %s", kdf.Algorithm)
+	}
+}
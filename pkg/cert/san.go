@@ -0,0 +1,125 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// SubjectAltName is one typed entry of the Subject Alternative Name
+// extension. Type uses the same names as the decoded extension fields.
+type SubjectAltName struct {
+	Type  string // "DNS", "IP", "Email", "URI", "otherName", "Directory Name", ...
+	Value string
+	Note  string // otherName type, SPIFFE trust domain or Unicode form of a DNS name
+}
+
+// SANGroup collects the Subject Alternative Names of one type.
+type SANGroup struct {
+	Type  string
+	Names []SubjectAltName
+}
+
+// Order in which SAN types are listed in reports.
+var sanTypeOrder = []string{"DNS", "IP", "Email", "URI", "otherName", "Directory Name", "Registered ID", "X.400 Address", "EDI Party Name", "Unknown"}
+
+const oidSubjectAltName = "2.5.29.17"
+
+func analyzeSANs(cert *x509.Certificate) []SubjectAltName {
+	return analyzeSANExtensions(cert.Extensions)
+}
+
+func analyzeSANExtensions(exts []pkix.Extension) []SubjectAltName {
+	var sans []SubjectAltName
+	for _, ext := range exts {
+		if ext.Id.String() != oidSubjectAltName {
+			continue
+		}
+		names, err := parseSequence(ext.Value)
+		if err != nil {
+			return nil
+		}
+		for _, name := range names {
+			sans = append(sans, newSubjectAltName(describeGeneralName(name)))
+		}
+	}
+	return sans
+}
+
+func newSubjectAltName(kind, value string) SubjectAltName {
+	san := SubjectAltName{Type: kind, Value: value}
+
+	switch kind {
+	case "DNS":
+		if strings.Contains(value, "xn--") {
+			if unicode, err := idna.ToUnicode(value); err == nil && unicode != value {
+				san.Note = unicode
+			}
+		}
+	case "URI":
+		if strings.HasPrefix(strings.ToLower(value), "spiffe:") {
+			san.Note = describeSPIFFEID(value)
+		}
+	case "otherName":
+		// describeGeneralName renders otherNames as "type: value"
+		if name, rest, ok := strings.Cut(value, ": "); ok {
+			san.Note, san.Value = name, rest
+		}
+	}
+	return san
+}
+
+// describeSPIFFEID validates a SPIFFE ID against the SPIFFE-ID
+// specification and names its trust domain.
+func describeSPIFFEID(id string) string {
+	u, err := url.Parse(id)
+	if err != nil {
+		return "Malformed SPIFFE ID: " + err.Error()
+	}
+
+	switch {
+	case u.Scheme != "spiffe":
+		return "Malformed SPIFFE ID: scheme must be lower-case \"spiffe\""
+	case u.Host == "":
+		return "Malformed SPIFFE ID: missing trust domain"
+	case u.User != nil || u.Port() != "":
+		return "Malformed SPIFFE ID: trust domain must not contain user info or a port"
+	case u.RawQuery != "" || u.Fragment != "":
+		return "Malformed SPIFFE ID: query and fragment are not allowed"
+	}
+	for _, r := range u.Host {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+			return fmt.Sprintf("Malformed SPIFFE ID: invalid character %q in trust domain", r)
+		}
+	}
+	if u.Path != "" {
+		for _, segment := range strings.Split(u.Path[1:], "/") {
+			if segment == "" || segment == "." || segment == ".." {
+				return "Malformed SPIFFE ID: empty, \".\" or \"..\" path segment"
+			}
+		}
+	}
+
+	return "SPIFFE ID, trust domain " + u.Host
+}
+
+// SANGroups returns the Subject Alternative Names grouped by type.
+func (c CertificateInfo) SANGroups() []SANGroup {
+	var groups []SANGroup
+	for _, kind := range sanTypeOrder {
+		group := SANGroup{Type: kind}
+		for _, san := range c.SANs {
+			if san.Type == kind {
+				group.Names = append(group.Names, san)
+			}
+		}
+		if len(group.Names) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,256 @@
+package cert
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// ScanResult lists the protocol versions, cipher suites and key exchange
+// groups an endpoint accepts, together with an overall grade.
+type ScanResult struct {
+	Versions     []VersionSupport
+	CipherSuites []CipherSupport // accepted suites only
+	Groups       []GroupSupport
+	Grade        string
+	Issues       []string
+}
+
+type VersionSupport struct {
+	Version   string
+	Supported bool
+	Legacy    bool // TLS 1.0 and 1.1, deprecated by RFC 8996
+}
+
+type CipherSupport struct {
+	Name           string
+	Version        string
+	ForwardSecrecy bool
+	Weak           bool
+	Reason         string // why the suite is weak
+}
+
+type GroupSupport struct {
+	Name        string
+	PostQuantum bool
+	Supported   bool
+}
+
+var scanVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+// Groups crypto/tls can offer, strongest first.
+var scanGroups = []tls.CurveID{tls.X25519MLKEM768, tls.X25519, tls.CurveP256, tls.CurveP384, tls.CurveP521}
+
+// Scan probes which TLS versions, cipher suites and groups the target
+// accepts. Only what crypto/tls implements can be detected, and TLS 1.3
+// suites cannot be offered individually, so for TLS 1.3 only the suite the
+// server picks is reported.
+func Scan(domainPort string, opts FetchOptions) (*ScanResult, error) {
+	t, err := resolveTarget(domainPort, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	suites := append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+	result := &ScanResult{}
+
+	for _, version := range scanVersions {
+		config := t.scanConfig(version)
+		if version < tls.VersionTLS13 {
+			config.CipherSuites = suiteIDs(suites, version)
+		}
+		state, ok := t.probe(config)
+		result.Versions = append(result.Versions, VersionSupport{
+			Version:   tls.VersionName(version),
+			Supported: ok,
+			Legacy:    version < tls.VersionTLS12,
+		})
+		if !ok {
+			continue
+		}
+
+		if version == tls.VersionTLS13 {
+			result.CipherSuites = append(result.CipherSuites, classifySuite(state.CipherSuite, version))
+			continue
+		}
+		for _, suite := range suites {
+			if !supportsVersion(suite, version) {
+				continue
+			}
+			config := t.scanConfig(version)
+			config.CipherSuites = []uint16{suite.ID}
+			if _, ok := t.probe(config); ok {
+				result.CipherSuites = append(result.CipherSuites, classifySuite(suite.ID, version))
+			}
+		}
+	}
+
+	if !result.supportsAny() {
+		return nil, fmt.Errorf("no TLS version could be negotiated with %s", t.address)
+	}
+
+	// Offer only ECDHE suites so that a server without the group cannot
+	// fall back to RSA key exchange.
+	var ecdhe []uint16
+	for _, suite := range suites {
+		if strings.Contains(suite.Name, "_ECDHE_") {
+			ecdhe = append(ecdhe, suite.ID)
+		}
+	}
+	for _, group := range scanGroups {
+		config := t.scanConfig(0)
+		config.CipherSuites = ecdhe
+		config.CurvePreferences = []tls.CurveID{group}
+		state, ok := t.probe(config)
+		result.Groups = append(result.Groups, GroupSupport{
+			Name:        groupName(group),
+			PostQuantum: postQuantumGroups[group],
+			Supported:   ok && state.CurveID == group,
+		})
+	}
+
+	result.grade()
+	return result, nil
+}
+
+// scanConfig pins the handshake to version, or allows TLS 1.0 to 1.3 when
+// version is zero.
+func (t *target) scanConfig(version uint16) *tls.Config {
+	config := &tls.Config{
+		ServerName:         t.serverName,
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+		MaxVersion:         tls.VersionTLS13,
+	}
+	if version != 0 {
+		config.MinVersion, config.MaxVersion = version, version
+	}
+	return config
+}
+
+func (t *target) probe(config *tls.Config) (tls.ConnectionState, bool) {
+	conn, err := t.handshake(config)
+	if err != nil {
+		return tls.ConnectionState{}, false
+	}
+	defer conn.Close()
+	return conn.ConnectionState(), true
+}
+
+func suiteIDs(suites []*tls.CipherSuite, version uint16) []uint16 {
+	var ids []uint16
+	for _, suite := range suites {
+		if supportsVersion(suite, version) {
+			ids = append(ids, suite.ID)
+		}
+	}
+	return ids
+}
+
+func supportsVersion(suite *tls.CipherSuite, version uint16) bool {
+	for _, v := range suite.SupportedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func classifySuite(id uint16, version uint16) CipherSupport {
+	name := tls.CipherSuiteName(id)
+	c := CipherSupport{
+		Name:    name,
+		Version: tls.VersionName(version),
+		// TLS 1.3 key exchange is always ephemeral
+		ForwardSecrecy: version == tls.VersionTLS13 || strings.Contains(name, "_ECDHE_") || strings.Contains(name, "_DHE_"),
+	}
+
+	switch {
+	case strings.Contains(name, "_RC4_"):
+		c.Weak, c.Reason = true, "RC4 is broken (RFC 7465)"
+	case strings.Contains(name, "_3DES_"):
+		c.Weak, c.Reason = true, "64-bit block cipher vulnerable to Sweet32"
+	case !c.ForwardSecrecy:
+		c.Weak, c.Reason = true, "RSA key exchange provides no forward secrecy"
+	case strings.Contains(name, "_CBC_"):
+		c.Weak, c.Reason = true, "CBC mode is prone to padding oracle attacks"
+	}
+	return c
+}
+
+func (r *ScanResult) supportsAny() bool {
+	for _, v := range r.Versions {
+		if v.Supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Grades from best to worst.
+var scanGrades = []string{"A+", "A", "A-", "B", "C"}
+
+// Cipher suite classes that crypto/tls cannot offer, so a scan never sees
+// whether the server accepts them.
+var untestedSuiteClasses = []string{
+	"DHE",
+	"static DH and ECDH",
+	"export",
+	"NULL",
+	"anonymous",
+	"PSK",
+	"CCM",
+	"Camellia, ARIA, SEED and IDEA",
+	"RC4 and 3DES other than RSA/ECDHE-RSA 3DES and RSA/ECDHE RC4",
+}
+
+// grade caps the score at the worst issue found, loosely following the
+// SSL Labs rating guide. A+ means no issues were found among the suites
+// that could be offered; the last issue always names the classes that
+// were not tested.
+func (r *ScanResult) grade() {
+	worst := 0
+	limit := func(grade, issue string) {
+		r.Issues = append(r.Issues, issue)
+		for i, g := range scanGrades {
+			if g == grade && i > worst {
+				worst = i
+			}
+		}
+	}
+
+	var tls13 bool
+	for _, v := range r.Versions {
+		if v.Supported && v.Legacy {
+			limit("B", fmt.Sprintf("Legacy protocol %s is enabled", v.Version))
+		}
+		if v.Supported && v.Version == tls.VersionName(tls.VersionTLS13) {
+			tls13 = true
+		}
+	}
+	if !tls13 {
+		limit("A-", "TLS 1.3 is not supported")
+	}
+
+	var cbc, noFS bool
+	for _, c := range r.CipherSuites {
+		switch {
+		case strings.Contains(c.Name, "_RC4_") || strings.Contains(c.Name, "_3DES_"):
+			limit("C", fmt.Sprintf("Weak cipher %s (%s): %s", c.Name, c.Version, c.Reason))
+		case !c.ForwardSecrecy:
+			noFS = true
+		case strings.Contains(c.Name, "_CBC_"):
+			cbc = true
+		}
+	}
+	if noFS {
+		limit("B", "Cipher suites without forward secrecy are accepted")
+	}
+	if cbc {
+		limit("A", "CBC mode cipher suites are accepted")
+	}
+	r.Issues = append(r.Issues, fmt.Sprintf("Not tested, as crypto/tls cannot offer them: %s suites",
+		strings.Join(untestedSuiteClasses, ", ")))
+
+	r.Grade = scanGrades[worst]
+}
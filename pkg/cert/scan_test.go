@@ -0,0 +1,220 @@
+package cert
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestScanResultGrade(t *testing.T) {
+	versions := func(supported ...uint16) []VersionSupport {
+		var vs []VersionSupport
+		for _, v := range scanVersions {
+			support := VersionSupport{Version: tls.VersionName(v), Legacy: v < tls.VersionTLS12}
+			for _, s := range supported {
+				support.Supported = support.Supported || s == v
+			}
+			vs = append(vs, support)
+		}
+		return vs
+	}
+	suites := func(version uint16, ids ...uint16) []CipherSupport {
+		var cs []CipherSupport
+		for _, id := range ids {
+			cs = append(cs, classifySuite(id, version))
+		}
+		return cs
+	}
+	modern := suites(tls.VersionTLS12, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256)
+
+	tests := []struct {
+		name       string
+		result     ScanResult
+		want       string
+		wantIssues []string
+	}{
+		{
+			name:   "TLS 1.2 and 1.3 with AEAD suites",
+			result: ScanResult{Versions: versions(tls.VersionTLS12, tls.VersionTLS13), CipherSuites: modern},
+			want:   "A+",
+		},
+		{
+			name: "CBC suites",
+			result: ScanResult{
+				Versions:     versions(tls.VersionTLS12, tls.VersionTLS13),
+				CipherSuites: append(suites(tls.VersionTLS12, tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA), modern...),
+			},
+			want:       "A",
+			wantIssues: []string{"CBC mode cipher suites are accepted"},
+		},
+		{
+			name:       "no TLS 1.3",
+			result:     ScanResult{Versions: versions(tls.VersionTLS12), CipherSuites: modern},
+			want:       "A-",
+			wantIssues: []string{"TLS 1.3 is not supported"},
+		},
+		{
+			name: "RSA key exchange",
+			result: ScanResult{
+				Versions:     versions(tls.VersionTLS12, tls.VersionTLS13),
+				CipherSuites: append(suites(tls.VersionTLS12, tls.TLS_RSA_WITH_AES_128_GCM_SHA256), modern...),
+			},
+			want:       "B",
+			wantIssues: []string{"Cipher suites without forward secrecy are accepted"},
+		},
+		{
+			name:       "legacy versions",
+			result:     ScanResult{Versions: versions(tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13), CipherSuites: modern},
+			want:       "B",
+			wantIssues: []string{"Legacy protocol TLS 1.0 is enabled", "Legacy protocol TLS 1.1 is enabled"},
+		},
+		{
+			name: "3DES",
+			result: ScanResult{
+				Versions:     versions(tls.VersionTLS12),
+				CipherSuites: suites(tls.VersionTLS12, tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA),
+			},
+			want: "C",
+			wantIssues: []string{
+				"TLS 1.3 is not supported",
+				"Weak cipher TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA (TLS 1.2): 64-bit block cipher vulnerable to Sweet32",
+			},
+		},
+		{
+			name: "RC4 with RSA key exchange",
+			result: ScanResult{
+				Versions:     versions(tls.VersionTLS10),
+				CipherSuites: suites(tls.VersionTLS10, tls.TLS_RSA_WITH_RC4_128_SHA),
+			},
+			want: "C",
+			wantIssues: []string{
+				"Legacy protocol TLS 1.0 is enabled",
+				"TLS 1.3 is not supported",
+				"Weak cipher TLS_RSA_WITH_RC4_128_SHA (TLS 1.0): RC4 is broken (RFC 7465)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.result.grade()
+			if tt.result.Grade != tt.want {
+				t.Errorf("grade %s, want %s", tt.result.Grade, tt.want)
+			}
+			issues := tt.result.Issues
+			if len(issues) == 0 || !strings.HasPrefix(issues[len(issues)-1], "Not tested, as crypto/tls cannot offer them: DHE,") {
+				t.Fatalf("issues %q do not end with the untested suites", issues)
+			}
+			if got := issues[:len(issues)-1]; !reflect.DeepEqual(got, tt.wantIssues) && (len(got) != 0 || len(tt.wantIssues) != 0) {
+				t.Errorf("issues %q, want %q", got, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestClassifySuite(t *testing.T) {
+	tests := []struct {
+		id      uint16
+		version uint16
+		want    CipherSupport
+	}{
+		{
+			id:      tls.TLS_AES_128_GCM_SHA256,
+			version: tls.VersionTLS13,
+			want:    CipherSupport{Name: "TLS_AES_128_GCM_SHA256", Version: "TLS 1.3", ForwardSecrecy: true},
+		},
+		{
+			id:      tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			version: tls.VersionTLS12,
+			want:    CipherSupport{Name: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", Version: "TLS 1.2", ForwardSecrecy: true},
+		},
+		{
+			id:      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+			version: tls.VersionTLS12,
+			want: CipherSupport{Name: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", Version: "TLS 1.2", ForwardSecrecy: true,
+				Weak: true, Reason: "CBC mode is prone to padding oracle attacks"},
+		},
+		{
+			id:      tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+			version: tls.VersionTLS12,
+			want: CipherSupport{Name: "TLS_RSA_WITH_AES_128_GCM_SHA256", Version: "TLS 1.2",
+				Weak: true, Reason: "RSA key exchange provides no forward secrecy"},
+		},
+		{
+			id:      tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+			version: tls.VersionTLS11,
+			want: CipherSupport{Name: "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA", Version: "TLS 1.1", ForwardSecrecy: true,
+				Weak: true, Reason: "64-bit block cipher vulnerable to Sweet32"},
+		},
+		{
+			id:      tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
+			version: tls.VersionTLS12,
+			want: CipherSupport{Name: "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA", Version: "TLS 1.2", ForwardSecrecy: true,
+				Weak: true, Reason: "RC4 is broken (RFC 7465)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want.Name, func(t *testing.T) {
+			if got := classifySuite(tt.id, tt.version); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		MaxVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+		},
+		CurvePreferences: []tls.CurveID{tls.CurveP256},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	result, err := Scan(server.Listener.Addr().String(), FetchOptions{StartTLS: StartTLSNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions, suites, groups []string
+	for _, v := range result.Versions {
+		if v.Supported {
+			versions = append(versions, v.Version)
+		}
+	}
+	for _, c := range result.CipherSuites {
+		suites = append(suites, c.Name)
+	}
+	for _, g := range result.Groups {
+		if g.Supported {
+			groups = append(groups, g.Name)
+		}
+	}
+	if want := []string{"TLS 1.2"}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("versions %q, want %q", versions, want)
+	}
+	if want := []string{"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}; !reflect.DeepEqual(sortedCopy(suites), want) {
+		t.Errorf("suites %q, want %q", suites, want)
+	}
+	if want := []string{"secp256r1"}; !reflect.DeepEqual(groups, want) {
+		t.Errorf("groups %q, want %q", groups, want)
+	}
+	if result.Grade != "A-" {
+		t.Errorf("grade %s, want A- (issues %q)", result.Grade, result.Issues)
+	}
+}
+
+func sortedCopy(s []string) []string {
+	s = append([]string(nil), s...)
+	sort.Strings(s)
+	return s
+}
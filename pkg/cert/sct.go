@@ -0,0 +1,112 @@
+package cert
+
+import (
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+const oidSCTList = "1.3.6.1.4.1.11129.2.4.2"
+
+// SCT is a Signed Certificate Timestamp as defined in RFC 6962, section 3.2.
+type SCT struct {
+	Version       int
+	LogID         []byte
+	Timestamp     time.Time
+	Extensions    []byte
+	HashAlgorithm string
+	SigAlgorithm  string
+	Signature     []byte
+}
+
+func (s SCT) LogIDBase64() string {
+	return base64.StdEncoding.EncodeToString(s.LogID)
+}
+
+var sctHashAlgorithms = map[byte]string{
+	0: "none", 1: "MD5", 2: "SHA-1", 3: "SHA-224", 4: "SHA-256", 5: "SHA-384", 6: "SHA-512",
+}
+
+var sctSignatureAlgorithms = map[byte]string{
+	0: "anonymous", 1: "RSA", 2: "DSA", 3: "ECDSA",
+}
+
+// parseSCTExtension unwraps the OCTET STRING carried in the X.509 extension
+// before parsing the TLS-encoded list.
+func parseSCTExtension(value []byte) ([]SCT, error) {
+	var list []byte
+	if _, err := asn1.Unmarshal(value, &list); err != nil {
+		return nil, fmt.Errorf("invalid SCT list encoding: %v", err)
+	}
+	return parseSCTList(list)
+}
+
+func parseSCTList(data []byte) ([]SCT, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("truncated SCT list")
+	}
+	length := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+	if length != len(data) {
+		return nil, fmt.Errorf("SCT list length mismatch")
+	}
+
+	var scts []SCT
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("truncated SCT entry")
+		}
+		n := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+n {
+			return nil, fmt.Errorf("truncated SCT entry")
+		}
+		sct, err := parseSCT(data[2 : 2+n])
+		if err != nil {
+			return nil, err
+		}
+		scts = append(scts, sct)
+		data = data[2+n:]
+	}
+
+	return scts, nil
+}
+
+func parseSCT(data []byte) (SCT, error) {
+	var sct SCT
+	// version(1) + log id(32) + timestamp(8) + extensions length(2)
+	if len(data) < 43 {
+		return sct, fmt.Errorf("truncated SCT")
+	}
+
+	sct.Version = int(data[0])
+	sct.LogID = data[1:33]
+	millis := binary.BigEndian.Uint64(data[33:41])
+	sct.Timestamp = time.UnixMilli(int64(millis)).UTC()
+
+	extLen := int(binary.BigEndian.Uint16(data[41:43]))
+	data = data[43:]
+	if len(data) < extLen+4 {
+		return sct, fmt.Errorf("truncated SCT")
+	}
+	sct.Extensions = data[:extLen]
+	data = data[extLen:]
+
+	sct.HashAlgorithm = lookupAlgorithm(sctHashAlgorithms, data[0])
+	sct.SigAlgorithm = lookupAlgorithm(sctSignatureAlgorithms, data[1])
+	sigLen := int(binary.BigEndian.Uint16(data[2:4]))
+	if len(data[4:]) != sigLen {
+		return sct, fmt.Errorf("SCT signature length mismatch")
+	}
+	sct.Signature = data[4:]
+
+	return sct, nil
+}
+
+func lookupAlgorithm(names map[byte]string, id byte) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (%d)", id)
+}
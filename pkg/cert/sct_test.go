@@ -0,0 +1,111 @@
+package cert
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// encodeSCT serializes an SCT (RFC 6962, section 3.2) with the given hash
+// and signature algorithm codes.
+func encodeSCT(version byte, logID []byte, timestamp time.Time, extensions []byte, hash, sig byte, signature []byte) []byte {
+	var b bytes.Buffer
+	b.WriteByte(version)
+	b.Write(logID)
+	binary.Write(&b, binary.BigEndian, uint64(timestamp.UnixMilli()))
+	binary.Write(&b, binary.BigEndian, uint16(len(extensions)))
+	b.Write(extensions)
+	b.WriteByte(hash)
+	b.WriteByte(sig)
+	binary.Write(&b, binary.BigEndian, uint16(len(signature)))
+	b.Write(signature)
+	return b.Bytes()
+}
+
+// encodeSCTList wraps SCTs in a SignedCertificateTimestampList.
+func encodeSCTList(scts ...[]byte) []byte {
+	var entries bytes.Buffer
+	for _, sct := range scts {
+		binary.Write(&entries, binary.BigEndian, uint16(len(sct)))
+		entries.Write(sct)
+	}
+	list := binary.BigEndian.AppendUint16(nil, uint16(entries.Len()))
+	return append(list, entries.Bytes()...)
+}
+
+func TestParseSCTList(t *testing.T) {
+	logID := bytes.Repeat([]byte{0xab}, 32)
+	timestamp := time.Date(2026, 3, 1, 12, 0, 0, 500e6, time.UTC)
+	sct := encodeSCT(0, logID, timestamp, []byte{0x01, 0x02}, 4, 3, []byte{0x30, 0x00})
+	rsaSCT := encodeSCT(0, logID, timestamp, nil, 4, 1, []byte{0xff})
+	oddSCT := encodeSCT(1, logID, timestamp, nil, 9, 7, nil)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		want      []SCT
+		wantError string
+	}{
+		{
+			name: "two SCTs",
+			data: encodeSCTList(sct, rsaSCT),
+			want: []SCT{
+				{LogID: logID, Timestamp: timestamp, Extensions: []byte{0x01, 0x02}, HashAlgorithm: "SHA-256", SigAlgorithm: "ECDSA", Signature: []byte{0x30, 0x00}},
+				{LogID: logID, Timestamp: timestamp, Extensions: []byte{}, HashAlgorithm: "SHA-256", SigAlgorithm: "RSA", Signature: []byte{0xff}},
+			},
+		},
+		{
+			name: "unknown algorithms",
+			data: encodeSCTList(oddSCT),
+			want: []SCT{{Version: 1, LogID: logID, Timestamp: timestamp, Extensions: []byte{}, HashAlgorithm: "unknown (9)", SigAlgorithm: "unknown (7)", Signature: []byte{}}},
+		},
+		{name: "empty list", data: []byte{0x00, 0x00}},
+		{name: "no length", data: []byte{0x00}, wantError: "truncated SCT list"},
+		{name: "list length mismatch", data: append(encodeSCTList(sct), 0x00), wantError: "SCT list length mismatch"},
+		{name: "truncated entry length", data: []byte{0x00, 0x01, 0x00}, wantError: "truncated SCT entry"},
+		{name: "entry longer than the list", data: []byte{0x00, 0x03, 0x00, 0x05, 0x00}, wantError: "truncated SCT entry"},
+		{name: "truncated SCT", data: encodeSCTList(sct[:42]), wantError: "truncated SCT"},
+		{name: "extensions past the end", data: encodeSCTList(sct[:45]), wantError: "truncated SCT"},
+		{name: "signature length mismatch", data: encodeSCTList(sct[:len(sct)-1]), wantError: "SCT signature length mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scts, err := parseSCTList(tt.data)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("error = %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(scts) != len(tt.want) {
+				t.Fatalf("got %d SCTs, want %d", len(scts), len(tt.want))
+			}
+			for i, got := range scts {
+				want := tt.want[i]
+				if got.Version != want.Version || !bytes.Equal(got.LogID, want.LogID) || !got.Timestamp.Equal(want.Timestamp) ||
+					!bytes.Equal(got.Extensions, want.Extensions) || got.HashAlgorithm != want.HashAlgorithm ||
+					got.SigAlgorithm != want.SigAlgorithm || !bytes.Equal(got.Signature, want.Signature) {
+					t.Errorf("SCT %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSCTExtension(t *testing.T) {
+	list := encodeSCTList(encodeSCT(0, make([]byte, 32), time.Now(), nil, 4, 3, []byte{0x30, 0x00}))
+	scts, err := parseSCTExtension(der(asn1.ClassUniversal, asn1.TagOctetString, false, list))
+	if err != nil || len(scts) != 1 {
+		t.Errorf("got %d SCTs, error %v; want 1 SCT", len(scts), err)
+	}
+	if _, err := parseSCTExtension(list); err == nil || !strings.Contains(err.Error(), "invalid SCT list encoding") {
+		t.Errorf("unwrapped list: error = %v, want an encoding error", err)
+	}
+}
@@ -0,0 +1,391 @@
+package cert
+
+import (
+	"bufio"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+)
+
+const StartTLSNone = "none"
+
+type startTLSFunc func(conn net.Conn, host string) error
+
+var startTLSHandlers = map[string]startTLSFunc{
+	"smtp":     startTLSSMTP,
+	"imap":     startTLSIMAP,
+	"pop3":     startTLSPOP3,
+	"ftp":      startTLSFTP,
+	"ldap":     startTLSLDAP,
+	"xmpp":     startTLSXMPP,
+	"postgres": startTLSPostgres,
+}
+
+// Well-known plaintext ports whose protocol upgrades to TLS in-band.
+var startTLSPorts = map[string]string{
+	"21":   "ftp",
+	"25":   "smtp",
+	"110":  "pop3",
+	"143":  "imap",
+	"389":  "ldap",
+	"587":  "smtp",
+	"5222": "xmpp",
+	"5432": "postgres",
+}
+
+// StartTLSProtocols lists the protocols accepted by FetchOptions.StartTLS.
+func StartTLSProtocols() []string {
+	protocols := make([]string, 0, len(startTLSHandlers))
+	for name := range startTLSHandlers {
+		protocols = append(protocols, name)
+	}
+	sort.Strings(protocols)
+	return protocols
+}
+
+// resolveStartTLS returns the protocol to upgrade with, or "" for direct
+// TLS. An empty request means auto-detect from the port.
+func resolveStartTLS(requested, port string) (string, error) {
+	switch requested {
+	case "", "auto":
+		return startTLSPorts[port], nil
+	case StartTLSNone:
+		return "", nil
+	}
+
+	proto := strings.ToLower(requested)
+	if proto == "postgresql" {
+		proto = "postgres"
+	}
+	if _, ok := startTLSHandlers[proto]; !ok {
+		return "", fmt.Errorf("unsupported STARTTLS protocol %q (use %s or none)", requested, strings.Join(StartTLSProtocols(), ", "))
+	}
+	return proto, nil
+}
+
+func startTLS(conn net.Conn, proto, host string) error {
+	return startTLSHandlers[proto](conn, host)
+}
+
+// readReply reads a (possibly multi-line) SMTP or FTP reply and returns its
+// status code and text.
+func readReply(r *bufio.Reader) (string, string, error) {
+	var text []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) < 3 {
+			return "", "", fmt.Errorf("malformed reply %q", line)
+		}
+		text = append(text, line)
+		// "250-" continues a reply, "250 " ends it
+		if len(line) == 3 || line[3] == ' ' {
+			return line[:3], strings.Join(text, "\n"), nil
+		}
+	}
+}
+
+func expectReply(r *bufio.Reader, code string) (string, error) {
+	got, text, err := readReply(r)
+	if err != nil {
+		return "", err
+	}
+	if got != code {
+		return "", fmt.Errorf("unexpected reply: %s", text)
+	}
+	return text, nil
+}
+
+func startTLSSMTP(conn net.Conn, host string) error {
+	r := bufio.NewReader(conn)
+	if _, err := expectReply(r, "220"); err != nil {
+		return fmt.Errorf("greeting: %v", err)
+	}
+
+	if _, err := io.WriteString(conn, "EHLO certview.invalid\r\n"); err != nil {
+		return err
+	}
+	features, err := expectReply(r, "250")
+	if err != nil {
+		return fmt.Errorf("EHLO: %v", err)
+	}
+	if !strings.Contains(strings.ToUpper(features), "STARTTLS") {
+		return fmt.Errorf("server does not advertise STARTTLS")
+	}
+
+	if _, err := io.WriteString(conn, "STARTTLS\r\n"); err != nil {
+		return err
+	}
+	if _, err := expectReply(r, "220"); err != nil {
+		return fmt.Errorf("STARTTLS: %v", err)
+	}
+	return nil
+}
+
+func startTLSFTP(conn net.Conn, host string) error {
+	r := bufio.NewReader(conn)
+	if _, err := expectReply(r, "220"); err != nil {
+		return fmt.Errorf("greeting: %v", err)
+	}
+
+	if _, err := io.WriteString(conn, "AUTH TLS\r\n"); err != nil {
+		return err
+	}
+	if _, err := expectReply(r, "234"); err != nil {
+		return fmt.Errorf("AUTH TLS: %v", err)
+	}
+	return nil
+}
+
+func startTLSIMAP(conn net.Conn, host string) error {
+	r := bufio.NewReader(conn)
+	greeting, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("greeting: %v", err)
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("unexpected greeting: %s", strings.TrimSpace(greeting))
+	}
+
+	if _, err := io.WriteString(conn, "a001 STARTTLS\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("STARTTLS: %v", err)
+		}
+		// Skip untagged responses until the tagged completion
+		if !strings.HasPrefix(line, "a001 ") {
+			continue
+		}
+		if !strings.HasPrefix(line, "a001 OK") {
+			return fmt.Errorf("STARTTLS refused: %s", strings.TrimSpace(line))
+		}
+		return nil
+	}
+}
+
+func startTLSPOP3(conn net.Conn, host string) error {
+	r := bufio.NewReader(conn)
+	greeting, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("greeting: %v", err)
+	}
+	if !strings.HasPrefix(greeting, "+OK") {
+		return fmt.Errorf("unexpected greeting: %s", strings.TrimSpace(greeting))
+	}
+
+	if _, err := io.WriteString(conn, "STLS\r\n"); err != nil {
+		return err
+	}
+	reply, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("STLS: %v", err)
+	}
+	if !strings.HasPrefix(reply, "+OK") {
+		return fmt.Errorf("STLS refused: %s", strings.TrimSpace(reply))
+	}
+	return nil
+}
+
+// LDAP StartTLS extended operation (RFC 4511, section 4.14).
+const oidLDAPStartTLS = "1.3.6.1.4.1.1466.20037"
+
+func startTLSLDAP(conn net.Conn, host string) error {
+	request, err := asn1.Marshal(struct {
+		MessageID int
+		Request   asn1.RawValue
+	}{
+		MessageID: 1,
+		Request: asn1.RawValue{
+			Class:      asn1.ClassApplication,
+			Tag:        23, // ExtendedRequest
+			IsCompound: true,
+			Bytes:      append([]byte{0x80, byte(len(oidLDAPStartTLS))}, oidLDAPStartTLS...),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	message, err := readBERMessage(conn)
+	if err != nil {
+		return fmt.Errorf("extended response: %v", err)
+	}
+	envelope, err := parseBER(message)
+	if err != nil || len(envelope) != 1 {
+		return fmt.Errorf("malformed extended response")
+	}
+	elems, err := parseBER(envelope[0].content)
+	if err != nil || len(elems) < 2 {
+		return fmt.Errorf("malformed extended response")
+	}
+	response := elems[1]
+	if response.class != asn1.ClassApplication || response.tag != 24 {
+		return fmt.Errorf("unexpected LDAP response (tag %d)", response.tag)
+	}
+	fields, err := parseBER(response.content)
+	if err != nil || len(fields) == 0 || fields[0].tag != asn1.TagEnum {
+		return fmt.Errorf("malformed extended response")
+	}
+	code := 0
+	for _, b := range fields[0].content {
+		code = code<<8 | int(b)
+	}
+	if code != 0 {
+		return fmt.Errorf("StartTLS refused (result code %d)", code)
+	}
+	return nil
+}
+
+type berElement struct {
+	class   int
+	tag     int
+	content []byte
+}
+
+// parseBER splits data into its top-level elements. Unlike encoding/asn1 it
+// accepts the non-minimal length encodings many LDAP servers send.
+func parseBER(data []byte) ([]berElement, error) {
+	var elems []berElement
+	for len(data) > 0 {
+		if len(data) < 2 || data[0]&0x1f == 0x1f {
+			return nil, fmt.Errorf("unsupported BER element")
+		}
+		elem := berElement{class: int(data[0] >> 6), tag: int(data[0] & 0x1f)}
+		length, offset := int(data[1]), 2
+		if length&0x80 != 0 {
+			n := length & 0x7f
+			if n == 0 || n > 4 || len(data) < 2+n {
+				return nil, fmt.Errorf("unsupported BER length encoding")
+			}
+			length = 0
+			for _, b := range data[2 : 2+n] {
+				length = length<<8 | int(b)
+			}
+			offset += n
+		}
+		if length > len(data)-offset {
+			return nil, fmt.Errorf("truncated BER element")
+		}
+		elem.content = data[offset : offset+length]
+		elems = append(elems, elem)
+		data = data[offset+length:]
+	}
+	return elems, nil
+}
+
+// readBERMessage reads exactly one definite-length BER element so that no
+// bytes of the following TLS handshake are consumed.
+func readBERMessage(r io.Reader) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	length := int(header[1])
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 {
+			return nil, fmt.Errorf("unsupported BER length encoding")
+		}
+		extra := make([]byte, n)
+		if _, err := io.ReadFull(r, extra); err != nil {
+			return nil, err
+		}
+		header = append(header, extra...)
+		length = 0
+		for _, b := range extra {
+			length = length<<8 | int(b)
+		}
+	}
+
+	if length > 1<<20 {
+		return nil, fmt.Errorf("response too large")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+func startTLSXMPP(conn net.Conn, host string) error {
+	header := fmt.Sprintf("<?xml version='1.0'?><stream:stream xmlns='jabber:client' "+
+		"xmlns:stream='http://etherx.jabber.org/streams' to='%s' version='1.0'>", host)
+	if _, err := io.WriteString(conn, header); err != nil {
+		return err
+	}
+
+	features, err := readUntil(conn, "</stream:features>")
+	if err != nil {
+		return fmt.Errorf("stream features: %v", err)
+	}
+	if !strings.Contains(features, "urn:ietf:params:xml:ns:xmpp-tls") {
+		return fmt.Errorf("server does not offer STARTTLS")
+	}
+
+	if _, err := io.WriteString(conn, "<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"); err != nil {
+		return err
+	}
+	reply, err := readUntil(conn, "/>")
+	if err != nil {
+		return fmt.Errorf("STARTTLS: %v", err)
+	}
+	if !strings.Contains(reply, "<proceed") {
+		return fmt.Errorf("STARTTLS refused: %s", strings.TrimSpace(reply))
+	}
+	return nil
+}
+
+// readUntil reads byte by byte so nothing past the marker is consumed.
+func readUntil(r io.Reader, marker string) (string, error) {
+	var buf strings.Builder
+	b := make([]byte, 1)
+	for buf.Len() < 64<<10 {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return buf.String(), err
+		}
+		buf.WriteByte(b[0])
+		if strings.HasSuffix(buf.String(), marker) {
+			return buf.String(), nil
+		}
+	}
+	return buf.String(), fmt.Errorf("response too large")
+}
+
+// PostgreSQL SSLRequest (protocol version 1234.5679).
+const postgresSSLRequest = 80877103
+
+func startTLSPostgres(conn net.Conn, host string) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgresSSLRequest)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 1)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("SSLRequest: %v", err)
+	}
+	switch reply[0] {
+	case 'S':
+		return nil
+	case 'N':
+		return fmt.Errorf("server does not support SSL")
+	default:
+		return fmt.Errorf("unexpected SSLRequest reply %q", reply[0])
+	}
+}
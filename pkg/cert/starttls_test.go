@@ -0,0 +1,332 @@
+package cert
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestResolveStartTLS(t *testing.T) {
+	tests := []struct {
+		requested string
+		port      string
+		want      string
+		wantError bool
+	}{
+		{requested: "", port: "443", want: ""},
+		{requested: "", port: "587", want: "smtp"},
+		{requested: "auto", port: "389", want: "ldap"},
+		{requested: "none", port: "25", want: ""},
+		{requested: "IMAP", port: "993", want: "imap"},
+		{requested: "postgresql", port: "5433", want: "postgres"},
+		{requested: "gopher", port: "70", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.requested+"/"+tt.port, func(t *testing.T) {
+			got, err := resolveStartTLS(tt.requested, tt.port)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("error = %v, want error %v", err, tt.wantError)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBER(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		want      []berElement
+		wantError string
+	}{
+		{
+			name: "short lengths",
+			data: []byte{0x02, 0x01, 0x05, 0x78, 0x02, 0x0a, 0x00},
+			want: []berElement{{class: 0, tag: 2, content: []byte{0x05}}, {class: 1, tag: 24, content: []byte{0x0a, 0x00}}},
+		},
+		{
+			name: "non-minimal long length",
+			data: []byte{0x30, 0x84, 0x00, 0x00, 0x00, 0x02, 0x04, 0x00},
+			want: []berElement{{class: 0, tag: 16, content: []byte{0x04, 0x00}}},
+		},
+		{
+			name: "long length",
+			data: append([]byte{0x04, 0x81, 0x80}, make([]byte, 128)...),
+			want: []berElement{{class: 0, tag: 4, content: make([]byte, 128)}},
+		},
+		{name: "context class", data: []byte{0x8a, 0x00}, want: []berElement{{class: 2, tag: 10, content: []byte{}}}},
+		{name: "empty", data: nil},
+		{name: "lone tag", data: []byte{0x30}, wantError: "unsupported BER element"},
+		{name: "high tag number", data: []byte{0x1f, 0x81, 0x00}, wantError: "unsupported BER element"},
+		{name: "indefinite length", data: []byte{0x30, 0x80, 0x00, 0x00}, wantError: "unsupported BER length encoding"},
+		{name: "five length bytes", data: []byte{0x30, 0x85, 0, 0, 0, 0, 0}, wantError: "unsupported BER length encoding"},
+		{name: "missing length bytes", data: []byte{0x30, 0x82, 0x00}, wantError: "unsupported BER length encoding"},
+		{name: "truncated content", data: []byte{0x30, 0x03, 0x02, 0x01}, wantError: "truncated BER element"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elems, err := parseBER(tt.data)
+			if tt.wantError != "" {
+				if err == nil || err.Error() != tt.wantError {
+					t.Fatalf("error = %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(elems) != len(tt.want) {
+				t.Fatalf("got %d elements, want %d", len(elems), len(tt.want))
+			}
+			for i, elem := range elems {
+				want := tt.want[i]
+				if elem.class != want.class || elem.tag != want.tag || !bytes.Equal(elem.content, want.content) {
+					t.Errorf("element %d = %+v, want %+v", i, elem, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadBERMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		want      []byte
+		wantError bool
+	}{
+		{name: "short length", data: []byte{0x30, 0x02, 0x05, 0x00, 0x16, 0x03}, want: []byte{0x30, 0x02, 0x05, 0x00}},
+		{name: "non-minimal length", data: []byte{0x30, 0x84, 0x00, 0x00, 0x00, 0x01, 0xff, 0x16}, want: []byte{0x30, 0x84, 0x00, 0x00, 0x00, 0x01, 0xff}},
+		{name: "indefinite length", data: []byte{0x30, 0x80, 0x00, 0x00}, wantError: true},
+		{name: "too large", data: []byte{0x30, 0x84, 0x7f, 0xff, 0xff, 0xff}, wantError: true},
+		{name: "truncated", data: []byte{0x30, 0x05, 0x00}, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bytes.NewReader(tt.data)
+			message, err := readBERMessage(r)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("error = %v, want error %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			if !bytes.Equal(message, tt.want) {
+				t.Errorf("got %x, want %x", message, tt.want)
+			}
+			// The bytes after the message belong to the TLS handshake
+			if rest := len(tt.data) - len(tt.want); r.Len() != rest {
+				t.Errorf("%d bytes left unread, want %d", r.Len(), rest)
+			}
+		})
+	}
+}
+
+// startTLSStep is one exchange of a scripted server: it waits for the
+// client to send expect (if not empty) and answers with reply.
+type startTLSStep struct {
+	expect string
+	reply  string
+}
+
+// tlsMarker stands in for the server's first TLS bytes, which the upgrade
+// must leave unread.
+const tlsMarker = "\x16\x03\x03"
+
+func runStartTLSScript(t *testing.T, proto string, steps []startTLSStep) error {
+	t.Helper()
+	client, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer server.Close()
+		for _, step := range steps {
+			if step.expect != "" {
+				got := make([]byte, len(step.expect))
+				if _, err := io.ReadFull(server, got); err != nil {
+					return
+				}
+				if string(got) != step.expect {
+					t.Errorf("server got %q, want %q", got, step.expect)
+					return
+				}
+			}
+			if _, err := io.WriteString(server, step.reply); err != nil {
+				return
+			}
+		}
+		io.WriteString(server, tlsMarker)
+	}()
+	defer func() {
+		client.Close()
+		<-done
+	}()
+
+	if err := startTLS(client, proto, "mail.example.com"); err != nil {
+		return err
+	}
+	marker := make([]byte, len(tlsMarker))
+	if _, err := io.ReadFull(client, marker); err != nil || string(marker) != tlsMarker {
+		t.Errorf("read %q after the upgrade (error %v), want the start of the TLS handshake", marker, err)
+	}
+	return nil
+}
+
+func TestStartTLS(t *testing.T) {
+	ldapRequest := "\x30\x1d\x02\x01\x01\x77\x18\x80\x16" + oidLDAPStartTLS
+	xmppHeader := "<?xml version='1.0'?><stream:stream xmlns='jabber:client' " +
+		"xmlns:stream='http://etherx.jabber.org/streams' to='mail.example.com' version='1.0'>"
+	xmppStartTLS := "<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"
+
+	tests := []struct {
+		name      string
+		proto     string
+		steps     []startTLSStep
+		wantError string
+	}{
+		{
+			name:  "SMTP",
+			proto: "smtp",
+			steps: []startTLSStep{
+				{reply: "220-mail.example.com ESMTP\r\n220 ready\r\n"},
+				{expect: "EHLO certview.invalid\r\n", reply: "250-mail.example.com\r\n250-SIZE 35882577\r\n250 STARTTLS\r\n"},
+				{expect: "STARTTLS\r\n", reply: "220 2.0.0 Ready to start TLS\r\n"},
+			},
+		},
+		{
+			name:  "SMTP without STARTTLS",
+			proto: "smtp",
+			steps: []startTLSStep{
+				{reply: "220 mail.example.com ESMTP\r\n"},
+				{expect: "EHLO certview.invalid\r\n", reply: "250-mail.example.com\r\n250 8BITMIME\r\n"},
+			},
+			wantError: "server does not advertise STARTTLS",
+		},
+		{
+			name:      "SMTP refused greeting",
+			proto:     "smtp",
+			steps:     []startTLSStep{{reply: "554 no service\r\n"}},
+			wantError: "greeting: unexpected reply: 554 no service",
+		},
+		{
+			name:  "FTP",
+			proto: "ftp",
+			steps: []startTLSStep{
+				{reply: "220 FTP server ready\r\n"},
+				{expect: "AUTH TLS\r\n", reply: "234 AUTH TLS successful\r\n"},
+			},
+		},
+		{
+			name:  "IMAP",
+			proto: "imap",
+			steps: []startTLSStep{
+				{reply: "* OK [CAPABILITY IMAP4rev1 STARTTLS] ready\r\n"},
+				{expect: "a001 STARTTLS\r\n", reply: "* CAPABILITY IMAP4rev1\r\na001 OK Begin TLS negotiation now\r\n"},
+			},
+		},
+		{
+			name:  "IMAP refused",
+			proto: "imap",
+			steps: []startTLSStep{
+				{reply: "* OK ready\r\n"},
+				{expect: "a001 STARTTLS\r\n", reply: "a001 BAD unknown command\r\n"},
+			},
+			wantError: "STARTTLS refused: a001 BAD unknown command",
+		},
+		{
+			name:  "POP3",
+			proto: "pop3",
+			steps: []startTLSStep{
+				{reply: "+OK POP3 ready\r\n"},
+				{expect: "STLS\r\n", reply: "+OK Begin TLS negotiation\r\n"},
+			},
+		},
+		{
+			name:  "POP3 refused",
+			proto: "pop3",
+			steps: []startTLSStep{
+				{reply: "+OK POP3 ready\r\n"},
+				{expect: "STLS\r\n", reply: "-ERR not supported\r\n"},
+			},
+			wantError: "STLS refused: -ERR not supported",
+		},
+		{
+			name:  "LDAP",
+			proto: "ldap",
+			steps: []startTLSStep{{expect: ldapRequest, reply: "\x30\x0c\x02\x01\x01\x78\x07\x0a\x01\x00\x04\x00\x04\x00"}},
+		},
+		{
+			name:  "LDAP with non-minimal lengths",
+			proto: "ldap",
+			steps: []startTLSStep{{expect: ldapRequest,
+				reply: "\x30\x84\x00\x00\x00\x10\x02\x01\x01\x78\x84\x00\x00\x00\x07\x0a\x01\x00\x04\x00\x04\x00"}},
+		},
+		{
+			name:      "LDAP refused",
+			proto:     "ldap",
+			steps:     []startTLSStep{{expect: ldapRequest, reply: "\x30\x0c\x02\x01\x01\x78\x07\x0a\x01\x02\x04\x00\x04\x00"}},
+			wantError: "StartTLS refused (result code 2)",
+		},
+		{
+			name:      "LDAP bind response",
+			proto:     "ldap",
+			steps:     []startTLSStep{{expect: ldapRequest, reply: "\x30\x05\x02\x01\x01\x61\x00"}},
+			wantError: "unexpected LDAP response (tag 1)",
+		},
+		{
+			name:      "LDAP malformed response",
+			proto:     "ldap",
+			steps:     []startTLSStep{{expect: ldapRequest, reply: "\x30\x03\x02\x01\x01"}},
+			wantError: "malformed extended response",
+		},
+		{
+			name:  "XMPP",
+			proto: "xmpp",
+			steps: []startTLSStep{
+				{expect: xmppHeader, reply: "<?xml version='1.0'?><stream:stream from='mail.example.com' version='1.0'>" +
+					"<stream:features><starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'><required/></starttls></stream:features>"},
+				{expect: xmppStartTLS, reply: "<proceed xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"},
+			},
+		},
+		{
+			name:  "XMPP refused",
+			proto: "xmpp",
+			steps: []startTLSStep{
+				{expect: xmppHeader, reply: "<stream:features><starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/></stream:features>"},
+				{expect: xmppStartTLS, reply: "<failure xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"},
+			},
+			wantError: "STARTTLS refused",
+		},
+		{
+			name:  "PostgreSQL",
+			proto: "postgres",
+			steps: []startTLSStep{{expect: "\x00\x00\x00\x08\x04\xd2\x16\x2f", reply: "S"}},
+		},
+		{
+			name:      "PostgreSQL without SSL",
+			proto:     "postgres",
+			steps:     []startTLSStep{{expect: "\x00\x00\x00\x08\x04\xd2\x16\x2f", reply: "N"}},
+			wantError: "server does not support SSL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runStartTLSScript(t, tt.proto, tt.steps)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/x509roots/fallback/bundle"
+)
+
+type TrustStore struct {
+	Name string
+	Pool *x509.CertPool
+	// Certificates is set for stores whose roots can be listed: those
+	// loaded from a file and the embedded Mozilla roots. It is used to
+	// verify CRLs analyzed without a chain.
+	Certificates []*x509.Certificate
+}
+
+type TrustInfo struct {
+	Store    string
+	Verified bool
+	Paths    []TrustPath
+	Failures []string
+}
+
+// TrustPath is a chain returned by x509 path building, ordered from the
+// leaf to the trust anchor it terminates in.
+type TrustPath struct {
+	Path          []CertificateInfo
+	Anchor        string
+	AnchorInChain bool
+}
+
+var (
+	mozillaOnce  sync.Once
+	mozillaStore *TrustStore
+)
+
+func SystemTrustStore() (*TrustStore, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load system trust store: %v", err)
+	}
+	if pool.Equal(x509.NewCertPool()) {
+		return nil, fmt.Errorf("system trust store is empty")
+	}
+	return &TrustStore{Name: "System", Pool: pool}, nil
+}
+
+// MozillaTrustStore returns the Mozilla NSS root program snapshot that is
+// compiled into the binary, so results do not depend on the host.
+func MozillaTrustStore() *TrustStore {
+	mozillaOnce.Do(func() {
+		pool := x509.NewCertPool()
+		var certs []*x509.Certificate
+		for root := range bundle.Roots() {
+			cert, err := x509.ParseCertificate(root.Certificate)
+			if err != nil {
+				continue
+			}
+			if root.Constraint != nil {
+				pool.AddCertWithConstraint(cert, root.Constraint)
+			} else {
+				pool.AddCert(cert)
+			}
+			certs = append(certs, cert)
+		}
+		mozillaStore = &TrustStore{Name: "Mozilla (embedded)", Pool: pool, Certificates: certs}
+	})
+	return mozillaStore
+}
+
+func LoadTrustStore(filename string) (*TrustStore, error) {
+	bundle, err := ParseBundleFile(filename, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA bundle: %v", err)
+	}
+	if len(bundle.Certificates) == 0 {
+		return nil, fmt.Errorf("CA bundle %s contains no certificates", filename)
+	}
+
+	pool := x509.NewCertPool()
+	for _, cert := range bundle.Certificates {
+		pool.AddCert(cert)
+	}
+	return &TrustStore{
+		Name:         fmt.Sprintf("Custom (%s)", filepath.Base(filename)),
+		Pool:         pool,
+		Certificates: bundle.Certificates,
+	}, nil
+}
+
+func LoadNamedTrustStore(name string) (*TrustStore, error) {
+	switch name {
+	case "", "system":
+		return SystemTrustStore()
+	case "mozilla":
+		return MozillaTrustStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown trust store %q (use system or mozilla)", name)
+	}
+}
+
+// DefaultTrustStore falls back to the embedded Mozilla roots when the host
+// has no usable system store, e.g. in a scratch container.
+func DefaultTrustStore() *TrustStore {
+	if store, err := SystemTrustStore(); err == nil {
+		return store
+	}
+	return MozillaTrustStore()
+}
+
+// issuers returns the roots of the store with the given subject whose key
+// identifier matches authorityKeyID, if both are known. The system store
+// cannot be listed, so the embedded Mozilla roots that it also trusts are
+// searched instead.
+func (s *TrustStore) issuers(rawSubject, authorityKeyID []byte) []*x509.Certificate {
+	candidates, listed := s.Certificates, s.Certificates != nil
+	if !listed {
+		candidates = MozillaTrustStore().Certificates
+	}
+
+	var issuers []*x509.Certificate
+	for _, cert := range candidates {
+		if !bytes.Equal(cert.RawSubject, rawSubject) {
+			continue
+		}
+		if len(authorityKeyID) > 0 && len(cert.SubjectKeyId) > 0 && !bytes.Equal(authorityKeyID, cert.SubjectKeyId) {
+			continue
+		}
+		if !listed {
+			// Check at a time the root is valid, as only membership matters
+			_, err := cert.Verify(x509.VerifyOptions{
+				Roots:       s.Pool,
+				CurrentTime: cert.NotBefore,
+				KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+			})
+			if err != nil {
+				continue
+			}
+		}
+		issuers = append(issuers, cert)
+	}
+	return issuers
+}
+
+func verifyTrust(certs []*x509.Certificate, leaves []int, store *TrustStore) *TrustInfo {
+	info := &TrustInfo{Store: store.Name, Verified: len(leaves) > 0}
+	anchors := make(map[string]bool)
+
+	for _, leafIndex := range leaves {
+		leaf := certs[leafIndex]
+		intermediates := x509.NewCertPool()
+		for i, cert := range certs {
+			if i != leafIndex {
+				intermediates.AddCert(cert)
+			}
+		}
+
+		chains, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         store.Pool,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			prefix := ""
+			if len(leaves) > 1 {
+				prefix = fmt.Sprintf("Leaf certificate %d: ", leafIndex+1)
+			}
+			info.Failures = append(info.Failures, fmt.Sprintf("%sVerification against the %s trust store failed: %v", prefix, store.Name, err))
+		}
+		if len(chains) == 0 {
+			info.Verified = false
+		}
+
+		for _, chain := range chains {
+			anchor := chain[len(chain)-1]
+			path := TrustPath{
+				Anchor:        anchor.Subject.String(),
+				AnchorInChain: containsCertificate(certs, anchor),
+			}
+			for _, cert := range chain {
+				path.Path = append(path.Path, analyzeCertificate(cert))
+			}
+			info.Paths = append(info.Paths, path)
+			anchors[string(anchor.Raw)] = true
+		}
+	}
+
+	// Explain why roots that were supplied with the chain did not end up
+	// anchoring any of the verified paths
+	seen := make(map[string]bool)
+	for _, cert := range certs {
+		if !isSelfSigned(cert) || anchors[string(cert.Raw)] || seen[string(cert.Raw)] {
+			continue
+		}
+		seen[string(cert.Raw)] = true
+		_, err := cert.Verify(x509.VerifyOptions{
+			Roots:     store.Pool,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			info.Failures = append(info.Failures, fmt.Sprintf("Root %s supplied in the chain is not trusted by the %s trust store", cert.Subject, store.Name))
+		} else {
+			info.Failures = append(info.Failures, fmt.Sprintf("Root %s is trusted, but no valid path from the leaf reaches it", cert.Subject))
+		}
+	}
+
+	return info
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+		return false
+	}
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+func containsCertificate(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, c := range certs {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
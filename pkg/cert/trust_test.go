@@ -0,0 +1,64 @@
+package cert
+
+import (
+	"crypto/x509"
+	"testing"
+)
+
+func TestTrustStoreIssuers(t *testing.T) {
+	root := newTestRoot(t, "Issuer Test Root")
+	other := newTestRoot(t, "Issuer Test Root")
+	var mozillaRoot *x509.Certificate
+	for _, cert := range MozillaTrustStore().Certificates {
+		if len(cert.SubjectKeyId) > 0 {
+			mozillaRoot = cert
+			break
+		}
+	}
+	if mozillaRoot == nil {
+		t.Fatal("no embedded Mozilla root with a subject key identifier")
+	}
+	withMozillaRoot := x509.NewCertPool()
+	withMozillaRoot.AddCert(mozillaRoot)
+
+	tests := []struct {
+		name    string
+		store   *TrustStore
+		subject *x509.Certificate // RawSubject and SubjectKeyId are looked up
+		noKeyID bool
+		want    []*x509.Certificate
+	}{
+		{name: "file", store: newTestStore(root), subject: root.cert, want: []*x509.Certificate{root.cert}},
+		{name: "same subject, other key", store: newTestStore(root, other), subject: other.cert, want: []*x509.Certificate{other.cert}},
+		{
+			name:    "same subject, no key identifier",
+			store:   newTestStore(root, other),
+			subject: other.cert,
+			noKeyID: true,
+			want:    []*x509.Certificate{root.cert, other.cert},
+		},
+		{name: "not in the file", store: newTestStore(root), subject: mozillaRoot},
+		{name: "embedded Mozilla roots", store: MozillaTrustStore(), subject: mozillaRoot, want: []*x509.Certificate{mozillaRoot}},
+		{name: "Mozilla root in an unlisted pool", store: &TrustStore{Name: "pool", Pool: withMozillaRoot}, subject: mozillaRoot, want: []*x509.Certificate{mozillaRoot}},
+		{name: "Mozilla root not in an unlisted pool", store: &TrustStore{Name: "pool", Pool: x509.NewCertPool()}, subject: mozillaRoot},
+		{name: "unlisted pool", store: &TrustStore{Name: "pool", Pool: newTestStore(root).Pool}, subject: root.cert},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyID := tt.subject.SubjectKeyId
+			if tt.noKeyID {
+				keyID = nil
+			}
+			got := tt.store.issuers(tt.subject.RawSubject, keyID)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d issuers, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("issuer %d is %s", i, got[i].Subject)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package html
 import (
 	"bytes"
 	"html/template"
+	"math"
+	"strings"
 	"time"
 
 	"certview/pkg/cert"
@@ -13,18 +15,52 @@ type TemplateData struct {
 	ChainInfo *cert.ChainInfo
 }
 
+// BatchTemplateData is the data for the combined report of a batch run.
+type BatchTemplateData struct {
+	Title   string
+	Summary cert.BatchSummary
+	Results []cert.BatchResult
+}
+
+// InventoryTemplateData is the data for the report of a discovery run.
+type InventoryTemplateData struct {
+	Title     string
+	Inventory *cert.Inventory
+}
+
+// CRLTemplateData is the data for the report of standalone CRLs.
+type CRLTemplateData struct {
+	Title string
+	CRLs  []*cert.CRLReport
+}
+
+// RequestTemplateData is the data for the report of certificate requests.
+type RequestTemplateData struct {
+	Title    string
+	Requests []cert.RequestInfo
+}
+
+var templateFuncs = template.FuncMap{
+	"add": func(a, b int) int {
+		return a + b
+	},
+	"sub": func(a, b int) int {
+		return a - b
+	},
+	"now": func() time.Time {
+		return time.Now()
+	},
+	"hexdigits": func(s string) string {
+		return strings.ReplaceAll(s, ":", "")
+	},
+	"status": cert.StatusName,
+	"days": func(t time.Time) int {
+		return int(math.Floor(time.Until(t).Hours() / 24))
+	},
+}
+
 func GenerateHTML(chainInfo *cert.ChainInfo, title string) (string, error) {
-	tmpl, err := template.New("cert").Funcs(template.FuncMap{
-		"add": func(a, b int) int {
-			return a + b
-		},
-		"sub": func(a, b int) int {
-			return a - b
-		},
-		"now": func() time.Time {
-			return time.Now()
-		},
-	}).Parse(htmlTemplate)
+	tmpl, err := template.New("cert").Funcs(templateFuncs).Parse(htmlTemplate)
 	if err != nil {
 		return "", err
 	}
@@ -43,6 +79,100 @@ func GenerateHTML(chainInfo *cert.ChainInfo, title string) (string, error) {
 	return buf.String(), nil
 }
 
-func GenerateWebForm() (string, error) {
-	return webFormTemplate, nil
+func GenerateBatchHTML(results []cert.BatchResult, title string) (string, error) {
+	tmpl, err := template.New("batch").Funcs(templateFuncs).Parse(batchTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := BatchTemplateData{
+		Title:   title,
+		Summary: cert.SummarizeBatch(results),
+		Results: results,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func GenerateInventoryHTML(inventory *cert.Inventory, title string) (string, error) {
+	tmpl, err := template.New("inventory").Funcs(templateFuncs).Parse(inventoryTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := InventoryTemplateData{
+		Title:     title,
+		Inventory: inventory,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func GenerateCRLHTML(crls []*cert.CRLReport, title string) (string, error) {
+	tmpl, err := template.New("crl").Funcs(templateFuncs).Parse(crlTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := CRLTemplateData{
+		Title: title,
+		CRLs:  crls,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func GenerateRequestHTML(requests []cert.RequestInfo, title string) (string, error) {
+	tmpl, err := template.New("request").Funcs(templateFuncs).Parse(requestTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := RequestTemplateData{
+		Title:    title,
+		Requests: requests,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// FormOptions selects the optional parts of the web form.
+type FormOptions struct {
+	// RevocationFetch offers the OCSP responder query and CRL download,
+	// which the server only allows when started with -allow-revocation-fetch.
+	RevocationFetch bool
+}
+
+func GenerateWebForm(opts FormOptions) (string, error) {
+	tmpl, err := template.New("form").Parse(webFormTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, opts); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
 }
\ No newline at end of file
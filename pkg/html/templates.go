@@ -207,6 +207,43 @@ const htmlTemplate = `<!DOCTYPE html>
             background: #f7fafc;
         }
 
+        .extension-value {
+            word-break: break-word;
+            max-width: 480px;
+        }
+
+        .extension-value code {
+            word-break: break-all;
+            font-size: 0.85em;
+        }
+
+        .extension-value summary {
+            cursor: pointer;
+            color: #718096;
+            font-size: 0.85em;
+            margin-top: 4px;
+        }
+
+        .identifier-value code {
+            word-break: break-all;
+            font-size: 0.85em;
+        }
+
+        .copy-button {
+            margin-left: 8px;
+            padding: 2px 8px;
+            font-size: 0.75em;
+            border: 1px solid #cbd5e0;
+            border-radius: 4px;
+            background: white;
+            color: #4a5568;
+            cursor: pointer;
+        }
+
+        .copy-button:hover {
+            background: #edf2f7;
+        }
+
         .critical {
             background: #fed7d7;
             color: #c53030;
@@ -258,6 +295,28 @@ const htmlTemplate = `<!DOCTYPE html>
             margin-bottom: 15px;
         }
 
+        .detail-section {
+            background: rgba(235, 244, 255, 0.9);
+            border: 1px solid #90cdf4;
+            border-radius: 10px;
+            padding: 20px;
+            margin: 20px 0;
+        }
+
+        .detail-section h3 {
+            color: #2b6cb0;
+            margin-bottom: 15px;
+        }
+
+        .detail-entry {
+            margin: 10px 0;
+            padding: 12px;
+            background: rgba(255, 255, 255, 0.7);
+            border-radius: 8px;
+            font-size: 0.9em;
+            word-break: break-all;
+        }
+
         .toggle-icon {
             transition: transform 0.3s ease;
         }
@@ -317,16 +376,16 @@ const htmlTemplate = `<!DOCTYPE html>
             <div class="cross-signing-section">
                 <h3>🔗 Cross-Signing Detected</h3>
                 <p>Cross-signed certificates are identical certificates (same public key and subject) that have been signed by different Certificate Authorities, providing multiple validation paths.</p>
-                {{range $key, $certs := .ChainInfo.CrossSigning}}
+                {{range $group := .ChainInfo.CrossSigning}}
                 <div style="margin: 15px 0; padding: 15px; background: rgba(255, 255, 255, 0.7); border-radius: 8px;">
                     <div style="font-weight: bold; color: #97266d; margin-bottom: 10px;">
-                        📜 Certificate Group: {{len $certs}} cross-signed version(s)
+                        📜 Certificate Group: {{len $group.Certificates}} cross-signed version(s)
                     </div>
                     <div style="font-size: 0.9em; color: #666; margin-bottom: 10px;">
-                        {{$key}}
+                        {{$group.Subject}}
                     </div>
                     <div style="margin-left: 20px;">
-                        {{range $i, $cert := $certs}}
+                        {{range $i, $cert := $group.Certificates}}
                         <div style="margin: 5px 0; padding: 8px; background: rgba(151, 38, 109, 0.1); border-radius: 4px;">
                             <strong>Version {{add $i 1}}:</strong><br>
                             <span style="font-size: 0.85em;">
@@ -346,6 +405,256 @@ const htmlTemplate = `<!DOCTYPE html>
             </div>
             {{end}}
 
+            {{with .ChainInfo.Connection}}
+            <div class="detail-section">
+                <h3>🔌 Connection</h3>
+                <div class="detail-entry">
+                    <strong>Target:</strong> {{.Target}}<br>
+                    <strong>Connected to:</strong> {{.Address}}<br>
+                    <strong>SNI:</strong> {{if .ServerName}}{{.ServerName}}{{else}}<em>none sent</em>{{end}}
+                    {{if .StartTLS}}<br><strong>STARTTLS:</strong> {{.StartTLS}}{{end}}
+                </div>
+            </div>
+            {{end}}
+
+            {{with .ChainInfo.Hostname}}
+            <div class="detail-section">
+                <h3>🏷️ Hostname Verification</h3>
+                <p style="margin-bottom: 10px;">
+                    {{if and .Matched (not .Deprecated)}}<span class="status-badge status-valid" style="padding: 4px 12px;">✓ Pass</span>
+                    {{else if .Matched}}<span class="status-badge status-invalid" style="padding: 4px 12px;">⚠ Pass (deprecated)</span>
+                    {{else}}<span class="status-badge status-invalid" style="padding: 4px 12px;">✗ Fail</span>{{end}}
+                </p>
+                <div class="detail-entry">
+                    <strong>Host:</strong> {{.Host}}{{if ne .Host .Normalized}}{{if .Normalized}} ({{.Normalized}}){{end}}{{end}}<br>
+                    <strong>Certificate:</strong> #{{add .Certificate 1}}<br>
+                    {{if .Matched}}<strong>Matched:</strong> {{.MatchedName}} ({{.MatchType}})<br>{{end}}
+                    {{.Message}}
+                </div>
+            </div>
+            {{end}}
+
+            {{with .ChainInfo.Handshake}}
+            <div class="detail-section">
+                <h3>🤝 TLS Handshake</h3>
+                <div class="detail-entry">
+                    <strong>Protocol:</strong> {{.Version}}<br>
+                    <strong>Cipher Suite:</strong> {{.CipherSuite}}<br>
+                    <strong>Key Exchange:</strong> {{if .Group}}{{.Group}}{{if .PostQuantum}} <span style="color: #48bb78;">(post-quantum)</span>{{end}}{{else}}RSA (no forward secrecy){{end}}<br>
+                    <strong>ALPN:</strong> {{if .ALPN}}{{.ALPN}}{{else}}<em>not negotiated</em>{{end}}<br>
+                    <strong>OCSP Staple:</strong> {{if .OCSPStaple}}present ({{len .OCSPStaple}} bytes){{else}}<em>not stapled</em>{{end}}<br>
+                    <strong>Session Resumption:</strong> {{if .Resumption}}<span style="color: #48bb78;">✓ supported</span>{{else if .ResumptionProbed}}not resumed{{else}}not checked{{end}}
+                </div>
+                {{if .SCTs}}
+                <p style="margin-top: 10px;"><strong>SCTs delivered in the TLS extension:</strong></p>
+                {{with $.ChainInfo.CTLogList}}{{if .Stale}}<p style="color: #c53030;">⚠️ The CT log list ({{.Name}}, {{.Timestamp.Format "2006-01-02"}}) is out of date, so SCTs from newer logs cannot be verified. Pass a current log_list.json with -ct-logs.</p>{{end}}{{end}}
+                {{template "sct-table" .SCTs}}
+                {{end}}
+            </div>
+            {{end}}
+
+            {{with .ChainInfo.Scan}}
+            <div class="detail-section">
+                <h3>🔍 TLS Configuration Scan</h3>
+                <p style="margin-bottom: 10px;"><strong>Grade:</strong> <span class="status-badge {{if or (eq .Grade "A+") (eq .Grade "A")}}status-valid{{else}}status-invalid{{end}}" style="padding: 4px 12px;">{{.Grade}}</span></p>
+                {{if .Issues}}
+                <ul class="error-list" style="margin-bottom: 10px;">
+                    {{range .Issues}}
+                    <li>{{.}}</li>
+                    {{end}}
+                </ul>
+                {{end}}
+                <div class="detail-entry">
+                    <strong>Protocols:</strong>
+                    {{range $i, $v := .Versions}}{{if $i}}, {{end}}{{if $v.Supported}}<span style="color: {{if $v.Legacy}}#c53030{{else}}#48bb78{{end}};">✓ {{$v.Version}}</span>{{else}}<span style="color: #a0aec0;">✗ {{$v.Version}}</span>{{end}}{{end}}<br>
+                    <strong>Key Exchange Groups:</strong>
+                    {{range $i, $g := .Groups}}{{if $i}}, {{end}}{{if $g.Supported}}✓ {{$g.Name}}{{if $g.PostQuantum}} (post-quantum){{end}}{{else}}<span style="color: #a0aec0;">✗ {{$g.Name}}</span>{{end}}{{end}}
+                </div>
+                <table class="extensions-table">
+                    <thead>
+                        <tr>
+                            <th>Cipher Suite</th>
+                            <th>Protocol</th>
+                            <th>Forward Secrecy</th>
+                            <th>Assessment</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {{range .CipherSuites}}
+                        <tr>
+                            <td><code>{{.Name}}</code></td>
+                            <td>{{.Version}}</td>
+                            <td>{{if .ForwardSecrecy}}Yes{{else}}<span class="critical">NO</span>{{end}}</td>
+                            <td>{{if .Weak}}<span style="color: #c53030;">⚠️ {{.Reason}}</span>{{else}}<span style="color: #48bb78;">✓ Strong</span>{{end}}</td>
+                        </tr>
+                        {{end}}
+                    </tbody>
+                </table>
+            </div>
+            {{end}}
+
+            {{if .ChainInfo.OCSP}}
+            <div class="detail-section">
+                <h3>🛡️ OCSP Revocation Status</h3>
+                <table class="extensions-table">
+                    <thead>
+                        <tr>
+                            <th>Certificate</th>
+                            <th>Source</th>
+                            <th>Status</th>
+                            <th>Validity</th>
+                            <th>Signed By</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {{range .ChainInfo.OCSP}}
+                        <tr>
+                            <td>#{{add .Certificate 1}}</td>
+                            <td>{{if eq .Source "stapled"}}Stapled{{else}}Responder<br><small><code>{{.URL}}</code></small>{{end}}</td>
+                            <td>
+                                {{if eq .Status "good"}}<span style="color: #48bb78;">✓ Good</span>
+                                {{else if eq .Status "revoked"}}<span class="critical">REVOKED</span> {{.RevokedAt.Format "2006-01-02 15:04:05 MST"}}<br><small>Reason: {{.Reason}}</small>
+                                {{else if .Status}}<span style="color: #c05621;">Unknown to responder</span>
+                                {{else}}<em>no usable response</em>{{end}}
+                                {{if .Error}}<br><span style="color: #c53030;">⚠️ {{.Error}}</span>{{end}}
+                            </td>
+                            <td>
+                                {{if .Status}}
+                                <strong>This update:</strong> {{.ThisUpdate.Format "2006-01-02 15:04:05 MST"}}<br>
+                                <strong>Next update:</strong> {{if .NextUpdate.IsZero}}<em>not given</em>{{else}}{{.NextUpdate.Format "2006-01-02 15:04:05 MST"}}{{end}}
+                                {{if .IsStale}}<br><span style="color: #c53030;">⚠️ Stale</span>{{end}}
+                                {{end}}
+                            </td>
+                            <td>
+                                {{if .Signer}}{{.Signer}}{{if .Delegated}} <small>(delegated responder)</small>{{end}}<br>{{end}}
+                                {{if .SignatureValid}}<span style="color: #48bb78;">✓ Verified</span>{{else if .Status}}<span style="color: #c53030;">✗ Not verified</span>{{end}}
+                            </td>
+                        </tr>
+                        {{end}}
+                    </tbody>
+                </table>
+            </div>
+            {{end}}
+
+            {{if .ChainInfo.Findings}}
+            <div class="detail-section">
+                <h3>🧩 Chain Structure</h3>
+                <ul class="error-list">
+                    {{range .ChainInfo.Findings}}
+                    <li><strong>{{.Kind}}:</strong> {{.Message}}</li>
+                    {{end}}
+                </ul>
+            </div>
+            {{end}}
+
+            {{if .ChainInfo.Lint}}
+            <div class="detail-section">
+                <h3>📋 Lint Findings</h3>
+                <table class="extensions-table">
+                    <thead>
+                        <tr>
+                            <th>Certificate</th>
+                            <th>Severity</th>
+                            <th>Rule</th>
+                            <th>Finding</th>
+                            <th>Citation</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {{range .ChainInfo.Lint}}
+                        <tr>
+                            <td>#{{add .Certificate 1}}</td>
+                            <td>{{if eq .Severity "error"}}<span class="critical">ERROR</span>{{else if eq .Severity "warning"}}<span style="color: #c05621;">Warning</span>{{else}}Notice{{end}}</td>
+                            <td><code>{{.ID}}</code></td>
+                            <td>{{.Message}}</td>
+                            <td>{{.Citation}}</td>
+                        </tr>
+                        {{end}}
+                    </tbody>
+                </table>
+            </div>
+            {{end}}
+
+            {{with .ChainInfo.Trust}}
+            <div class="detail-section">
+                <h3>🛡️ Trust Validation</h3>
+                <p style="margin-bottom: 10px;"><strong>Trust Store:</strong> {{.Store}} &mdash;
+                {{if .Verified}}<span style="color: #48bb78;">✓ {{len .Paths}} verified path(s)</span>{{else}}<span style="color: #c53030;">✗ No verified path to a trusted anchor</span>{{end}}</p>
+                {{range $i, $path := .Paths}}
+                <div class="detail-entry">
+                    <strong>Path {{add $i 1}}</strong> terminates in <strong>{{$path.Anchor}}</strong>
+                    {{if $path.AnchorInChain}}(supplied in chain){{else}}(from trust store){{end}}<br>
+                    {{range $j, $cert := $path.Path}}{{if $j}} → {{end}}{{$cert.Subject}}{{end}}
+                </div>
+                {{end}}
+                {{if .Failures}}
+                <ul class="error-list" style="margin-top: 10px;">
+                    {{range .Failures}}
+                    <li>{{.}}</li>
+                    {{end}}
+                </ul>
+                {{end}}
+            </div>
+            {{end}}
+
+            {{with .ChainInfo.PrivateKey}}
+            <div class="detail-section">
+                <h3>🔑 Private Key</h3>
+                <div class="detail-entry">
+                    <strong>Algorithm:</strong> {{.PublicKey.Algorithm}}<br>
+                    {{if .PublicKey.Size}}<strong>Size:</strong> {{.PublicKey.Size}} bits<br>{{end}}
+                    {{if .PublicKey.Curve}}<strong>Curve:</strong> {{.PublicKey.Curve}}<br>{{end}}
+                    {{if .PublicKey.SPKISHA256}}<strong>SPKI SHA-256 pin:</strong> <code style="word-break: break-all;">{{.PublicKey.SPKISHA256}}</code><br>{{end}}
+                    {{if .MatchesLeaf}}<span style="color: #48bb78;">✓ Matches the leaf certificate</span>{{else if ge .CertificateIndex 0}}<span style="color: #c53030;">⚠️ Does not match the leaf, matches certificate {{add .CertificateIndex 1}}</span>{{else}}<span style="color: #c53030;">⚠️ Does not match any certificate in the chain</span>{{end}}
+                </div>
+            </div>
+            {{end}}
+
+            {{if or .ChainInfo.CRLs .ChainInfo.CRLChecks}}
+            <div class="detail-section">
+                <h3>📄 Certificate Revocation Lists</h3>
+                {{range .ChainInfo.CRLs}}
+                <div class="detail-entry">
+                    {{if .Source}}<strong>Source:</strong> <code>{{.Source}}</code><br>{{end}}
+                    <strong>Issuer:</strong> {{.Issuer}}<br>
+                    <strong>This Update:</strong> {{.ThisUpdate.Format "2006-01-02 15:04:05 UTC"}}<br>
+                    {{if not .NextUpdate.IsZero}}<strong>Next Update:</strong> {{.NextUpdate.Format "2006-01-02 15:04:05 UTC"}}{{if .IsStale}} <span class="critical">STALE</span>{{end}}<br>{{end}}
+                    {{if .Number}}<strong>CRL Number:</strong> {{.Number}}<br>{{end}}
+                    {{if .IsDelta}}<strong>Delta CRL:</strong> updates base CRL {{if .BaseNumber}}number {{.BaseNumber}}{{else}}<em>unknown</em>{{end}}<br>{{end}}
+                    <strong>Revoked Entries:</strong> {{.RevokedCount}}<br>
+                    <strong>Signature:</strong> {{if .SignatureValid}}<span style="color: #48bb78;">✓ Verified by {{if ge .IssuerIndex 0}}certificate {{add .IssuerIndex 1}}{{else}}{{.SignedBy}} (trust store){{end}}</span>{{else}}<span style="color: #c53030;">⚠️ Not verified ({{.SignatureError}})</span>{{end}}
+                </div>
+                {{end}}
+                {{if .ChainInfo.CRLChecks}}
+                <table class="extensions-table">
+                    <thead>
+                        <tr>
+                            <th>Certificate</th>
+                            <th>Status</th>
+                            <th>CRL</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {{range .ChainInfo.CRLChecks}}
+                        <tr>
+                            <td>#{{add .Certificate 1}}</td>
+                            <td>
+                                {{if eq .Status "good"}}<span style="color: #48bb78;">✓ Not revoked</span>
+                                {{else if eq .Status "revoked"}}<span class="critical">REVOKED</span> {{.RevokedAt.Format "2006-01-02 15:04:05 MST"}}<br><small>Reason: {{.Reason}}</small>
+                                {{else}}<span style="color: #c05621;">Unknown</span>{{end}}
+                                {{if .Error}}<br><span style="color: #c53030;">⚠️ {{.Error}}</span>{{end}}
+                            </td>
+                            <td>
+                                {{if ge .CRL 0}}CRL {{add .CRL 1}} of {{len $.ChainInfo.CRLs}}{{if .Stale}}<br><span style="color: #c53030;">⚠️ Stale</span>{{end}}{{else}}<em>none</em>{{end}}
+                            </td>
+                        </tr>
+                        {{end}}
+                    </tbody>
+                </table>
+                {{end}}
+            </div>
+            {{end}}
+
             <div class="chain-visualization">
                 <h3>Certificate Chain Visualization</h3>
                 {{if .ChainInfo.CrossSigning}}
@@ -354,17 +663,17 @@ const htmlTemplate = `<!DOCTYPE html>
                     <p style="margin-bottom: 15px; color: #8b5e3c;">The visualization below shows the complex cross-signing relationships in your certificate chain:</p>
                     
                     <div class="cross-sign-tree">
-                        {{range $key, $certs := .ChainInfo.CrossSigning}}
+                        {{range $group := .ChainInfo.CrossSigning}}
                         <div class="cross-sign-group" style="margin: 20px 0; padding: 15px; border: 2px dashed #d69e2e; border-radius: 10px; background: rgba(237, 242, 247, 0.5);">
                             <div style="text-align: center; margin-bottom: 15px;">
                                 <div class="cert-box cross-signed ca" style="display: inline-block; margin: 0;">
                                     <strong>🔗 Cross-Signed Certificate</strong><br>
-                                    {{(index $certs 0).Subject}}
+                                    {{$group.Subject}}
                                 </div>
                             </div>
                             
                             <div style="display: flex; justify-content: space-around; align-items: flex-start; flex-wrap: wrap; margin-top: 15px;">
-                                {{range $i, $cert := $certs}}
+                                {{range $i, $cert := $group.Certificates}}
                                 <div style="margin: 10px; text-align: center; flex: 1; min-width: 250px;">
                                     <div class="cert-arrow" style="margin: 5px 0;">↑</div>
                                     <div class="cert-box ca" style="margin: 0;">
@@ -382,11 +691,12 @@ const htmlTemplate = `<!DOCTYPE html>
                         {{end}}
                     </div>
                 </div>
+                {{end}}
                 
                 {{if .ChainInfo.ChainPaths}}
                 <div style="background: rgba(240, 253, 244, 0.9); padding: 20px; border-radius: 10px; margin: 20px 0;">
-                    <h4 style="color: #2f855a; margin-bottom: 15px;">🛤️ Multiple Validation Paths</h4>
-                    <p style="margin-bottom: 15px; color: #2d3748;">Due to cross-signing, this certificate can be validated through multiple paths:</p>
+                    <h4 style="color: #2f855a; margin-bottom: 15px;">🛤️ {{if gt (len .ChainInfo.ChainPaths) 1}}Multiple Validation Paths{{else}}Validation Path{{end}}</h4>
+                    <p style="margin-bottom: 15px; color: #2d3748;">{{if .ChainInfo.CrossSigning}}Due to cross-signing, this certificate can be validated through multiple paths:{{else}}Every path from the leaf certificate to an anchor:{{end}}</p>
                     
                     {{range $pathIndex, $path := .ChainInfo.ChainPaths}}
                     <div style="margin: 15px 0; padding: 15px; background: rgba(255, 255, 255, 0.8); border-radius: 8px; border-left: 4px solid #48bb78;">
@@ -394,20 +704,20 @@ const htmlTemplate = `<!DOCTYPE html>
                         <div class="cert-chain" style="display: flex; flex-direction: column; align-items: center;">
                             {{range $i, $cert := $path.Path}}
                             <div class="cert-link">
-                                <div class="cert-box{{if $cert.IsCA}} ca{{end}}{{if $cert.IsExpired}} expired{{end}}" style="max-width: 400px;">
+                                <div class="cert-box{{if $cert.IsCA}} ca{{end}}{{if $cert.IsExpired}} expired{{end}}{{if $cert.CrossSigns}} cross-signed{{end}}" style="max-width: 400px;">
                                     <strong>{{if $cert.IsCA}}🏛️ CA: {{else}}🌐 End Entity: {{end}}</strong><br>
                                     {{$cert.Subject}}
                                     {{if $cert.IsExpired}}<br><small>⚠️ EXPIRED</small>{{end}}
                                 </div>
                             </div>
                             {{if ne $i (sub (len $path.Path) 1)}}
-                            <div class="cert-arrow">↓</div>
+                            <div class="cert-arrow">↓{{if index $path.CrossSigns $i}}<small style="font-size: 0.4em; margin-left: 8px;">cross-signed</small>{{end}}</div>
                             {{end}}
                             {{end}}
                         </div>
                         <div style="margin-top: 10px; font-size: 0.85em; color: #4a5568;">
                             {{if $path.IsComplete}}
-                            ✅ <strong>Complete validation path</strong>
+                            ✅ <strong>Complete validation path</strong>{{if $path.IsTrusted}} to a trusted anchor{{else}} to an untrusted anchor{{end}}
                             {{else}}
                             ⚠️ <strong>Incomplete path</strong> - some intermediate certificates may be missing
                             {{end}}
@@ -416,14 +726,38 @@ const htmlTemplate = `<!DOCTYPE html>
                     {{end}}
                 </div>
                 {{end}}
-                {{end}}
+
+                {{with .ChainInfo.Graph}}{{if .Edges}}
+                <div style="background: rgba(247, 250, 252, 0.9); padding: 20px; border-radius: 10px; margin: 20px 0;">
+                    <h4 style="color: #4a5568; margin-bottom: 15px;">🕸️ Certificate Graph</h4>
+                    <table class="extensions-table">
+                        <thead>
+                            <tr>
+                                <th>Certificate</th>
+                                <th>Issued By</th>
+                                <th>Type</th>
+                            </tr>
+                        </thead>
+                        <tbody>
+                            {{range .Edges}}
+                            {{$from := index $.ChainInfo.Graph.Nodes .From}}{{$to := index $.ChainInfo.Graph.Nodes .To}}
+                            <tr>
+                                <td>{{if ge $from.Index 0}}#{{add $from.Index 1}} {{end}}{{$from.Certificate.Subject}}</td>
+                                <td>{{if ge $to.Index 0}}#{{add $to.Index 1}} {{else}}<em>trust store</em> {{end}}{{$to.Certificate.Subject}}</td>
+                                <td>{{if .CrossSign}}<span class="critical">CROSS-SIGN</span>{{else}}Direct{{end}}</td>
+                            </tr>
+                            {{end}}
+                        </tbody>
+                    </table>
+                </div>
+                {{end}}{{end}}
                 
                 <div class="traditional-chain">
                     <h4 style="margin-bottom: 15px;">📋 All Certificates in Order</h4>
                     <div class="cert-chain">
                         {{range $i, $cert := .ChainInfo.Certificates}}
                         <div class="cert-link">
-                            <div class="cert-box{{if $cert.IsCA}} ca{{end}}{{if $cert.IsExpired}} expired{{end}}{{if index $.ChainInfo.CrossSigning $cert.Subject}} cross-signed{{end}}">
+                            <div class="cert-box{{if $cert.IsCA}} ca{{end}}{{if $cert.IsExpired}} expired{{end}}{{if $cert.CrossSigns}} cross-signed{{end}}">
                                 <strong>{{if $cert.IsCA}}🏛️ CA: {{else}}🌐 End Entity: {{end}}</strong><br>
                                 {{$cert.Subject}}
                                 {{if $cert.IsExpired}}<br><small>⚠️ EXPIRED</small>{{end}}
@@ -466,8 +800,11 @@ const htmlTemplate = `<!DOCTYPE html>
                     </div>
                     <div class="info-card">
                         <h4>Public Key</h4>
-                        <p><strong>Algorithm:</strong> {{$cert.PublicKeyAlg}}<br>
-                        {{if $cert.PublicKeySize}}<strong>Size:</strong> {{$cert.PublicKeySize}} bits{{end}}</p>
+                        <p><strong>Algorithm:</strong> {{$cert.PublicKey.Algorithm}}<br>
+                        {{if $cert.PublicKey.Size}}<strong>Size:</strong> {{$cert.PublicKey.Size}} bits<br>{{end}}
+                        {{if $cert.PublicKey.Curve}}<strong>Curve:</strong> {{$cert.PublicKey.Curve}}<br>{{end}}
+                        {{if $cert.PublicKey.Exponent}}<strong>Exponent:</strong> {{$cert.PublicKey.Exponent}}<br>{{end}}
+                        <strong>SPKI SHA-256 pin:</strong> <code style="word-break: break-all;">{{$cert.PublicKey.SPKISHA256}}</code></p>
                     </div>
                     <div class="info-card">
                         <h4>Signature Algorithm</h4>
@@ -488,11 +825,37 @@ const htmlTemplate = `<!DOCTYPE html>
                     {{if $cert.SANs}}
                     <div class="info-card">
                         <h4>Subject Alternative Names</h4>
-                        <p>{{range $cert.SANs}}{{.}}<br>{{end}}</p>
+                        {{range $cert.SANGroups}}
+                        <p><strong>{{.Type}}</strong> ({{len .Names}})<br>
+                        {{range .Names}}{{if eq .Type "otherName"}}{{.Note}}: {{.Value}}{{else}}{{.Value}}{{if .Note}} <em>({{.Note}})</em>{{end}}{{end}}<br>{{end}}</p>
+                        {{end}}
                     </div>
                     {{end}}
                 </div>
 
+                {{with $cert.Identifiers}}
+                <h3>Fingerprints &amp; Identifiers</h3>
+                <table class="extensions-table">
+                    <tbody>
+                        <tr><td>SHA-256 Fingerprint</td><td class="identifier-value">{{template "copy-value" .SHA256}} <a href="https://crt.sh/?q={{hexdigits .SHA256}}" target="_blank" rel="noopener">crt.sh</a></td></tr>
+                        <tr><td>SHA-1 Fingerprint</td><td class="identifier-value">{{template "copy-value" .SHA1}}</td></tr>
+                        <tr><td>SPKI SHA-256</td><td class="identifier-value">{{template "copy-value" .SPKISHA256}}</td></tr>
+                        <tr><td>SPKI SHA-256 Pin</td><td class="identifier-value">{{template "copy-value" $cert.PublicKey.SPKISHA256}}</td></tr>
+                        <tr><td>SPKI SHA-1</td><td class="identifier-value">{{template "copy-value" .SPKISHA1}}</td></tr>
+                        <tr><td>Subject Hash (OpenSSL)</td><td class="identifier-value">{{template "copy-value" .SubjectHash}}</td></tr>
+                        <tr><td>Issuer Hash (OpenSSL)</td><td class="identifier-value">{{template "copy-value" .IssuerHash}}</td></tr>
+                        {{if .SubjectKeyID}}<tr><td>Subject Key Identifier</td><td class="identifier-value">{{template "copy-value" .SubjectKeyID}}</td></tr>{{end}}
+                        {{if .AuthorityKeyID}}<tr><td>Authority Key Identifier</td><td class="identifier-value">{{template "copy-value" .AuthorityKeyID}}</td></tr>{{end}}
+                    </tbody>
+                </table>
+                {{end}}
+
+                {{if $cert.SCTs}}
+                <h3>Signed Certificate Timestamps</h3>
+                {{with $.ChainInfo.CTLogList}}{{if .Stale}}<p style="color: #c53030;">⚠️ The CT log list ({{.Name}}, {{.Timestamp.Format "2006-01-02"}}) is out of date, so SCTs from newer logs cannot be verified. Pass a current log_list.json with -ct-logs.</p>{{end}}{{end}}
+                {{template "sct-table" $cert.SCTs}}
+                {{end}}
+
                 {{if $cert.Extensions}}
                 <h3>Extensions</h3>
                 <table class="extensions-table">
@@ -510,7 +873,17 @@ const htmlTemplate = `<!DOCTYPE html>
                             <td>{{.Name}}</td>
                             <td>{{.OID}}</td>
                             <td>{{if .Critical}}<span class="critical">CRITICAL</span>{{else}}No{{end}}</td>
-                            <td style="word-break: break-all; max-width: 200px;">{{.Value}}</td>
+                            <td class="extension-value">
+                                {{if .Fields}}
+                                {{range .Fields}}<div><strong>{{.Name}}:</strong> {{.Value}}</div>{{end}}
+                                <details>
+                                    <summary>Raw</summary>
+                                    <code>{{.Raw}}</code>
+                                </details>
+                                {{else}}
+                                <code>{{.Value}}</code>
+                                {{end}}
+                            </td>
                         </tr>
                         {{end}}
                     </tbody>
@@ -535,13 +908,61 @@ const htmlTemplate = `<!DOCTYPE html>
             }
         }
 
+        function copyValue(button) {
+            const value = button.dataset.value;
+            const done = function() {
+                button.textContent = 'Copied';
+                setTimeout(function() { button.textContent = 'Copy'; }, 1200);
+            };
+            if (navigator.clipboard && window.isSecureContext) {
+                navigator.clipboard.writeText(value).then(done);
+                return;
+            }
+            // Fallback for reports opened over plain HTTP
+            const area = document.createElement('textarea');
+            area.value = value;
+            document.body.appendChild(area);
+            area.select();
+            document.execCommand('copy');
+            document.body.removeChild(area);
+            done();
+        }
+
         // Expand first certificate by default
         document.addEventListener('DOMContentLoaded', function() {
             toggleCert(0);
         });
     </script>
 </body>
-</html>`
+</html>
+
+{{- define "copy-value"}}<code>{{.}}</code><button type="button" class="copy-button" data-value="{{.}}" onclick="copyValue(this)">Copy</button>{{end}}
+
+{{- define "sct-table"}}
+<table class="extensions-table">
+    <thead>
+        <tr>
+            <th>Log</th>
+            <th>Timestamp</th>
+            <th>Algorithm</th>
+            <th>Signature</th>
+        </tr>
+    </thead>
+    <tbody>
+        {{range .}}
+        <tr>
+            <td class="extension-value">
+                {{if .Log}}<strong>{{.Log.Description}}</strong><br><small>{{.Log.Operator}}</small>{{else}}<em>Unknown log</em>{{end}}
+                <br><code>{{.LogIDBase64}}</code>
+            </td>
+            <td>{{.Timestamp.Format "2006-01-02 15:04:05 MST"}}</td>
+            <td>{{.HashAlgorithm}} / {{.SigAlgorithm}}</td>
+            <td>{{if .SignatureValid}}<span style="color: #48bb78;">✓ Verified</span>{{else}}<span style="color: #c53030;">⚠️ Not verified ({{.SignatureError}})</span>{{end}}</td>
+        </tr>
+        {{end}}
+    </tbody>
+</table>
+{{end}}`
 
 const webFormTemplate = `<!DOCTYPE html>
 <html lang="en">
@@ -601,7 +1022,7 @@ const webFormTemplate = `<!DOCTYPE html>
             color: #4a5568;
         }
 
-        input[type="text"], input[type="file"], textarea {
+        input[type="text"], input[type="password"], input[type="file"], select, textarea {
             width: 100%;
             padding: 12px;
             border: 2px solid #e2e8f0;
@@ -610,7 +1031,7 @@ const webFormTemplate = `<!DOCTYPE html>
             transition: border-color 0.3s ease;
         }
 
-        input[type="text"]:focus, input[type="file"]:focus, textarea:focus {
+        input[type="text"]:focus, input[type="password"]:focus, input[type="file"]:focus, select:focus, textarea:focus {
             outline: none;
             border-color: #667eea;
         }
@@ -725,19 +1146,83 @@ const webFormTemplate = `<!DOCTYPE html>
                 </div>
             </div>
 
+            <div class="form-group">
+                <label for="trust-input">Trust Store:</label>
+                <select id="trust-input" name="trust">
+                    <option value="system" selected>System roots</option>
+                    <option value="mozilla">Mozilla roots (embedded)</option>
+                </select>
+            </div>
+
+            <div class="form-group">
+                <label for="expecthost-input">Expected Host (optional):</label>
+                <input type="text" id="expecthost-input" name="expecthost" placeholder="www.example.com">
+                <div class="example">Check that the leaf certificate is valid for this host name or IP address (domains default to the SNI sent)</div>
+            </div>
+
+            {{if .RevocationFetch}}
+            <div class="form-group">
+                <label style="font-weight: normal;"><input type="checkbox" name="ocsp" value="1"> Query the OCSP responders of the certificates (stapled responses are always checked)</label>
+            </div>
+
+            <div class="form-group">
+                <label style="font-weight: normal;"><input type="checkbox" name="crl" value="1"> Download the CRLs named in the certificates and check revocation</label>
+            </div>
+            {{end}}
+
+            <div class="form-group">
+                <label for="keyfile-input">Private Key (optional):</label>
+                <input type="file" id="keyfile-input" name="keyfile" accept=".key,.pem">
+                <input type="password" id="keypassword-input" name="keypassword" autocomplete="off" placeholder="Key password" style="margin-top: 8px;">
+                <div class="example">PEM PKCS#1, PKCS#8 or SEC 1 key, optionally encrypted. Reports which certificate it belongs to; the key is not stored or shown</div>
+            </div>
+
             <div id="domain-section" class="input-section active">
                 <div class="form-group">
                     <label for="domain-input">Domain and Port:</label>
                     <input type="text" id="domain-input" name="domain" placeholder="example.com:443">
                     <div class="example">Example: google.com:443 or just google.com (defaults to port 443)</div>
                 </div>
+                <div class="form-group">
+                    <label for="starttls-input">STARTTLS:</label>
+                    <select id="starttls-input" name="starttls">
+                        <option value="" selected>Detect from port</option>
+                        <option value="none">None (direct TLS)</option>
+                        <option value="smtp">SMTP</option>
+                        <option value="imap">IMAP</option>
+                        <option value="pop3">POP3</option>
+                        <option value="ftp">FTP</option>
+                        <option value="ldap">LDAP</option>
+                        <option value="xmpp">XMPP</option>
+                        <option value="postgres">PostgreSQL</option>
+                    </select>
+                    <div class="example">Ports 21, 25, 110, 143, 389, 587, 5222 and 5432 are upgraded automatically</div>
+                </div>
+                <div class="form-group">
+                    <label for="connect-input">Connect To (optional):</label>
+                    <input type="text" id="connect-input" name="connect" placeholder="203.0.113.5:443">
+                    <div class="example">Dial this address instead of resolving the domain, e.g. to test a backend before DNS cutover (public addresses only)</div>
+                </div>
+                <div class="form-group">
+                    <label for="sni-input">SNI Override (optional):</label>
+                    <input type="text" id="sni-input" name="sni" placeholder="api.example.com">
+                    <label style="font-weight: normal; margin-top: 8px;"><input type="checkbox" name="nosni" value="1"> Send no SNI</label>
+                </div>
+                <div class="form-group">
+                    <label style="font-weight: normal;"><input type="checkbox" name="scan" value="1"> Scan supported TLS versions, cipher suites and groups (slower)</label>
+                </div>
             </div>
 
             <div id="file-section" class="input-section">
                 <div class="form-group">
                     <label for="file-input">Certificate File:</label>
-                    <input type="file" id="file-input" name="certfile" accept=".pem,.crt,.cer,.der">
-                    <div class="example">Supports PEM and DER formats (.pem, .crt, .cer, .der)</div>
+                    <input type="file" id="file-input" name="certfile" accept=".pem,.crt,.cer,.der,.p7b,.p7c,.pfx,.p12,.crl,.csr,.req">
+                    <div class="example">Supports PEM, DER, PKCS#7 and PKCS#12 formats (.pem, .crt, .cer, .der, .p7b, .p7c, .pfx, .p12), CRLs (.crl) and certificate requests (.csr, .req)</div>
+                </div>
+                <div class="form-group">
+                    <label for="password-input">Keystore Password:</label>
+                    <input type="password" id="password-input" name="password" autocomplete="off">
+                    <div class="example">Only needed for password protected PKCS#12/PFX files</div>
                 </div>
             </div>
 
@@ -781,4 +1266,548 @@ MIIFXzCCA0egAwIBAgIRAOJyQ...
         });
     </script>
 </body>
-</html>`
\ No newline at end of file
+</html>`
+const batchTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Certificate Analysis - {{.Title}}</title>
+    <style>` + listCSS + `</style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🔒 Certificate Analysis</h1>
+            <div class="subtitle">{{.Title}}</div>
+        </div>
+
+        <div class="panel">
+            <h2>📊 Summary</h2>
+            <div class="counts">
+                <div class="count"><strong>{{.Summary.Total}}</strong>Targets</div>
+                <div class="count OK"><strong>{{.Summary.OK}}</strong>OK</div>
+                <div class="count WARNING"><strong>{{.Summary.Warning}}</strong>Warning</div>
+                <div class="count CRITICAL"><strong>{{.Summary.Critical}}</strong>Critical</div>
+                <div class="count UNKNOWN"><strong>{{.Summary.Unknown}}</strong>Unknown</div>
+            </div>
+            <input type="text" id="filter" placeholder="Filter targets..." oninput="filterTargets(this.value)">
+            <table class="extensions-table" id="summary-table">
+                <thead>
+                    <tr>
+                        <th>Target</th>
+                        <th>Status</th>
+                        <th>Expires</th>
+                        <th>Chain</th>
+                        <th>Host</th>
+                        <th>Problems</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range $i, $r := .Results}}
+                    {{$status := status $r.Status}}
+                    <tr>
+                        <td><a href="#target-{{$i}}">{{$r.Target.Input}}</a></td>
+                        <td><span class="status-badge {{$status}}">{{$status}}</span></td>
+                        <td>{{with $r.Check}}{{.Expiry.Format "2006-01-02"}} ({{.DaysLeft}} days){{end}}</td>
+                        <td>{{with $r.Chain}}{{if .IsValid}}✓ Valid{{else}}✗ Invalid{{end}}{{end}}</td>
+                        <td>{{with $r.Chain}}{{with .Hostname}}{{if .Matched}}✓ {{.Host}}{{else}}✗ {{.Host}}{{end}}{{end}}{{end}}</td>
+                        <td>{{if $r.Error}}{{$r.Error}}{{else}}{{range $j, $p := $r.Check.Problems}}{{if $j}}<br>{{end}}{{$p.Message}}{{end}}{{end}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+
+        <div class="panel">
+            <h2>🔍 Targets</h2>
+            {{range $i, $r := .Results}}
+            {{$status := status $r.Status}}
+            <details class="target" id="target-{{$i}}">
+                <summary><span class="status-badge {{$status}}">{{$status}}</span> {{$r.Title}} <small>(line {{$r.Target.Line}})</small></summary>
+                {{if $r.Error}}
+                <ul class="problems"><li>{{$r.Error}}</li></ul>
+                {{else}}
+                {{with $r.Check.Problems}}
+                <ul class="problems">
+                    {{range .}}<li>{{.Message}}</li>{{end}}
+                </ul>
+                {{end}}
+                {{with $r.Chain}}
+                <table class="extensions-table">
+                    <thead>
+                        <tr>
+                            <th>#</th>
+                            <th>Subject</th>
+                            <th>Issuer</th>
+                            <th>Valid</th>
+                            <th>Key</th>
+                            <th>Signature</th>
+                            <th>SHA-256</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {{range $j, $c := .Certificates}}
+                        <tr>
+                            <td>{{add $j 1}}</td>
+                            <td>{{$c.Subject}}</td>
+                            <td>{{$c.Issuer}}</td>
+                            <td>{{$c.NotBefore.Format "2006-01-02"}} to {{$c.NotAfter.Format "2006-01-02"}}{{if $c.IsExpired}} <strong style="color: #c53030;">expired</strong>{{end}}</td>
+                            <td>{{$c.PublicKey.Algorithm}}{{if $c.PublicKey.Size}} {{$c.PublicKey.Size}}{{end}}</td>
+                            <td>{{$c.SignatureAlg}}</td>
+                            <td><code>{{$c.Identifiers.SHA256}}</code></td>
+                        </tr>
+                        {{end}}
+                    </tbody>
+                </table>
+                {{if .Errors}}
+                <h4>Chain Errors</h4>
+                <ul class="problems">{{range .Errors}}<li>{{.}}</li>{{end}}</ul>
+                {{end}}
+                {{if .Findings}}
+                <h4>Chain Structure</h4>
+                <ul class="problems">{{range .Findings}}<li><strong>{{.Kind}}:</strong> {{.Message}}</li>{{end}}</ul>
+                {{end}}
+                {{if .Lint}}
+                <h4>Lint Findings</h4>
+                <table class="extensions-table">
+                    <tbody>
+                        {{range .Lint}}
+                        <tr>
+                            <td>#{{add .Certificate 1}}</td>
+                            <td>{{.Severity}}</td>
+                            <td><code>{{.ID}}</code></td>
+                            <td>{{.Message}}</td>
+                        </tr>
+                        {{end}}
+                    </tbody>
+                </table>
+                {{end}}
+                {{end}}
+                {{end}}
+            </details>
+            {{end}}
+        </div>
+    </div>
+
+    <script>` + listScript + `</script>
+</body>
+</html>`
+
+const inventoryTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Certificate Inventory - {{.Title}}</title>
+    <style>` + listCSS + `</style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🛰️ Certificate Inventory</h1>
+            <div class="subtitle">{{.Title}}</div>
+        </div>
+
+        <div class="panel">
+            <h2>📊 Summary</h2>
+            <div class="counts">
+                <div class="count"><strong>{{.Inventory.Probed}}</strong>Endpoints probed</div>
+                <div class="count OK"><strong>{{.Inventory.Responding}}</strong>Endpoints with TLS</div>
+                <div class="count"><strong>{{len .Inventory.Entries}}</strong>Distinct certificates</div>
+            </div>
+            <input type="text" id="filter" placeholder="Filter certificates and endpoints..." oninput="filterTargets(this.value)">
+            <table class="extensions-table" id="summary-table">
+                <thead>
+                    <tr>
+                        <th>Certificate</th>
+                        <th>Issuer</th>
+                        <th>Expires</th>
+                        <th>Chain</th>
+                        <th>Endpoints</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .Inventory.Entries}}
+                    {{$days := days .Certificate.NotAfter}}
+                    <tr>
+                        <td>
+                            {{.Certificate.Subject}}
+                            {{with .Certificate.SANs}}<br><small>{{range $i, $san := .}}{{if $i}}, {{end}}{{$san.Value}}{{end}}</small>{{end}}
+                            <br><code>{{.Fingerprint}}</code>
+                        </td>
+                        <td>{{.Certificate.Issuer}}</td>
+                        <td>{{.Certificate.NotAfter.Format "2006-01-02"}}<br><span class="status-badge {{if lt $days 0}}CRITICAL{{else if lt $days 30}}WARNING{{else}}OK{{end}}">{{if lt $days 0}}expired{{else}}{{$days}} days{{end}}</span></td>
+                        <td>{{if .ChainValid}}✓ Valid{{else}}<span class="status-badge CRITICAL">✗ Invalid</span>{{end}}</td>
+                        <td>
+                            {{range .Endpoints}}
+                            <div><code>{{.Address}}</code>{{if .StartTLS}} <small>(STARTTLS {{.StartTLS}})</small>{{end}}{{if not .ChainValid}} <span title="{{range $i, $e := .Errors}}{{if $i}}; {{end}}{{$e}}{{end}}">⚠️</span>{{end}}</div>
+                            {{end}}
+                        </td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+    </div>
+
+    <script>` + listScript + `</script>
+</body>
+</html>`
+
+
+const crlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>CRL Report - {{.Title}}</title>
+    <style>` + listCSS + `</style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>📄 Certificate Revocation List</h1>
+            <div class="subtitle">{{.Title}}</div>
+        </div>
+
+        {{range $i, $crl := .CRLs}}
+        {{with $crl}}
+        <div class="panel">
+            <h2>📋 {{if gt (len $.CRLs) 1}}CRL {{add $i 1}}: {{end}}Overview</h2>
+            <div class="counts">
+                <div class="count {{if .IsStale}}CRITICAL{{else}}OK{{end}}"><strong>{{if .NextUpdate.IsZero}}—{{else}}{{.NextUpdate.Format "2006-01-02"}}{{end}}</strong>{{if .IsStale}}Stale since{{else}}Next update{{end}}</div>
+                <div class="count"><strong>{{.RevokedCount}}</strong>Revoked certificates</div>
+                <div class="count {{if .SignatureValid}}OK{{else}}UNKNOWN{{end}}"><strong>{{if .SignatureValid}}✓{{else}}?{{end}}</strong>Signature</div>
+            </div>
+            <table class="extensions-table">
+                <tbody>
+                    <tr><th>Issuer</th><td>{{.Issuer}}</td></tr>
+                    <tr><th>This Update</th><td>{{.ThisUpdate.Format "2006-01-02 15:04:05 UTC"}}</td></tr>
+                    <tr><th>Next Update</th><td>{{if .NextUpdate.IsZero}}<em>not given</em>{{else}}{{.NextUpdate.Format "2006-01-02 15:04:05 UTC"}}{{if .IsStale}} <span class="status-badge CRITICAL">STALE</span>{{end}}{{end}}</td></tr>
+                    {{if .Number}}<tr><th>CRL Number</th><td>{{.Number}}</td></tr>{{end}}
+                    {{if .IsDelta}}<tr><th>Delta CRL</th><td>Updates base CRL {{if .BaseNumber}}number {{.BaseNumber}}{{else}}<em>unknown</em>{{end}}</td></tr>{{end}}
+                    <tr><th>Signature Algorithm</th><td>{{.SignatureAlgorithm}}</td></tr>
+                    {{if .AuthorityKeyID}}<tr><th>Authority Key ID</th><td><code>{{.AuthorityKeyID}}</code></td></tr>{{end}}
+                    <tr><th>Signature</th><td>{{if .SignatureValid}}✓ Verified by {{.SignedBy}}{{else}}Not verified ({{.SignatureError}}){{end}}</td></tr>
+                </tbody>
+            </table>
+        </div>
+
+        {{if .Extensions}}
+        <div class="panel">
+            <h2>🧩 Extensions</h2>
+            {{template "extensions" .Extensions}}
+        </div>
+        {{end}}
+
+        <div class="panel">
+            <h2>🚫 Revoked Certificates</h2>
+            {{if .Entries}}
+            <input type="text" class="filter" placeholder="Filter by serial, date or reason..." oninput="filterRows(this, this.value)">
+            <table class="extensions-table">
+                <thead>
+                    <tr>
+                        <th>Serial Number</th>
+                        <th>Revoked</th>
+                        <th>Reason</th>
+                        <th>Invalidity Date</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .Entries}}
+                    <tr>
+                        <td><code>{{.SerialHex}}</code><br><small>{{.SerialNumber}}</small></td>
+                        <td>{{.RevokedAt.Format "2006-01-02 15:04:05 UTC"}}</td>
+                        <td>{{if .Reason}}{{.Reason}}{{else}}<em>not given</em>{{end}}</td>
+                        <td>{{if not .InvalidityDate.IsZero}}{{.InvalidityDate.Format "2006-01-02 15:04:05 UTC"}}{{end}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+            {{else}}
+            <p>This CRL revokes no certificates.</p>
+            {{end}}
+        </div>
+        {{end}}
+        {{end}}
+    </div>
+
+    <script>` + listScript + `</script>
+</body>
+</html>` + extensionsTable
+
+const requestTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Certificate Request - {{.Title}}</title>
+    <style>` + listCSS + `</style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>📝 Certificate Signing Request</h1>
+            <div class="subtitle">{{.Title}}</div>
+        </div>
+
+        {{range $i, $req := .Requests}}
+        {{with $req}}
+        <div class="panel">
+            <h2>📋 {{if gt (len $.Requests) 1}}Request {{add $i 1}}: {{end}}{{.Subject}}</h2>
+            <div class="counts">
+                <div class="count {{if .SignatureValid}}OK{{else}}CRITICAL{{end}}"><strong>{{if .SignatureValid}}✓{{else}}✗{{end}}</strong>Signature</div>
+                <div class="count"><strong>{{len .SANs}}</strong>Subject Alternative Names</div>
+                <div class="count {{if .Lint}}WARNING{{else}}OK{{end}}"><strong>{{len .Lint}}</strong>Lint findings</div>
+            </div>
+            <table class="extensions-table">
+                <tbody>
+                    <tr><th>Subject</th><td>{{if .Subject}}{{.Subject}}{{else}}<em>empty</em>{{end}}</td></tr>
+                    <tr><th>Signature Algorithm</th><td>{{.SignatureAlg}}</td></tr>
+                    <tr><th>Signature</th><td>{{if .SignatureValid}}✓ Self-signature verifies with the requested key{{else}}<span class="status-badge CRITICAL">INVALID</span> {{.SignatureError}}{{end}}</td></tr>
+                    <tr><th>Public Key</th><td>{{.PublicKey.Algorithm}}{{if .PublicKey.Size}} {{.PublicKey.Size}} bits{{end}}{{if .PublicKey.Curve}} ({{.PublicKey.Curve}}){{end}}{{if .PublicKey.Exponent}}, exponent {{.PublicKey.Exponent}}{{end}}</td></tr>
+                    <tr><th>SPKI SHA-256</th><td><code>{{.PublicKey.SPKISHA256}}</code></td></tr>
+                    <tr><th>Subject Alternative Names</th><td>{{range .SANs}}<div><strong>{{.Type}}:</strong> {{.Value}}{{if .Note}} <small>({{.Note}})</small>{{end}}</div>{{else}}<em>none requested</em>{{end}}</td></tr>
+                </tbody>
+            </table>
+        </div>
+
+        {{if .Lint}}
+        <div class="panel">
+            <h2>📋 Lint Findings</h2>
+            <table class="extensions-table">
+                <thead>
+                    <tr>
+                        <th>Severity</th>
+                        <th>Rule</th>
+                        <th>Finding</th>
+                        <th>Reference</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .Lint}}
+                    <tr>
+                        <td><span class="status-badge {{if eq .Severity "error"}}CRITICAL{{else if eq .Severity "warning"}}WARNING{{else}}UNKNOWN{{end}}">{{.Severity}}</span></td>
+                        <td><code>{{.ID}}</code></td>
+                        <td>{{.Message}}</td>
+                        <td>{{.Citation}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
+        {{if .Extensions}}
+        <div class="panel">
+            <h2>🧩 Requested Extensions</h2>
+            {{template "extensions" .Extensions}}
+        </div>
+        {{end}}
+        {{end}}
+        {{end}}
+    </div>
+</body>
+</html>` + extensionsTable
+
+// extensionsTable lists decoded extensions in the CRL and request reports.
+const extensionsTable = `
+{{define "extensions"}}
+<table class="extensions-table">
+    <thead>
+        <tr>
+            <th>Name</th>
+            <th>OID</th>
+            <th>Critical</th>
+            <th>Value</th>
+        </tr>
+    </thead>
+    <tbody>
+        {{range .}}
+        <tr>
+            <td>{{.Name}}</td>
+            <td>{{.OID}}</td>
+            <td>{{if .Critical}}<span class="status-badge CRITICAL">CRITICAL</span>{{else}}No{{end}}</td>
+            <td>
+                {{if .Fields}}
+                {{range .Fields}}<div><strong>{{.Name}}:</strong> {{.Value}}</div>{{end}}
+                {{else}}
+                <code>{{.Value}}</code>
+                {{end}}
+            </td>
+        </tr>
+        {{end}}
+    </tbody>
+</table>
+{{end}}`
+
+// listCSS styles the batch, discovery, CRL and request reports.
+const listCSS = `
+        * {
+            margin: 0;
+            padding: 0;
+            box-sizing: border-box;
+        }
+
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            min-height: 100vh;
+        }
+
+        .container {
+            max-width: 1400px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+
+        .header, .panel {
+            background: rgba(255, 255, 255, 0.95);
+            padding: 25px;
+            border-radius: 15px;
+            margin-bottom: 30px;
+            box-shadow: 0 10px 30px rgba(0, 0, 0, 0.1);
+        }
+
+        .header {
+            text-align: center;
+        }
+
+        .header h1 {
+            color: #4a5568;
+            font-size: 2.5em;
+            margin-bottom: 10px;
+        }
+
+        .header .subtitle {
+            color: #718096;
+            font-size: 1.2em;
+        }
+
+        .panel h2 {
+            color: #4a5568;
+            margin-bottom: 15px;
+        }
+
+        .counts {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 15px;
+            margin-bottom: 20px;
+        }
+
+        .count {
+            flex: 1;
+            min-width: 140px;
+            padding: 15px;
+            border-radius: 10px;
+            background: #f7fafc;
+            border-left: 4px solid #cbd5e0;
+            text-align: center;
+        }
+
+        .count strong {
+            display: block;
+            font-size: 2em;
+        }
+
+        .count.OK { border-left-color: #48bb78; }
+        .count.WARNING { border-left-color: #ed8936; }
+        .count.CRITICAL { border-left-color: #f56565; }
+        .count.UNKNOWN { border-left-color: #a0aec0; }
+
+        .status-badge {
+            display: inline-block;
+            padding: 2px 10px;
+            border-radius: 12px;
+            font-size: 0.8em;
+            font-weight: bold;
+            color: white;
+            white-space: nowrap;
+        }
+
+        .status-badge.OK { background: #48bb78; }
+        .status-badge.WARNING { background: #ed8936; }
+        .status-badge.CRITICAL { background: #f56565; }
+        .status-badge.UNKNOWN { background: #a0aec0; }
+
+        #filter, .filter {
+            width: 100%;
+            padding: 10px;
+            border: 2px solid #e2e8f0;
+            border-radius: 8px;
+            font-size: 1em;
+        }
+
+        .extensions-table {
+            width: 100%;
+            border-collapse: collapse;
+            margin-top: 15px;
+        }
+
+        .extensions-table th,
+        .extensions-table td {
+            padding: 10px;
+            text-align: left;
+            border-bottom: 1px solid #e2e8f0;
+            vertical-align: top;
+        }
+
+        .extensions-table th {
+            background: #edf2f7;
+            font-weight: 600;
+            color: #4a5568;
+        }
+
+        .extensions-table tr:hover {
+            background: #f7fafc;
+        }
+
+        .extensions-table code {
+            word-break: break-all;
+            font-size: 0.85em;
+        }
+
+        .target {
+            border: 1px solid #e2e8f0;
+            border-radius: 10px;
+            margin-bottom: 15px;
+            padding: 15px;
+        }
+
+        .target summary {
+            cursor: pointer;
+            font-weight: 600;
+            color: #4a5568;
+        }
+
+        .target h4 {
+            color: #2b6cb0;
+            margin-top: 15px;
+        }
+
+        .problems {
+            margin: 10px 0 0 20px;
+            color: #c53030;
+        }
+    `
+
+const listScript = `
+        function filterTargets(text) {
+            const needle = text.toLowerCase();
+            document.querySelectorAll('#summary-table tbody tr').forEach(function(row) {
+                row.style.display = row.textContent.toLowerCase().includes(needle) ? '' : 'none';
+            });
+        }
+
+        // filterRows filters the table that follows input
+        function filterRows(input, text) {
+            const needle = text.toLowerCase();
+            input.nextElementSibling.querySelectorAll('tbody tr').forEach(function(row) {
+                row.style.display = row.textContent.toLowerCase().includes(needle) ? '' : 'none';
+            });
+        }
+    `
\ No newline at end of file
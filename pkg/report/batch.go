@@ -0,0 +1,165 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"certview/pkg/cert"
+)
+
+type BatchReport struct {
+	SchemaVersion string        `json:"schema_version"`
+	Title         string        `json:"title"`
+	GeneratedAt   time.Time     `json:"generated_at"`
+	Summary       BatchSummary  `json:"summary"`
+	Targets       []BatchTarget `json:"targets"`
+}
+
+type BatchSummary struct {
+	Total    int `json:"total"`
+	OK       int `json:"ok"`
+	Warning  int `json:"warning"`
+	Critical int `json:"critical"`
+	Unknown  int `json:"unknown"`
+}
+
+// BatchTarget status is "OK", "WARNING", "CRITICAL" or "UNKNOWN". Targets
+// that could not be analyzed have an error and no chain. Expires and
+// days_left describe the certificate that expires first.
+type BatchTarget struct {
+	Input    string     `json:"input"`
+	Line     int        `json:"line"`
+	Title    string     `json:"title"`
+	Status   string     `json:"status"`
+	Problems []string   `json:"problems"`
+	Error    string     `json:"error,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	DaysLeft *int       `json:"days_left,omitempty"`
+	Chain    *Chain     `json:"chain,omitempty"`
+}
+
+func GenerateBatchJSON(results []cert.BatchResult, title string) (string, error) {
+	data, err := json.MarshalIndent(NewBatchReport(results, title), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func NewBatchReport(results []cert.BatchResult, title string) *BatchReport {
+	summary := cert.SummarizeBatch(results)
+	batch := &BatchReport{
+		SchemaVersion: SchemaVersion,
+		Title:         title,
+		GeneratedAt:   time.Now().UTC(),
+		Summary: BatchSummary{
+			Total:    summary.Total,
+			OK:       summary.OK,
+			Warning:  summary.Warning,
+			Critical: summary.Critical,
+			Unknown:  summary.Unknown,
+		},
+		Targets: make([]BatchTarget, len(results)),
+	}
+
+	for i, result := range results {
+		target := BatchTarget{
+			Input:    result.Target.Input,
+			Line:     result.Target.Line,
+			Title:    result.Title,
+			Status:   cert.StatusName(result.Status()),
+			Problems: []string{},
+			Error:    result.Error,
+		}
+		if result.Check != nil {
+			for _, problem := range result.Check.Problems {
+				target.Problems = append(target.Problems, problem.Message)
+			}
+			expires, daysLeft := result.Check.Expiry, result.Check.DaysLeft
+			target.Expires, target.DaysLeft = &expires, &daysLeft
+		}
+		if result.Chain != nil {
+			chain := newChain(result.Chain)
+			target.Chain = &chain
+		}
+		batch.Targets[i] = target
+	}
+
+	return batch
+}
+
+var batchCSVHeader = []string{
+	"target", "status", "problems", "error", "chain_valid", "hostname_matched",
+	"certificate_index", "subject", "issuer", "serial_number", "not_before", "not_after",
+	"days_left", "public_key", "signature_algorithm", "sha256",
+}
+
+// GenerateBatchCSV writes one row per certificate, repeating the target
+// columns. Targets that could not be analyzed get a single row with the
+// certificate columns left empty.
+func GenerateBatchCSV(results []cert.BatchResult) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(batchCSVHeader); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	for _, result := range results {
+		var problems []string
+		if result.Check != nil {
+			for _, problem := range result.Check.Problems {
+				problems = append(problems, problem.Message)
+			}
+		}
+		target := []string{
+			result.Target.Input,
+			cert.StatusName(result.Status()),
+			strings.Join(problems, "; "),
+			result.Error,
+		}
+
+		if result.Chain == nil {
+			row := append(target, make([]string, len(batchCSVHeader)-len(target))...)
+			if err := w.Write(row); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		hostname := ""
+		if result.Chain.Hostname != nil {
+			hostname = strconv.FormatBool(result.Chain.Hostname.Matched)
+		}
+		target = append(target, strconv.FormatBool(result.Chain.IsValid), hostname)
+
+		for i, info := range result.Chain.Certificates {
+			publicKey := info.PublicKey.Algorithm
+			if info.PublicKey.Size > 0 {
+				publicKey += " " + strconv.Itoa(info.PublicKey.Size)
+			}
+			row := append(append([]string(nil), target...),
+				strconv.Itoa(i),
+				info.Subject,
+				info.Issuer,
+				info.SerialNumber,
+				info.NotBefore.UTC().Format(time.RFC3339),
+				info.NotAfter.UTC().Format(time.RFC3339),
+				strconv.Itoa(daysLeft(info.NotAfter, now)),
+				publicKey,
+				info.SignatureAlg,
+				info.Identifiers.SHA256,
+			)
+			if err := w.Write(row); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	w.Flush()
+	return buf.String(), w.Error()
+}
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"encoding/json"
+	"time"
+
+	"certview/pkg/cert"
+)
+
+// CRLReport is the report of CRL files analyzed on their own.
+type CRLReport struct {
+	SchemaVersion string       `json:"schema_version"`
+	Title         string       `json:"title"`
+	GeneratedAt   time.Time    `json:"generated_at"`
+	CRLs          []CRLDetails `json:"crls"`
+}
+
+// CRLDetails extends the chain report's CRL summary with the full content.
+type CRLDetails struct {
+	CRL
+	SignatureAlgorithm  string               `json:"signature_algorithm"`
+	AuthorityKeyID      string               `json:"authority_key_id,omitempty"`
+	Extensions          []Extension          `json:"extensions"`
+	RevokedCertificates []RevokedCertificate `json:"revoked_certificates"`
+}
+
+// RevokedCertificate serial_number is decimal, as for certificates. Reason
+// is omitted when the entry carries no reason code.
+type RevokedCertificate struct {
+	SerialNumber   string     `json:"serial_number"`
+	SerialHex      string     `json:"serial_hex"`
+	RevokedAt      time.Time  `json:"revoked_at"`
+	Reason         string     `json:"reason,omitempty"`
+	InvalidityDate *time.Time `json:"invalidity_date,omitempty"`
+}
+
+func GenerateCRLJSON(crls []*cert.CRLReport, title string) (string, error) {
+	data, err := json.MarshalIndent(NewCRLReport(crls, title), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func NewCRLReport(crls []*cert.CRLReport, title string) *CRLReport {
+	report := &CRLReport{
+		SchemaVersion: SchemaVersion,
+		Title:         title,
+		GeneratedAt:   time.Now().UTC(),
+		CRLs:          make([]CRLDetails, len(crls)),
+	}
+
+	for i, crl := range crls {
+		details := CRLDetails{
+			CRL:                 newCRL(crl.CRLInfo),
+			SignatureAlgorithm:  crl.SignatureAlgorithm,
+			AuthorityKeyID:      crl.AuthorityKeyID,
+			Extensions:          newExtensions(crl.Extensions),
+			RevokedCertificates: make([]RevokedCertificate, len(crl.Entries)),
+		}
+		for j, entry := range crl.Entries {
+			details.RevokedCertificates[j] = RevokedCertificate{
+				SerialNumber:   entry.SerialNumber,
+				SerialHex:      entry.SerialHex,
+				RevokedAt:      entry.RevokedAt,
+				Reason:         entry.Reason,
+				InvalidityDate: optionalTime(entry.InvalidityDate),
+			}
+		}
+		report.CRLs[i] = details
+	}
+
+	return report
+}
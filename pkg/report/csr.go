@@ -0,0 +1,77 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"time"
+
+	"certview/pkg/cert"
+)
+
+// RequestReport is the report of certificate signing requests.
+type RequestReport struct {
+	SchemaVersion string    `json:"schema_version"`
+	Title         string    `json:"title"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	Requests      []Request `json:"requests"`
+}
+
+// Request fields mirror Certificate; extensions are the ones requested.
+// Lint certificate_index is the index of the request.
+type Request struct {
+	Index              int           `json:"index"`
+	Subject            string        `json:"subject"`
+	SANs               []string      `json:"sans"`
+	SubjectAltNames    []SAN         `json:"subject_alt_names"`
+	SignatureAlgorithm string        `json:"signature_algorithm"`
+	SignatureValid     bool          `json:"signature_valid"`
+	SignatureError     string        `json:"signature_error,omitempty"`
+	PublicKey          PublicKey     `json:"public_key"`
+	Extensions         []Extension   `json:"extensions"`
+	Lint               []LintFinding `json:"lint"`
+	PEM                string        `json:"pem"`
+}
+
+func GenerateRequestJSON(requests []cert.RequestInfo, title string) (string, error) {
+	data, err := json.MarshalIndent(NewRequestReport(requests, title), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func NewRequestReport(requests []cert.RequestInfo, title string) *RequestReport {
+	report := &RequestReport{
+		SchemaVersion: SchemaVersion,
+		Title:         title,
+		GeneratedAt:   time.Now().UTC(),
+		Requests:      make([]Request, len(requests)),
+	}
+
+	for i, info := range requests {
+		r := Request{
+			Index:              i,
+			Subject:            info.Subject,
+			SANs:               []string{},
+			SubjectAltNames:    []SAN{},
+			SignatureAlgorithm: info.SignatureAlg,
+			SignatureValid:     info.SignatureValid,
+			SignatureError:     info.SignatureError,
+			PublicKey:          newPublicKey(info.PublicKey),
+			Extensions:         newExtensions(info.Extensions),
+			Lint:               newLintFindings(info.Lint),
+		}
+		for _, san := range info.SANs {
+			if san.Type == "DNS" {
+				r.SANs = append(r.SANs, san.Value)
+			}
+			r.SubjectAltNames = append(r.SubjectAltNames, SAN{Type: san.Type, Value: san.Value, Note: san.Note})
+		}
+		if info.Request != nil {
+			r.PEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: info.Request.Raw}))
+		}
+		report.Requests[i] = r
+	}
+
+	return report
+}
@@ -0,0 +1,147 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"certview/pkg/cert"
+)
+
+// Inventory is the report of a discovery run. Certificates are distinct
+// leaf certificates ordered by expiry.
+type Inventory struct {
+	SchemaVersion string                 `json:"schema_version"`
+	Title         string                 `json:"title"`
+	GeneratedAt   time.Time              `json:"generated_at"`
+	Probed        int                    `json:"probed"`
+	Responding    int                    `json:"responding"`
+	Certificates  []InventoryCertificate `json:"certificates"`
+}
+
+// InventoryCertificate chain_valid is true when every endpoint served a
+// valid chain for the certificate.
+type InventoryCertificate struct {
+	Fingerprint  string              `json:"fingerprint"`
+	Subject      string              `json:"subject"`
+	Issuer       string              `json:"issuer"`
+	SerialNumber string              `json:"serial_number"`
+	NotBefore    time.Time           `json:"not_before"`
+	NotAfter     time.Time           `json:"not_after"`
+	DaysLeft     int                 `json:"days_left"`
+	Expired      bool                `json:"expired"`
+	SANs         []string            `json:"sans"`
+	ChainValid   bool                `json:"chain_valid"`
+	Endpoints    []InventoryEndpoint `json:"endpoints"`
+}
+
+type InventoryEndpoint struct {
+	Address    string   `json:"address"`
+	StartTLS   string   `json:"starttls,omitempty"`
+	ChainValid bool     `json:"chain_valid"`
+	Errors     []string `json:"errors"`
+}
+
+func GenerateInventoryJSON(inventory *cert.Inventory, title string) (string, error) {
+	data, err := json.MarshalIndent(NewInventory(inventory, title), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func NewInventory(inventory *cert.Inventory, title string) *Inventory {
+	report := &Inventory{
+		SchemaVersion: SchemaVersion,
+		Title:         title,
+		GeneratedAt:   time.Now().UTC(),
+		Probed:        inventory.Probed,
+		Responding:    inventory.Responding,
+		Certificates:  make([]InventoryCertificate, len(inventory.Entries)),
+	}
+
+	now := time.Now()
+	for i, entry := range inventory.Entries {
+		info := entry.Certificate
+		c := InventoryCertificate{
+			Fingerprint:  entry.Fingerprint,
+			Subject:      info.Subject,
+			Issuer:       info.Issuer,
+			SerialNumber: info.SerialNumber,
+			NotBefore:    info.NotBefore,
+			NotAfter:     info.NotAfter,
+			DaysLeft:     daysLeft(info.NotAfter, now),
+			Expired:      info.IsExpired,
+			SANs:         []string{},
+			ChainValid:   entry.ChainValid(),
+			Endpoints:    make([]InventoryEndpoint, len(entry.Endpoints)),
+		}
+		for _, san := range info.SANs {
+			c.SANs = append(c.SANs, san.Value)
+		}
+		for j, endpoint := range entry.Endpoints {
+			c.Endpoints[j] = InventoryEndpoint{
+				Address:    endpoint.Address,
+				StartTLS:   endpoint.StartTLS,
+				ChainValid: endpoint.ChainValid,
+				Errors:     nonNil(endpoint.Errors),
+			}
+		}
+		report.Certificates[i] = c
+	}
+
+	return report
+}
+
+var inventoryCSVHeader = []string{
+	"fingerprint", "subject", "issuer", "serial_number", "not_after", "days_left",
+	"sans", "endpoint", "starttls", "chain_valid", "errors",
+}
+
+// GenerateInventoryCSV writes one row per certificate and endpoint.
+func GenerateInventoryCSV(inventory *cert.Inventory) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(inventoryCSVHeader); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	for _, entry := range inventory.Entries {
+		info := entry.Certificate
+		var sans []string
+		for _, san := range info.SANs {
+			sans = append(sans, san.Value)
+		}
+		for _, endpoint := range entry.Endpoints {
+			err := w.Write([]string{
+				entry.Fingerprint,
+				info.Subject,
+				info.Issuer,
+				info.SerialNumber,
+				info.NotAfter.UTC().Format(time.RFC3339),
+				strconv.Itoa(daysLeft(info.NotAfter, now)),
+				strings.Join(sans, " "),
+				endpoint.Address,
+				endpoint.StartTLS,
+				strconv.FormatBool(endpoint.ChainValid),
+				strings.Join(endpoint.Errors, "; "),
+			})
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// daysLeft counts whole days until t, negative once it has passed.
+func daysLeft(t, now time.Time) int {
+	return int(math.Floor(t.Sub(now).Hours() / 24))
+}
@@ -0,0 +1,763 @@
+package report
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"time"
+
+	"certview/pkg/cert"
+)
+
+// SchemaVersion is bumped whenever a field is removed or changes meaning.
+// Adding new fields does not change the version.
+//
+// Version 2: extensions[].value is the decoded text; the hex it used to
+// hold is in extensions[].raw.
+const SchemaVersion = "2"
+
+type Report struct {
+	SchemaVersion string    `json:"schema_version"`
+	Title         string    `json:"title"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	Chain         Chain     `json:"chain"`
+}
+
+type Chain struct {
+	Valid        bool             `json:"valid"`
+	Errors       []string         `json:"errors"`
+	Certificates []Certificate    `json:"certificates"`
+	CrossSigning []CrossSignGroup `json:"cross_signing"`
+	ChainPaths   []ChainPath      `json:"chain_paths"`
+	Graph        *Graph           `json:"graph,omitempty"`
+	CRLs         []CRL            `json:"crls"`
+	PrivateKey   *PrivateKey      `json:"private_key,omitempty"`
+	Trust        *Trust           `json:"trust,omitempty"`
+	Leaves       []int            `json:"leaves"`
+	Findings     []Finding        `json:"findings"`
+	Lint         []LintFinding    `json:"lint"`
+	Connection   *Connection      `json:"connection,omitempty"`
+	Handshake    *Handshake       `json:"handshake,omitempty"`
+	Scan         *Scan            `json:"scan,omitempty"`
+	Hostname     *Hostname        `json:"hostname,omitempty"`
+	OCSP         []OCSPResponse   `json:"ocsp"`
+	CRLChecks    []CRLCheck       `json:"crl_checks"`
+	CTLogList    *CTLogList       `json:"ct_log_list,omitempty"`
+}
+
+// SANs holds only the DNS names; SubjectAltNames lists every entry with its
+// type ("DNS", "IP", "Email", "URI", "otherName", "Directory Name", ...).
+type Certificate struct {
+	Index              int         `json:"index"`
+	Subject            string      `json:"subject"`
+	Issuer             string      `json:"issuer"`
+	SerialNumber       string      `json:"serial_number"`
+	NotBefore          time.Time   `json:"not_before"`
+	NotAfter           time.Time   `json:"not_after"`
+	Expired            bool        `json:"expired"`
+	IsCA               bool        `json:"is_ca"`
+	KeyUsage           []string    `json:"key_usage"`
+	ExtKeyUsage        []string    `json:"ext_key_usage"`
+	SANs               []string    `json:"sans"`
+	SubjectAltNames    []SAN       `json:"subject_alt_names"`
+	SignatureAlgorithm string      `json:"signature_algorithm"`
+	PublicKeyAlgorithm string      `json:"public_key_algorithm"`
+	PublicKeySize      int         `json:"public_key_size"`
+	PublicKey          PublicKey   `json:"public_key"`
+	Identifiers        Identifiers `json:"identifiers"`
+	Extensions         []Extension `json:"extensions"`
+	SCTs               []SCT       `json:"scts"`
+	PEM                string      `json:"pem"`
+	DER                []byte      `json:"der"`
+}
+
+// PublicKey curve is set for EC and EdDSA keys, exponent for RSA keys.
+// SPKISHA256 is the base64 SHA-256 of the SubjectPublicKeyInfo (HPKP pin).
+type PublicKey struct {
+	Algorithm  string `json:"algorithm"`
+	Size       int    `json:"size"`
+	Curve      string `json:"curve,omitempty"`
+	Exponent   int    `json:"exponent,omitempty"`
+	SPKISHA256 string `json:"spki_sha256"`
+}
+
+// Identifiers are colon-separated upper-case hex, except subject_hash and
+// issuer_hash which match `openssl x509 -subject_hash` / `-issuer_hash`.
+// The key IDs are empty when the extension is absent.
+type Identifiers struct {
+	SHA1           string `json:"sha1"`
+	SHA256         string `json:"sha256"`
+	SPKISHA1       string `json:"spki_sha1"`
+	SPKISHA256     string `json:"spki_sha256"`
+	SubjectHash    string `json:"subject_hash"`
+	IssuerHash     string `json:"issuer_hash"`
+	SubjectKeyID   string `json:"subject_key_id"`
+	AuthorityKeyID string `json:"authority_key_id"`
+}
+
+// SAN notes carry the otherName type, the SPIFFE trust domain or the
+// Unicode form of an internationalized DNS name.
+type SAN struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Note  string `json:"note,omitempty"`
+}
+
+type Extension struct {
+	OID      string           `json:"oid"`
+	Name     string           `json:"name"`
+	Critical bool             `json:"critical"`
+	Value    string           `json:"value"`
+	Fields   []ExtensionField `json:"fields"`
+	Raw      string           `json:"raw"`
+}
+
+type ExtensionField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CTLogList is the log list SCTs were checked against. Stale lists miss
+// newer logs, so their SCTs cannot be attributed or verified.
+type CTLogList struct {
+	Name      string     `json:"name"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Stale     bool       `json:"stale"`
+}
+
+// SCT log fields are empty when the log is not in the CT log list.
+type SCT struct {
+	Version            int       `json:"version"`
+	LogID              string    `json:"log_id"`
+	LogDescription     string    `json:"log_description,omitempty"`
+	LogOperator        string    `json:"log_operator,omitempty"`
+	LogURL             string    `json:"log_url,omitempty"`
+	Timestamp          time.Time `json:"timestamp"`
+	HashAlgorithm      string    `json:"hash_algorithm"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	SignatureValid     bool      `json:"signature_valid"`
+	SignatureError     string    `json:"signature_error,omitempty"`
+}
+
+// CrossSignGroup and ChainPath refer to certificates by their index in
+// Chain.Certificates. Certificates that are not part of the input have
+// an index of -1.
+type CrossSignGroup struct {
+	Subject      string    `json:"subject"`
+	Certificates []CertRef `json:"certificates"`
+}
+
+// CrossSignEdges[i] is true when certificates[i] is cross-signed by
+// certificates[i+1].
+type ChainPath struct {
+	Description    string    `json:"description"`
+	Complete       bool      `json:"complete"`
+	Trusted        bool      `json:"trusted"`
+	Anchor         string    `json:"anchor,omitempty"`
+	CrossSigned    bool      `json:"cross_signed"`
+	CrossSignEdges []bool    `json:"cross_sign_edges"`
+	Certificates   []CertRef `json:"certificates"`
+}
+
+// Graph edges point from a certificate node to the node of its issuer.
+// Nodes with an index of -1 are anchors taken from the trust store.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	ID      int    `json:"id"`
+	Index   int    `json:"index"`
+	Subject string `json:"subject"`
+	Issuer  string `json:"issuer"`
+	Anchor  bool   `json:"anchor"`
+	Trusted bool   `json:"trusted"`
+}
+
+type GraphEdge struct {
+	From      int  `json:"from"`
+	To        int  `json:"to"`
+	CrossSign bool `json:"cross_sign"`
+}
+
+// CRL source is the URL it was downloaded from, omitted for supplied CRLs.
+// issuer_index is -1 when the CRL was verified with a trust anchor
+// (signed_by) or not verified at all.
+type CRL struct {
+	Source         string     `json:"source,omitempty"`
+	Issuer         string     `json:"issuer"`
+	ThisUpdate     time.Time  `json:"this_update"`
+	NextUpdate     *time.Time `json:"next_update,omitempty"`
+	Number         string     `json:"number,omitempty"`
+	Delta          bool       `json:"delta"`
+	BaseNumber     string     `json:"base_number,omitempty"`
+	RevokedCount   int        `json:"revoked_count"`
+	Stale          bool       `json:"stale"`
+	IssuerIndex    int        `json:"issuer_index"`
+	SignedBy       string     `json:"signed_by,omitempty"`
+	SignatureValid bool       `json:"signature_valid"`
+	SignatureError string     `json:"signature_error,omitempty"`
+	PEM            string     `json:"pem"`
+}
+
+// CRLCheck status is "good", "revoked" or "unknown"; crl_index points into
+// crls and is -1 when no CRL from the issuer was available.
+type CRLCheck struct {
+	CertificateIndex int        `json:"certificate_index"`
+	Status           string     `json:"status"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	Reason           string     `json:"reason,omitempty"`
+	CRLIndex         int        `json:"crl_index"`
+	Stale            bool       `json:"stale"`
+	Error            string     `json:"error,omitempty"`
+}
+
+// Connection is present for chains fetched from a server. SNI is empty when
+// no server name was sent.
+type Connection struct {
+	Target   string `json:"target"`
+	Address  string `json:"address"`
+	SNI      string `json:"sni"`
+	StartTLS string `json:"starttls,omitempty"`
+}
+
+// Hostname is present when a host was given or the chain was fetched.
+// MatchType is "DNS SAN", "Wildcard DNS SAN", "IP SAN" or "Common Name";
+// deprecated marks a match that relied on the Subject Common Name.
+type Hostname struct {
+	Host             string `json:"host"`
+	Normalized       string `json:"normalized"`
+	CertificateIndex int    `json:"certificate_index"`
+	Matched          bool   `json:"matched"`
+	MatchedName      string `json:"matched_name,omitempty"`
+	MatchType        string `json:"match_type,omitempty"`
+	Deprecated       bool   `json:"deprecated"`
+	Message          string `json:"message"`
+}
+
+// OCSPResponse source is "stapled" or "responder"; status is "good",
+// "revoked" or "unknown", and empty with an error when no usable response
+// was obtained. Times are omitted when the response did not carry them.
+type OCSPResponse struct {
+	Source           string     `json:"source"`
+	CertificateIndex int        `json:"certificate_index"`
+	URL              string     `json:"url,omitempty"`
+	Status           string     `json:"status"`
+	ProducedAt       *time.Time `json:"produced_at,omitempty"`
+	ThisUpdate       *time.Time `json:"this_update,omitempty"`
+	NextUpdate       *time.Time `json:"next_update,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	Reason           string     `json:"reason,omitempty"`
+	Signer           string     `json:"signer,omitempty"`
+	Delegated        bool       `json:"delegated"`
+	SignatureValid   bool       `json:"signature_valid"`
+	Stale            bool       `json:"stale"`
+	Error            string     `json:"error,omitempty"`
+}
+
+// Handshake is present for chains fetched from a server. KeyExchangeGroup
+// is empty for RSA key exchange.
+type Handshake struct {
+	Version          string `json:"version"`
+	CipherSuite      string `json:"cipher_suite"`
+	KeyExchangeGroup string `json:"key_exchange_group"`
+	PostQuantum      bool   `json:"post_quantum"`
+	ALPN             string `json:"alpn"`
+	OCSPStaple       []byte `json:"ocsp_staple,omitempty"`
+	Resumption       bool   `json:"resumption"`
+	ResumptionProbed bool   `json:"resumption_probed"`
+	SCTs             []SCT  `json:"scts"`
+}
+
+// Scan lists every probed version and group; CipherSuites holds only the
+// accepted suites.
+type Scan struct {
+	Grade        string        `json:"grade"`
+	Issues       []string      `json:"issues"`
+	Versions     []ScanVersion `json:"versions"`
+	CipherSuites []ScanCipher  `json:"cipher_suites"`
+	Groups       []ScanGroup   `json:"groups"`
+}
+
+type ScanVersion struct {
+	Version   string `json:"version"`
+	Supported bool   `json:"supported"`
+	Legacy    bool   `json:"legacy"`
+}
+
+type ScanCipher struct {
+	Name           string `json:"name"`
+	Version        string `json:"version"`
+	ForwardSecrecy bool   `json:"forward_secrecy"`
+	Weak           bool   `json:"weak"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+type ScanGroup struct {
+	Name        string `json:"name"`
+	PostQuantum bool   `json:"post_quantum"`
+	Supported   bool   `json:"supported"`
+}
+
+// LintFinding severities are "error", "warning" and "notice".
+type LintFinding struct {
+	ID               string `json:"id"`
+	Severity         string `json:"severity"`
+	Citation         string `json:"citation"`
+	CertificateIndex int    `json:"certificate_index"`
+	Message          string `json:"message"`
+}
+
+// Finding kinds are "misordered", "duplicate", "unused" and "missing".
+type Finding struct {
+	Kind             string `json:"kind"`
+	CertificateIndex int    `json:"certificate_index"`
+	Message          string `json:"message"`
+}
+
+type Trust struct {
+	Store    string      `json:"store"`
+	Verified bool        `json:"verified"`
+	Paths    []TrustPath `json:"paths"`
+	Failures []string    `json:"failures"`
+}
+
+type TrustPath struct {
+	Anchor        string    `json:"anchor"`
+	AnchorInChain bool      `json:"anchor_in_chain"`
+	Certificates  []CertRef `json:"certificates"`
+}
+
+// PrivateKey only describes the key; key material is never exported. The
+// public key fields are those of the certificates' public_key.
+type PrivateKey struct {
+	Algorithm        string `json:"algorithm"`
+	Size             int    `json:"size"`
+	Curve            string `json:"curve,omitempty"`
+	Exponent         int    `json:"exponent,omitempty"`
+	SPKISHA256       string `json:"spki_sha256,omitempty"`
+	MatchesLeaf      bool   `json:"matches_leaf"`
+	CertificateIndex int    `json:"certificate_index"`
+}
+
+type CertRef struct {
+	Index   int    `json:"index"`
+	Subject string `json:"subject"`
+	Issuer  string `json:"issuer"`
+}
+
+func GenerateJSON(chainInfo *cert.ChainInfo, title string) (string, error) {
+	data, err := json.MarshalIndent(NewReport(chainInfo, title), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func NewReport(chainInfo *cert.ChainInfo, title string) *Report {
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		Title:         title,
+		GeneratedAt:   time.Now().UTC(),
+		Chain:         newChain(chainInfo),
+	}
+}
+
+func newChain(chainInfo *cert.ChainInfo) Chain {
+	chain := Chain{
+		Valid:        chainInfo.IsValid,
+		Errors:       nonNil(chainInfo.Errors),
+		Certificates: make([]Certificate, len(chainInfo.Certificates)),
+		CrossSigning: []CrossSignGroup{},
+		ChainPaths:   []ChainPath{},
+		CRLs:         []CRL{},
+		Leaves:       chainInfo.Leaves,
+		Findings:     []Finding{},
+		Lint:         []LintFinding{},
+		OCSP:         []OCSPResponse{},
+		CRLChecks:    []CRLCheck{},
+	}
+	if chain.Leaves == nil {
+		chain.Leaves = []int{}
+	}
+
+	if hs := chainInfo.Handshake; hs != nil {
+		chain.Handshake = &Handshake{
+			Version:          hs.Version,
+			CipherSuite:      hs.CipherSuite,
+			KeyExchangeGroup: hs.Group,
+			PostQuantum:      hs.PostQuantum,
+			ALPN:             hs.ALPN,
+			OCSPStaple:       hs.OCSPStaple,
+			Resumption:       hs.Resumption,
+			ResumptionProbed: hs.ResumptionProbed,
+			SCTs:             newSCTs(hs.SCTs),
+		}
+	}
+
+	if scan := chainInfo.Scan; scan != nil {
+		chain.Scan = newScan(scan)
+	}
+
+	if conn := chainInfo.Connection; conn != nil {
+		chain.Connection = &Connection{
+			Target:   conn.Target,
+			Address:  conn.Address,
+			SNI:      conn.ServerName,
+			StartTLS: conn.StartTLS,
+		}
+	}
+
+	for _, info := range chainInfo.OCSP {
+		chain.OCSP = append(chain.OCSP, OCSPResponse{
+			Source:           info.Source,
+			CertificateIndex: info.Certificate,
+			URL:              info.URL,
+			Status:           info.Status,
+			ProducedAt:       optionalTime(info.ProducedAt),
+			ThisUpdate:       optionalTime(info.ThisUpdate),
+			NextUpdate:       optionalTime(info.NextUpdate),
+			RevokedAt:        optionalTime(info.RevokedAt),
+			Reason:           info.Reason,
+			Signer:           info.Signer,
+			Delegated:        info.Delegated,
+			SignatureValid:   info.SignatureValid,
+			Stale:            info.IsStale,
+			Error:            info.Error,
+		})
+	}
+
+	if h := chainInfo.Hostname; h != nil {
+		chain.Hostname = &Hostname{
+			Host:             h.Host,
+			Normalized:       h.Normalized,
+			CertificateIndex: h.Certificate,
+			Matched:          h.Matched,
+			MatchedName:      h.MatchedName,
+			MatchType:        h.MatchType,
+			Deprecated:       h.Deprecated,
+			Message:          h.Message,
+		}
+	}
+
+	for _, finding := range chainInfo.Findings {
+		chain.Findings = append(chain.Findings, Finding{
+			Kind:             finding.Kind,
+			CertificateIndex: finding.Certificate,
+			Message:          finding.Message,
+		})
+	}
+
+	chain.Lint = newLintFindings(chainInfo.Lint)
+
+	for i, info := range chainInfo.Certificates {
+		chain.Certificates[i] = newCertificate(i, info)
+	}
+
+	for _, crossSign := range chainInfo.CrossSigning {
+		group := CrossSignGroup{Subject: crossSign.Subject}
+		for _, c := range crossSign.Certificates {
+			group.Certificates = append(group.Certificates, newCertRef(chainInfo, c))
+		}
+		chain.CrossSigning = append(chain.CrossSigning, group)
+	}
+
+	for _, path := range chainInfo.ChainPaths {
+		p := ChainPath{
+			Description:    path.Description,
+			Complete:       path.IsComplete,
+			Trusted:        path.IsTrusted,
+			Anchor:         path.Anchor,
+			CrossSigned:    path.CrossSigned,
+			CrossSignEdges: path.CrossSigns,
+			Certificates:   []CertRef{},
+		}
+		if p.CrossSignEdges == nil {
+			p.CrossSignEdges = []bool{}
+		}
+		for _, info := range path.Path {
+			p.Certificates = append(p.Certificates, newCertRef(chainInfo, info.Certificate))
+		}
+		chain.ChainPaths = append(chain.ChainPaths, p)
+	}
+
+	if graph := chainInfo.Graph; graph != nil {
+		chain.Graph = &Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+		for id, node := range graph.Nodes {
+			chain.Graph.Nodes = append(chain.Graph.Nodes, GraphNode{
+				ID:      id,
+				Index:   node.Index,
+				Subject: node.Certificate.Subject,
+				Issuer:  node.Certificate.Issuer,
+				Anchor:  node.IsAnchor,
+				Trusted: node.IsTrusted,
+			})
+		}
+		for _, edge := range graph.Edges {
+			chain.Graph.Edges = append(chain.Graph.Edges, GraphEdge{
+				From:      edge.From,
+				To:        edge.To,
+				CrossSign: edge.CrossSign,
+			})
+		}
+	}
+
+	for _, info := range chainInfo.CRLs {
+		chain.CRLs = append(chain.CRLs, newCRL(info))
+	}
+	for _, check := range chainInfo.CRLChecks {
+		chain.CRLChecks = append(chain.CRLChecks, CRLCheck{
+			CertificateIndex: check.Certificate,
+			Status:           check.Status,
+			RevokedAt:        optionalTime(check.RevokedAt),
+			Reason:           check.Reason,
+			CRLIndex:         check.CRL,
+			Stale:            check.Stale,
+			Error:            check.Error,
+		})
+	}
+
+	if trust := chainInfo.Trust; trust != nil {
+		chain.Trust = &Trust{
+			Store:    trust.Store,
+			Verified: trust.Verified,
+			Paths:    []TrustPath{},
+			Failures: nonNil(trust.Failures),
+		}
+		for _, path := range trust.Paths {
+			p := TrustPath{
+				Anchor:        path.Anchor,
+				AnchorInChain: path.AnchorInChain,
+				Certificates:  []CertRef{},
+			}
+			for _, info := range path.Path {
+				p.Certificates = append(p.Certificates, newCertRef(chainInfo, info.Certificate))
+			}
+			chain.Trust.Paths = append(chain.Trust.Paths, p)
+		}
+	}
+
+	if list := chainInfo.CTLogList; list != nil {
+		chain.CTLogList = &CTLogList{
+			Name:      list.Name,
+			Timestamp: optionalTime(list.Timestamp),
+			Stale:     list.Stale,
+		}
+	}
+
+	if key := chainInfo.PrivateKey; key != nil {
+		chain.PrivateKey = &PrivateKey{
+			Algorithm:        key.PublicKey.Algorithm,
+			Size:             key.PublicKey.Size,
+			Curve:            key.PublicKey.Curve,
+			Exponent:         key.PublicKey.Exponent,
+			SPKISHA256:       key.PublicKey.SPKISHA256,
+			MatchesLeaf:      key.MatchesLeaf,
+			CertificateIndex: key.CertificateIndex,
+		}
+	}
+
+	return chain
+}
+
+func newCRL(info cert.CRLInfo) CRL {
+	c := CRL{
+		Source:         info.Source,
+		Issuer:         info.Issuer,
+		ThisUpdate:     info.ThisUpdate,
+		Number:         info.Number,
+		Delta:          info.IsDelta,
+		BaseNumber:     info.BaseNumber,
+		RevokedCount:   info.RevokedCount,
+		Stale:          info.IsStale,
+		IssuerIndex:    info.IssuerIndex,
+		SignedBy:       info.SignedBy,
+		SignatureValid: info.SignatureValid,
+		SignatureError: info.SignatureError,
+	}
+	if !info.NextUpdate.IsZero() {
+		nextUpdate := info.NextUpdate
+		c.NextUpdate = &nextUpdate
+	}
+	if info.CRL != nil {
+		c.PEM = string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: info.CRL.Raw}))
+	}
+	return c
+}
+
+func newCertificate(index int, info cert.CertificateInfo) Certificate {
+	c := Certificate{
+		Index:              index,
+		Subject:            info.Subject,
+		Issuer:             info.Issuer,
+		SerialNumber:       info.SerialNumber,
+		NotBefore:          info.NotBefore,
+		NotAfter:           info.NotAfter,
+		Expired:            info.IsExpired,
+		IsCA:               info.IsCA,
+		KeyUsage:           nonNil(info.KeyUsage),
+		ExtKeyUsage:        nonNil(info.ExtKeyUsage),
+		SANs:               []string{},
+		SubjectAltNames:    []SAN{},
+		SignatureAlgorithm: info.SignatureAlg,
+		PublicKeyAlgorithm: info.PublicKeyAlg,
+		PublicKeySize:      info.PublicKeySize,
+		PublicKey:          newPublicKey(info.PublicKey),
+		Identifiers: Identifiers{
+			SHA1:           info.Identifiers.SHA1,
+			SHA256:         info.Identifiers.SHA256,
+			SPKISHA1:       info.Identifiers.SPKISHA1,
+			SPKISHA256:     info.Identifiers.SPKISHA256,
+			SubjectHash:    info.Identifiers.SubjectHash,
+			IssuerHash:     info.Identifiers.IssuerHash,
+			SubjectKeyID:   info.Identifiers.SubjectKeyID,
+			AuthorityKeyID: info.Identifiers.AuthorityKeyID,
+		},
+		Extensions: []Extension{},
+	}
+
+	for _, san := range info.SANs {
+		if san.Type == "DNS" {
+			c.SANs = append(c.SANs, san.Value)
+		}
+		c.SubjectAltNames = append(c.SubjectAltNames, SAN{Type: san.Type, Value: san.Value, Note: san.Note})
+	}
+
+	c.SCTs = newSCTs(info.SCTs)
+
+	c.Extensions = newExtensions(info.Extensions)
+
+	if info.Certificate != nil {
+		c.DER = info.Certificate.Raw
+		c.PEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: info.Certificate.Raw}))
+	}
+
+	return c
+}
+
+func newCertRef(chainInfo *cert.ChainInfo, c *x509.Certificate) CertRef {
+	return CertRef{
+		Index:   certIndex(chainInfo, c),
+		Subject: c.Subject.String(),
+		Issuer:  c.Issuer.String(),
+	}
+}
+
+func certIndex(chainInfo *cert.ChainInfo, c *x509.Certificate) int {
+	for i, info := range chainInfo.Certificates {
+		if info.Certificate == c || (info.Certificate != nil && info.Certificate.Equal(c)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func newPublicKey(key cert.PublicKeyDetails) PublicKey {
+	return PublicKey{
+		Algorithm:  key.Algorithm,
+		Size:       key.Size,
+		Curve:      key.Curve,
+		Exponent:   key.Exponent,
+		SPKISHA256: key.SPKISHA256,
+	}
+}
+
+func newLintFindings(findings []cert.LintFinding) []LintFinding {
+	out := []LintFinding{}
+	for _, finding := range findings {
+		out = append(out, LintFinding{
+			ID:               finding.ID,
+			Severity:         finding.Severity,
+			Citation:         finding.Citation,
+			CertificateIndex: finding.Certificate,
+			Message:          finding.Message,
+		})
+	}
+	return out
+}
+
+func newExtensions(exts []cert.ExtensionInfo) []Extension {
+	out := []Extension{}
+	for _, ext := range exts {
+		out = append(out, Extension{
+			OID:      ext.OID,
+			Name:     ext.Name,
+			Critical: ext.Critical,
+			Value:    ext.Value,
+			Fields:   newExtensionFields(ext.Fields),
+			Raw:      ext.Raw,
+		})
+	}
+	return out
+}
+
+func newExtensionFields(fields []cert.ExtensionField) []ExtensionField {
+	out := []ExtensionField{}
+	for _, f := range fields {
+		out = append(out, ExtensionField{Name: f.Name, Value: f.Value})
+	}
+	return out
+}
+
+func newSCTs(scts []cert.SCTInfo) []SCT {
+	out := []SCT{}
+	for _, sct := range scts {
+		s := SCT{
+			Version:            sct.Version + 1,
+			LogID:              sct.LogIDBase64(),
+			Timestamp:          sct.Timestamp,
+			HashAlgorithm:      sct.HashAlgorithm,
+			SignatureAlgorithm: sct.SigAlgorithm,
+			SignatureValid:     sct.SignatureValid,
+			SignatureError:     sct.SignatureError,
+		}
+		if sct.Log != nil {
+			s.LogDescription = sct.Log.Description
+			s.LogOperator = sct.Log.Operator
+			s.LogURL = sct.Log.URL
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func newScan(scan *cert.ScanResult) *Scan {
+	s := &Scan{
+		Grade:        scan.Grade,
+		Issues:       nonNil(scan.Issues),
+		Versions:     []ScanVersion{},
+		CipherSuites: []ScanCipher{},
+		Groups:       []ScanGroup{},
+	}
+	for _, v := range scan.Versions {
+		s.Versions = append(s.Versions, ScanVersion{Version: v.Version, Supported: v.Supported, Legacy: v.Legacy})
+	}
+	for _, c := range scan.CipherSuites {
+		s.CipherSuites = append(s.CipherSuites, ScanCipher{
+			Name:           c.Name,
+			Version:        c.Version,
+			ForwardSecrecy: c.ForwardSecrecy,
+			Weak:           c.Weak,
+			Reason:         c.Reason,
+		})
+	}
+	for _, g := range scan.Groups {
+		s.Groups = append(s.Groups, ScanGroup{Name: g.Name, PostQuantum: g.PostQuantum, Supported: g.Supported})
+	}
+	return s
+}